@@ -6,17 +6,41 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/lib/pq"
 	db "github.com/xmeizh/simplebank/db/postgresql"
 	"github.com/xmeizh/simplebank/token"
+	"github.com/xmeizh/simplebank/util"
+	"github.com/xmeizh/simplebank/val"
 )
 
 type createAccountRequest struct {
 	Currency string `json:"currency" binding:"required,currency"`
 }
 
+// accountResponse adds the balance formatted in the decimal places of the currency, like the gRPC API does
+type accountResponse struct {
+	db.Account
+	FormattedBalance string `json:"formatted_balance"`
+}
+
+func newAccountResponse(account db.Account) accountResponse {
+	return accountResponse{
+		Account:          account,
+		FormattedBalance: util.FormatAmount(account.Balance, account.Currency),
+	}
+}
+
+func newAccountsResponse(accounts []db.Account) []accountResponse {
+	rsp := make([]accountResponse, 0, len(accounts))
+	for _, account := range accounts {
+		rsp = append(rsp, newAccountResponse(account))
+	}
+	return rsp
+}
+
 func (server *Server) createAccount(ctx *gin.Context) {
 	var req createAccountRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -25,13 +49,15 @@ func (server *Server) createAccount(ctx *gin.Context) {
 	}
 
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-	arg := db.CreateAccountParams{
-		Owner:    authPayload.Username,
-		Currency: req.Currency,
-		Balance:  0,
+	arg := db.CreateAccountTxParams{
+		CreateAccountParams: db.CreateAccountParams{
+			Owner:    authPayload.Username,
+			Currency: req.Currency,
+			Balance:  0,
+		},
 	}
 
-	account, err := server.store.CreateAccount(ctx, arg)
+	result, err := server.store.CreateAccountTx(ctx, arg)
 	if err != nil {
 		if pqErr, ok := err.(*pq.Error); ok {
 			switch pqErr.Code.Name() {
@@ -46,7 +72,7 @@ func (server *Server) createAccount(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, account)
+	ctx.JSON(http.StatusOK, newAccountResponse(result.Account))
 }
 
 type getAccountRequest struct {
@@ -77,12 +103,95 @@ func (server *Server) getAccount(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, account)
+	ctx.JSON(http.StatusOK, newAccountResponse(account))
+}
+
+type getAccountStatementURI struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
 }
 
+type getAccountStatementQuery struct {
+	FromTime time.Time `form:"from_time" time_format:"2006-01-02T15:04:05Z07:00" binding:"required"`
+	ToTime   time.Time `form:"to_time" time_format:"2006-01-02T15:04:05Z07:00" binding:"required"`
+}
+
+type accountStatementResponse struct {
+	Account        accountResponse                     `json:"account"`
+	FromTime       time.Time                           `json:"from_time"`
+	ToTime         time.Time                           `json:"to_time"`
+	OpeningBalance int64                               `json:"opening_balance"`
+	ClosingBalance int64                               `json:"closing_balance"`
+	Entries        []db.ListAccountStatementEntriesRow `json:"entries"`
+}
+
+func (server *Server) getAccountStatement(ctx *gin.Context) {
+	var uri getAccountStatementURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	var req getAccountStatementQuery
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	if err := val.ValidateStatementPeriod(req.FromTime, req.ToTime); err != nil {
+		err = fmt.Errorf("invalid statement period: %w", err)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	account, err := server.store.GetAccount(ctx, uri.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if account.Owner != authPayload.Username {
+		err := errors.New("account doesn't belong to the user")
+		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		return
+	}
+
+	statement, err := server.store.AccountStatementTx(ctx, db.AccountStatementTxParams{
+		AccountID: account.ID,
+		FromTime:  req.FromTime,
+		ToTime:    req.ToTime,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp := accountStatementResponse{
+		Account:        newAccountResponse(account),
+		FromTime:       req.FromTime,
+		ToTime:         req.ToTime,
+		OpeningBalance: statement.OpeningBalance,
+		ClosingBalance: statement.ClosingBalance,
+		Entries:        statement.Entries,
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// listAccountsRequest paginates either by page_id, which is kept for existing clients,
+// or by page_token, the next_page_token of the previous page
 type listAccountsRequest struct {
-	PageID   int32 `form:"page_id" binding:"required,min=1"`
-	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+	PageID    int32  `form:"page_id" binding:"omitempty,min=1"`
+	PageSize  int32  `form:"page_size" binding:"required,min=5,max=10"`
+	PageToken string `form:"page_token" binding:"excluded_with=PageID"`
+}
+
+type listAccountsResponse struct {
+	Accounts      []accountResponse `json:"accounts"`
+	NextPageToken string            `json:"next_page_token"`
 }
 
 func (server *Server) listAccounts(ctx *gin.Context) {
@@ -93,18 +202,52 @@ func (server *Server) listAccounts(ctx *gin.Context) {
 	}
 
 	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-	arg := db.ListAccountsParams{
-		Owner:  authPayload.Username,
-		Limit:  req.PageSize,
-		Offset: (req.PageID - 1) * req.PageSize,
+
+	if req.PageID > 0 {
+		arg := db.ListAccountsParams{
+			Owner:  authPayload.Username,
+			Limit:  req.PageSize,
+			Offset: (req.PageID - 1) * req.PageSize,
+		}
+
+		accounts, err := server.store.ListAccounts(ctx, arg)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusOK, newAccountsResponse(accounts))
+		return
 	}
 
-	accounts, err := server.store.ListAccounts(ctx, arg)
+	scope := "accounts:" + authPayload.Username
+	after, err := util.DecodePageToken(req.PageToken, scope)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return
+	}
+
+	// load one more account to find out whether there is a next page
+	arg := db.ListAccountsAfterParams{
+		Owner:          authPayload.Username,
+		AfterCreatedAt: after.CreatedAt,
+		AfterID:        after.ID,
+		Limit:          req.PageSize + 1,
+	}
+
+	accounts, err := server.store.ListAccountsAfter(ctx, arg)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
-	ctx.JSON(http.StatusOK, accounts)
+
+	rsp := listAccountsResponse{}
+	if int32(len(accounts)) > req.PageSize {
+		accounts = accounts[:req.PageSize]
+		last := accounts[req.PageSize-1]
+		rsp.NextPageToken = util.EncodePageToken(scope, util.PageCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+	rsp.Accounts = newAccountsResponse(accounts)
+	ctx.JSON(http.StatusOK, rsp)
 }
 
 type deleteAccountRequest struct {
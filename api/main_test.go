@@ -1,26 +1,66 @@
 package api
 
 import (
+	"context"
 	"os"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/fx"
+	"github.com/xmeizh/simplebank/token"
 	"github.com/xmeizh/simplebank/util"
 )
 
+// testRates has no CAD rate, so CAD accounts can't receive cross-currency transfers
+var testRates = fx.Rates{
+	Base: util.USD,
+	Rates: map[string]float64{
+		util.EUR: 0.5,
+	},
+}
+
+// tokenChecker returns err for every token, the revocation package tests the real checks
+type tokenChecker struct {
+	err error
+}
+
+func (checker tokenChecker) CheckToken(ctx context.Context, sessionID uuid.UUID, issuedAt time.Time) error {
+	return checker.err
+}
+
+// loginGuard returns checkErr for every login and recordErr for every failure, the lockout package tests the real guard
+type loginGuard struct {
+	checkErr  error
+	recordErr error
+}
+
+func (guard loginGuard) Check(ctx context.Context, username string, clientIP string) error {
+	return guard.checkErr
+}
+
+func (guard loginGuard) RecordFailure(ctx context.Context, username string, clientIP string) error {
+	return guard.recordErr
+}
+
+func (guard loginGuard) RecordSuccess(ctx context.Context, username string) error {
+	return nil
+}
+
 func newTestServer(t *testing.T, store db.Store) *Server {
 	config := util.Config{
-		TokenSymmetricKey:   util.RandomString(32),
-		AccessTokenDuration: time.Minute,
+		AccessTokenDuration:  time.Minute,
+		MFAChallengeDuration: time.Minute,
+		TOTPIssuer:           "SimpleBank",
 	}
 
-	server, err := NewServer(config, store)
+	tokenMaker, err := token.NewPasetoMaker(util.RandomString(32))
 	require.NoError(t, err)
 
-	return server
+	return NewServer(config, store, tokenMaker, testRates, tokenChecker{}, loginGuard{})
 }
 
 func TestMain(m *testing.M) {
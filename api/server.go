@@ -1,34 +1,43 @@
 package api
 
 import (
-	"fmt"
-
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
 	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/fx"
+	"github.com/xmeizh/simplebank/lockout"
+	"github.com/xmeizh/simplebank/revocation"
 	"github.com/xmeizh/simplebank/token"
 	"github.com/xmeizh/simplebank/util"
 )
 
 // Server serves HTTP requests for our banking service
 type Server struct {
-	config     util.Config
-	store      db.Store
-	tokenMaker token.Maker
-	router     *gin.Engine
+	config            util.Config
+	store             db.Store
+	tokenMaker        token.Maker
+	rateProvider      fx.RateProvider
+	revocationChecker revocation.Checker
+	loginGuard        lockout.Guard
+	router            *gin.Engine
 }
 
-func NewServer(config util.Config, store db.Store) (*Server, error) {
-	tokenMaker, err := token.NewPasetoMaker(config.TokenSymmetricKey)
-	if err != nil {
-		return nil, fmt.Errorf("cannot create token maker: %w", err)
-	}
-
+func NewServer(
+	config util.Config,
+	store db.Store,
+	tokenMaker token.Maker,
+	rateProvider fx.RateProvider,
+	revocationChecker revocation.Checker,
+	loginGuard lockout.Guard,
+) *Server {
 	server := &Server{
-		config:     config,
-		store:      store,
-		tokenMaker: tokenMaker,
+		config:            config,
+		store:             store,
+		tokenMaker:        tokenMaker,
+		rateProvider:      rateProvider,
+		revocationChecker: revocationChecker,
+		loginGuard:        loginGuard,
 	}
 
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
@@ -36,7 +45,7 @@ func NewServer(config util.Config, store db.Store) (*Server, error) {
 	}
 
 	server.setupRouter()
-	return server, nil
+	return server
 }
 
 func (server *Server) setupRouter() {
@@ -44,15 +53,17 @@ func (server *Server) setupRouter() {
 
 	router.POST("/users", server.createUser)
 	router.POST("/users/login", server.loginUser)
+	router.POST("/users/login/mfa", server.verifyMFA)
 	router.POST("/tokens/renew_access", server.renewAccessToken)
 
 	// Group using gin.BasicAuth() middleware
 	// gin.Accounts is a shortcut for map[string]string
-	authRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker))
+	authRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker, server.revocationChecker))
 
 	authRoutes.POST("/accounts", server.createAccount)
 	authRoutes.GET("/accounts", server.listAccounts)
 	authRoutes.GET("/accounts/:id", server.getAccount)
+	authRoutes.GET("/accounts/:id/statement", server.getAccountStatement)
 	authRoutes.DELETE("/accounts/:id", server.deleteAccount)
 	authRoutes.POST("/transfers", server.createTransfer)
 
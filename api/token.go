@@ -2,11 +2,15 @@ package api
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/revocation"
 )
 
 type renewAccessTokenRequest struct {
@@ -14,10 +18,17 @@ type renewAccessTokenRequest struct {
 }
 
 type renewAccessTokenResponse struct {
-	AccessToken          string    `json:"access_token"`
-	AccessTokenExpiresAt time.Time `json:"access_token_expires_at"`
+	AccessToken           string    `json:"access_token"`
+	AccessTokenExpiresAt  time.Time `json:"access_token_expires_at"`
+	RefreshToken          string    `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
 }
 
+// errRefreshTokenReused is returned when a refresh token is presented after it was exchanged for a new one.
+// Only a stolen copy of the token can do that, so every session of its family is blocked.
+var errRefreshTokenReused = errors.New("refresh token has already been used")
+
+// renewAccessToken exchanges a refresh token for a new access token and a new refresh token
 func (server *Server) renewAccessToken(ctx *gin.Context) {
 	var req renewAccessTokenRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -65,15 +76,69 @@ func (server *Server) renewAccessToken(ctx *gin.Context) {
 		return
 	}
 
-	accessToken, accessPayload, err := server.tokenMaker.CreateToken(session.Username, refreshPayload.Role, server.config.AccessTokenDuration)
+	if session.RotatedAt.Valid {
+		server.blockSessionFamily(ctx, session)
+		return
+	}
+
+	err = server.revocationChecker.CheckToken(ctx, session.ID, refreshPayload.IssueAt)
+	if err != nil {
+		if errors.Is(err, revocation.ErrTokenRevoked) {
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// the new refresh token expires with the session created at login, rotating doesn't extend it
+	refreshToken, newRefreshPayload, err := server.tokenMaker.CreateToken(session.Username, refreshPayload.Role, uuid.Nil, time.Until(session.ExpiresAt))
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(session.Username, refreshPayload.Role, newRefreshPayload.ID, server.config.AccessTokenDuration)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	_, err = server.store.RotateSessionTx(ctx, db.RotateSessionTxParams{
+		SessionID: session.ID,
+		NewSession: db.CreateSessionParams{
+			ID:          newRefreshPayload.ID,
+			Username:    session.Username,
+			RefeshToken: refreshToken,
+			UserAgent:   ctx.Request.UserAgent(),
+			ClientIp:    ctx.ClientIP(),
+			IsBlocked:   false,
+			ExpiresAt:   newRefreshPayload.ExpiredAt,
+		},
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrSessionRotated) {
+			server.blockSessionFamily(ctx, session)
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
 	rsp := renewAccessTokenResponse{
-		AccessToken:          accessToken,
-		AccessTokenExpiresAt: accessPayload.ExpiredAt,
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessPayload.ExpiredAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: newRefreshPayload.ExpiredAt,
 	}
 	ctx.JSON(http.StatusOK, rsp)
 }
+
+func (server *Server) blockSessionFamily(ctx *gin.Context, session db.Session) {
+	err := server.store.BlockSessionFamily(ctx, session.FamilyID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusUnauthorized, errorResponse(errRefreshTokenReused))
+}
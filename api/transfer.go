@@ -8,7 +8,16 @@ import (
 
 	"github.com/gin-gonic/gin"
 	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/fx"
 	"github.com/xmeizh/simplebank/token"
+	"github.com/xmeizh/simplebank/util"
+	"github.com/xmeizh/simplebank/worker"
+)
+
+// The idempotency key header lets clients safely retry a transfer request
+const (
+	idempotencyKeyHeader    = "Idempotency-Key"
+	maxIdempotencyKeyLength = 100
 )
 
 type transferRequest struct {
@@ -18,6 +27,33 @@ type transferRequest struct {
 	Currency      string `json:"currency" binding:"required,currency"`
 }
 
+// transferResponse adds the amounts formatted in the decimal places of the currency of each account
+type transferResponse struct {
+	Transfer            db.Transfer     `json:"transfer"`
+	FromAccount         accountResponse `json:"from_account"`
+	ToAccount           accountResponse `json:"to_account"`
+	FromEntry           db.Entry        `json:"from_entry"`
+	ToEntry             db.Entry        `json:"to_entry"`
+	FromAmount          int64           `json:"from_amount"`
+	ToAmount            int64           `json:"to_amount"`
+	FormattedFromAmount string          `json:"formatted_from_amount"`
+	FormattedToAmount   string          `json:"formatted_to_amount"`
+}
+
+func newTransferResponse(result db.TransferTxResult) transferResponse {
+	return transferResponse{
+		Transfer:            result.Transfer,
+		FromAccount:         newAccountResponse(result.FromAccount),
+		ToAccount:           newAccountResponse(result.ToAccount),
+		FromEntry:           result.FromEntry,
+		ToEntry:             result.ToEntry,
+		FromAmount:          result.FromAmount,
+		ToAmount:            result.ToAmount,
+		FormattedFromAmount: util.FormatAmount(result.FromAmount, result.FromAccount.Currency),
+		FormattedToAmount:   util.FormatAmount(result.ToAmount, result.ToAccount.Currency),
+	}
+}
+
 func (server *Server) createTransfer(ctx *gin.Context) {
 	var req transferRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -37,7 +73,7 @@ func (server *Server) createTransfer(ctx *gin.Context) {
 		return
 	}
 
-	_, valid = server.validAccount(ctx, req.ToAccountID, req.Currency)
+	toAccount, valid := server.findAccount(ctx, req.ToAccountID)
 	if !valid {
 		return
 	}
@@ -46,18 +82,62 @@ func (server *Server) createTransfer(ctx *gin.Context) {
 		FromAccountID: req.FromAccountID,
 		ToAccountID:   req.ToAccountID,
 		Amount:        req.Amount,
+		Currency:      req.Currency,
+		AfterTransfer: func(q db.Querier, result db.TransferTxResult) error {
+			return worker.DistributeTransferNotifications(ctx, worker.NewOutboxTaskDistributor(q), result.Transfer)
+		},
+	}
+
+	if toAccount.Currency != fromAccount.Currency {
+		arg.ConvertedAmount, arg.ExchangeRate, valid = server.convertAmount(ctx, req.Amount, fromAccount.Currency, toAccount.Currency)
+		if !valid {
+			return
+		}
+	}
+
+	if idempotencyKey := ctx.GetHeader(idempotencyKeyHeader); idempotencyKey != "" {
+		if len(idempotencyKey) > maxIdempotencyKeyLength {
+			err := fmt.Errorf("%s header must contain at most %d characters", idempotencyKeyHeader, maxIdempotencyKeyLength)
+			ctx.JSON(http.StatusBadRequest, errorResponse(err))
+			return
+		}
+		arg.Username = authPayload.Username
+		arg.IdempotencyKey = idempotencyKey
 	}
 
-	account, err := server.store.TransferTx(ctx, arg)
+	result, err := server.store.TransferTx(ctx, arg)
 	if err != nil {
+		if errors.Is(err, db.ErrIdempotencyKeyConflict) {
+			ctx.JSON(http.StatusConflict, errorResponse(err))
+			return
+		}
+		if errors.Is(err, db.ErrInsufficientFunds) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, account)
+	ctx.JSON(http.StatusOK, newTransferResponse(result))
 }
 
 func (server *Server) validAccount(ctx *gin.Context, accountID int64, currency string) (db.Account, bool) {
+	account, valid := server.findAccount(ctx, accountID)
+	if !valid {
+		return db.Account{}, false
+	}
+
+	if account.Currency != currency {
+		err := fmt.Errorf("account [%d] currency mismatch: %s vs %s", account.ID, account.Currency, currency)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return db.Account{}, false
+
+	}
+	return account, true
+}
+
+func (server *Server) findAccount(ctx *gin.Context, accountID int64) (db.Account, bool) {
 	account, err := server.store.GetAccount(ctx, accountID)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -67,12 +147,45 @@ func (server *Server) validAccount(ctx *gin.Context, accountID int64, currency s
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return db.Account{}, false
 	}
+	return account, true
+}
 
-	if account.Currency != currency {
-		err := fmt.Errorf("account [%d] currency mismatch: %s vs %s", account.ID, account.Currency, currency)
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
-		return db.Account{}, false
+// convertAmount converts the amount between currencies at the current exchange rate
+func (server *Server) convertAmount(ctx *gin.Context, amount int64, from string, to string) (int64, float64, bool) {
+	rate, err := server.rateProvider.Rate(ctx, from, to)
+	if err != nil {
+		if errors.Is(err, fx.ErrRateNotFound) {
+			ctx.JSON(http.StatusUnprocessableEntity, errorResponse(err))
+			return 0, 0, false
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return 0, 0, false
+	}
 
+	fromCurrency, ok := util.GetCurrency(from)
+	if !ok {
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(fmt.Errorf("unknown currency %s", from)))
+		return 0, 0, false
 	}
-	return account, true
+
+	toCurrency, ok := util.GetCurrency(to)
+	if !ok {
+		ctx.JSON(http.StatusUnprocessableEntity, errorResponse(fmt.Errorf("unknown currency %s", to)))
+		return 0, 0, false
+	}
+
+	convertedAmount, err := fx.Convert(amount, rate, fromCurrency.MinorUnits, toCurrency.MinorUnits)
+	if err != nil {
+		err := fmt.Errorf("amount %d %s can't be converted to %s: %w", amount, from, to, err)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return 0, 0, false
+	}
+	if convertedAmount <= 0 {
+		err := fmt.Errorf("amount %d %s is too small to convert to %s", amount, from, to)
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		return 0, 0, false
+	}
+
+	exchangeRate, _ := rate.Float64()
+	return convertedAmount, exchangeRate, true
 }
@@ -2,15 +2,20 @@ package api
 
 import (
 	"database/sql"
+	"errors"
 	"log"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
 	"github.com/lib/pq"
 	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/lockout"
+	"github.com/xmeizh/simplebank/mfa"
 	"github.com/xmeizh/simplebank/util"
+	"github.com/xmeizh/simplebank/worker"
 )
 
 type createUserRequest struct {
@@ -51,14 +56,29 @@ func (server *Server) createUser(ctx *gin.Context) {
 		return
 	}
 
-	arg := db.CreateUserParams{
-		Username:       req.Username,
-		HashedPassword: hashedPassword,
-		FullName:       req.FullName,
-		Email:          req.Email,
+	// created like the gRPC API does, so the user is granted their role and can verify their email
+	arg := db.CreateUserTxParams{
+		CreateUserParams: db.CreateUserParams{
+			Username:       req.Username,
+			HashedPassword: hashedPassword,
+			FullName:       req.FullName,
+			Email:          req.Email,
+		},
+		AfterCreate: func(q db.Querier, user db.User) error {
+			payload := &worker.SendVerificationEmailPayload{
+				Username: user.Username,
+			}
+			opts := []asynq.Option{
+				asynq.MaxRetry(10),
+				asynq.Queue(worker.QueueCritical),
+			}
+			// written to the outbox, so the email is only sent if the user is committed
+			outbox := worker.NewOutboxTaskDistributor(q)
+			return outbox.DistributeTaskSendVerificationEmail(ctx, payload, opts...)
+		},
 	}
 
-	user, err := server.store.CreateUser(ctx, arg)
+	result, err := server.store.CreateUserTx(ctx, arg)
 	if err != nil {
 		if pqErr, ok := err.(*pq.Error); ok {
 			switch pqErr.Code.Name() {
@@ -73,7 +93,7 @@ func (server *Server) createUser(ctx *gin.Context) {
 		return
 	}
 
-	rsp := newUserResponse(user)
+	rsp := newUserResponse(result.User)
 	ctx.JSON(http.StatusOK, rsp)
 }
 
@@ -91,6 +111,8 @@ type loginUserResponse struct {
 	RefreshTokenExpiresAt time.Time    `json:"refresh_token_expires_at"`
 }
 
+var errInvalidLogin = errors.New("invalid username or password")
+
 func (server *Server) loginUser(ctx *gin.Context) {
 	var req loginUserRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -98,36 +120,155 @@ func (server *Server) loginUser(ctx *gin.Context) {
 		return
 	}
 
+	err := server.loginGuard.Check(ctx, req.Username, ctx.ClientIP())
+	if err != nil {
+		if errors.Is(err, lockout.ErrLoginThrottled) {
+			ctx.JSON(http.StatusTooManyRequests, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	// unknown users and wrong passwords get the same error, so the response does not reveal which usernames exist
 	user, err := server.store.GetUser(ctx, req.Username)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-		} else {
+		if err != sql.ErrNoRows {
 			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
 		}
+		err = util.CheckPasswordOfNoUser(req.Password)
+	} else {
+		err = util.CheckPassword(req.Password, user.HashedPassword)
+	}
+	if err != nil {
+		err = server.loginGuard.RecordFailure(ctx, req.Username, ctx.ClientIP())
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+		ctx.JSON(http.StatusUnauthorized, errorResponse(errInvalidLogin))
+		return
+	}
+
+	// the failed attempts of users with MFA are only cleared once they pass it
+	if user.IsMfaEnabled {
+		challengeToken, challengePayload, err := mfa.CreateChallenge(ctx, server.store, server.tokenMaker, user, server.config.MFAChallengeDuration)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			return
+		}
+
+		rsp := mfaChallengeResponse{
+			MFAChallengeToken:     challengeToken,
+			MFAChallengeExpiresAt: challengePayload.ExpiredAt,
+			User:                  newUserResponse(user),
+		}
+		ctx.JSON(http.StatusOK, rsp)
+		return
+	}
+
+	err = server.loginGuard.RecordSuccess(ctx, user.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp, err := server.createSession(ctx, user)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// mfaChallengeResponse is returned by loginUser instead of the tokens when the user has MFA enabled
+type mfaChallengeResponse struct {
+	MFAChallengeToken     string       `json:"mfa_challenge_token"`
+	MFAChallengeExpiresAt time.Time    `json:"mfa_challenge_expires_at"`
+	User                  userResponse `json:"user"`
+}
+
+type verifyMFARequest struct {
+	MFAChallengeToken string `json:"mfa_challenge_token" binding:"required"`
+	Code              string `json:"code" binding:"required,min=6,max=20"`
+}
+
+func (server *Server) verifyMFA(ctx *gin.Context) {
+	var req verifyMFARequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, errorResponse(err))
 		return
 	}
 
-	err = util.CheckPassword(req.Password, user.HashedPassword)
+	challengePayload, err := server.tokenMaker.VerifyToken(req.MFAChallengeToken)
 	if err != nil {
 		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
 		return
 	}
 
-	accessToken, accessPayload, err := server.tokenMaker.CreateToken(req.Username, user.Role, server.config.AccessTokenDuration)
+	err = server.loginGuard.Check(ctx, challengePayload.Username, ctx.ClientIP())
 	if err != nil {
+		if errors.Is(err, lockout.ErrLoginThrottled) {
+			ctx.JSON(http.StatusTooManyRequests, errorResponse(err))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
 		return
 	}
 
+	user, err := mfa.VerifyChallenge(ctx, server.store, challengePayload.ID, req.Code)
+	if err != nil {
+		// wrong codes count against the user like wrong passwords, so they can't be guessed with one challenge after another
+		if errors.Is(err, mfa.ErrInvalidCode) || errors.Is(err, mfa.ErrTooManyAttempts) {
+			recordErr := server.loginGuard.RecordFailure(ctx, challengePayload.Username, ctx.ClientIP())
+			if recordErr != nil {
+				ctx.JSON(http.StatusInternalServerError, errorResponse(recordErr))
+				return
+			}
+		}
+
+		switch {
+		case errors.Is(err, mfa.ErrInvalidChallenge), errors.Is(err, mfa.ErrInvalidCode):
+			ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+		case errors.Is(err, mfa.ErrTooManyAttempts):
+			ctx.JSON(http.StatusTooManyRequests, errorResponse(err))
+		default:
+			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		}
+		return
+	}
+
+	err = server.loginGuard.RecordSuccess(ctx, user.Username)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+
+	rsp, err := server.createSession(ctx, user)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		return
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+// createSession starts a new session family for a user who passed all login checks
+func (server *Server) createSession(ctx *gin.Context, user db.User) (loginUserResponse, error) {
 	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(
 		user.Username,
 		user.Role,
+		uuid.Nil,
 		server.config.RefreshTokenDuration,
 	)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-		return
+		return loginUserResponse{}, err
+	}
+
+	// the session is identified by the refresh token, the access token is bound to it so it can be revoked
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(user.Username, user.Role, refreshPayload.ID, server.config.AccessTokenDuration)
+	if err != nil {
+		return loginUserResponse{}, err
 	}
 
 	session, err := server.store.CreateSession(ctx, db.CreateSessionParams{
@@ -138,11 +279,10 @@ func (server *Server) loginUser(ctx *gin.Context) {
 		ClientIp:    ctx.ClientIP(),
 		IsBlocked:   false,
 		ExpiresAt:   refreshPayload.ExpiredAt,
+		FamilyID:    refreshPayload.ID,
 	})
-
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
-		return
+		return loginUserResponse{}, err
 	}
 
 	rsp := loginUserResponse{
@@ -153,5 +293,5 @@ func (server *Server) loginUser(ctx *gin.Context) {
 		RefreshToken:          refreshToken,
 		RefreshTokenExpiresAt: refreshPayload.ExpiredAt,
 	}
-	ctx.JSON(http.StatusOK, rsp)
+	return rsp, nil
 }
@@ -2,6 +2,7 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -10,22 +11,28 @@ import (
 	"net/http/httptest"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 	mockdb "github.com/xmeizh/simplebank/db/mock"
 	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/lockout"
+	"github.com/xmeizh/simplebank/mfa"
+	"github.com/xmeizh/simplebank/token"
 	"github.com/xmeizh/simplebank/util"
+	"github.com/xmeizh/simplebank/worker"
 )
 
-type eqCreateUserParamsMatcher struct {
+type eqCreateUserTxParamsMatcher struct {
 	arg      db.CreateUserParams
 	password string
 }
 
-func (e eqCreateUserParamsMatcher) Matches(x interface{}) bool {
-	arg, ok := x.(db.CreateUserParams)
+func (e eqCreateUserTxParamsMatcher) Matches(x interface{}) bool {
+	arg, ok := x.(db.CreateUserTxParams)
 	if !ok {
 		return false
 	}
@@ -37,15 +44,15 @@ func (e eqCreateUserParamsMatcher) Matches(x interface{}) bool {
 	}
 
 	e.arg.HashedPassword = arg.HashedPassword
-	return reflect.DeepEqual(e.arg, arg)
+	return reflect.DeepEqual(e.arg, arg.CreateUserParams)
 }
 
-func (e eqCreateUserParamsMatcher) String() string {
+func (e eqCreateUserTxParamsMatcher) String() string {
 	return fmt.Sprintf("matches arg %v and password %v", e.arg, e.password)
 }
 
-func EqCreateUserParams(arg db.CreateUserParams, password string) gomock.Matcher {
-	return eqCreateUserParamsMatcher{arg, password}
+func EqCreateUserTxParams(arg db.CreateUserParams, password string) gomock.Matcher {
+	return eqCreateUserTxParamsMatcher{arg, password}
 }
 
 func TestCreateUserAPI(t *testing.T) {
@@ -71,7 +78,16 @@ func TestCreateUserAPI(t *testing.T) {
 					FullName: user.FullName,
 					Email:    user.Email,
 				}
-				store.EXPECT().CreateUser(gomock.Any(), EqCreateUserParams(arg, password)).Times(1).Return(user, nil)
+				// the verification email is written to the outbox in the transaction that creates the user
+				store.EXPECT().CreateOutboxMessage(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateOutboxMessageParams) (db.Outbox, error) {
+						require.Equal(t, worker.TaskSendVerificationEmail, arg.TaskType)
+						return db.Outbox{}, nil
+					})
+				store.EXPECT().CreateUserTx(gomock.Any(), EqCreateUserTxParams(arg, password)).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateUserTxParams) (db.CreateUserTxResult, error) {
+						return db.CreateUserTxResult{User: user}, arg.AfterCreate(store, user)
+					})
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, recorder.Code)
@@ -87,7 +103,7 @@ func TestCreateUserAPI(t *testing.T) {
 				"email":     user.Email,
 			},
 			buildStubs: func(store *mockdb.MockStore) {
-				store.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrConnDone)
+				store.EXPECT().CreateUserTx(gomock.Any(), gomock.Any()).Times(1).Return(db.CreateUserTxResult{}, sql.ErrConnDone)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusInternalServerError, recorder.Code)
@@ -102,7 +118,7 @@ func TestCreateUserAPI(t *testing.T) {
 				"email":     user.Email,
 			},
 			buildStubs: func(store *mockdb.MockStore) {
-				store.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateUserTx(gomock.Any(), gomock.Any()).Times(0)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusBadRequest, recorder.Code)
@@ -164,10 +180,13 @@ func randomUser(t *testing.T) (user db.User, password string) {
 
 func TestLoginUserAPI(t *testing.T) {
 	user, password := randomUser(t)
+	mfaUser := user
+	mfaUser.IsMfaEnabled = true
 
 	testCases := []struct {
 		name          string
 		body          gin.H
+		guard         loginGuard
 		buildStubs    func(store *mockdb.MockStore)
 		checkResponse func(recorder *httptest.ResponseRecorder)
 	}{
@@ -185,6 +204,27 @@ func TestLoginUserAPI(t *testing.T) {
 				require.Equal(t, http.StatusOK, recorder.Code)
 			},
 		},
+		{
+			name: "MFAChallenge",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(mfaUser, nil)
+				store.EXPECT().CreateMfaChallenge(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp gin.H
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.NotEmpty(t, rsp["mfa_challenge_token"])
+				require.NotContains(t, rsp, "access_token")
+			},
+		},
 		{
 			name: "InternalError",
 			body: gin.H{
@@ -222,6 +262,7 @@ func TestLoginUserAPI(t *testing.T) {
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.JSONEq(t, `{"error":"invalid username or password"}`, recorder.Body.String())
 			},
 		},
 		{
@@ -234,7 +275,36 @@ func TestLoginUserAPI(t *testing.T) {
 				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(db.User{}, sql.ErrNoRows)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusNotFound, recorder.Code)
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+				require.JSONEq(t, `{"error":"invalid username or password"}`, recorder.Body.String())
+			},
+		},
+		{
+			name: "Throttled",
+			body: gin.H{
+				"username": user.Username,
+				"password": password,
+			},
+			guard: loginGuard{checkErr: lockout.ErrLoginThrottled},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+			},
+		},
+		{
+			name: "RecordFailureError",
+			body: gin.H{
+				"username": user.Username,
+				"password": "incorrect",
+			},
+			guard: loginGuard{recordErr: sql.ErrConnDone},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
 			},
 		},
 	}
@@ -250,6 +320,7 @@ func TestLoginUserAPI(t *testing.T) {
 			tc.buildStubs(store)
 
 			server := newTestServer(t, store)
+			server.loginGuard = tc.guard
 			recorder := httptest.NewRecorder()
 
 			data, err := json.Marshal(tc.body)
@@ -264,3 +335,176 @@ func TestLoginUserAPI(t *testing.T) {
 		})
 	}
 }
+
+func TestVerifyMFAAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	user.IsMfaEnabled = true
+	user.TotpSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+	code, err := mfa.GenerateCode(user.TotpSecret, time.Now())
+	require.NoError(t, err)
+
+	newChallengeToken := func(t *testing.T, tokenMaker token.Maker) (string, *token.Payload) {
+		challengeToken, payload, err := tokenMaker.CreateToken(user.Username, user.Role, uuid.Nil, time.Minute)
+		require.NoError(t, err)
+		return challengeToken, payload
+	}
+
+	testCases := []struct {
+		name          string
+		guard         loginGuard
+		buildBody     func(t *testing.T, tokenMaker token.Maker) (gin.H, *token.Payload)
+		buildStubs    func(store *mockdb.MockStore, challengeID uuid.UUID)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildBody: func(t *testing.T, tokenMaker token.Maker) (gin.H, *token.Payload) {
+				challengeToken, payload := newChallengeToken(t, tokenMaker)
+				return gin.H{"mfa_challenge_token": challengeToken, "code": code}, payload
+			},
+			buildStubs: func(store *mockdb.MockStore, challengeID uuid.UUID) {
+				challenge := db.MfaChallenge{ID: challengeID, Username: user.Username, ExpiresAt: time.Now().Add(time.Minute)}
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Eq(challengeID)).Times(1).Return(challenge, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().UseTotpStep(gomock.Any(), gomock.Any()).Times(1).Return(int64(1), nil)
+				store.EXPECT().UseMfaChallenge(gomock.Any(), gomock.Eq(challengeID)).Times(1).Return(challenge, nil)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp gin.H
+				err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+				require.NoError(t, err)
+				require.NotEmpty(t, rsp["access_token"])
+				require.NotEmpty(t, rsp["refresh_token"])
+			},
+		},
+		{
+			name: "InvalidCode",
+			buildBody: func(t *testing.T, tokenMaker token.Maker) (gin.H, *token.Payload) {
+				challengeToken, payload := newChallengeToken(t, tokenMaker)
+				return gin.H{"mfa_challenge_token": challengeToken, "code": "000000"}, payload
+			},
+			buildStubs: func(store *mockdb.MockStore, challengeID uuid.UUID) {
+				challenge := db.MfaChallenge{ID: challengeID, Username: user.Username, ExpiresAt: time.Now().Add(time.Minute)}
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Eq(challengeID)).Times(1).Return(challenge, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().IncrementMfaChallengeFailedAttempts(gomock.Any(), gomock.Eq(challengeID)).Times(1).
+					Return(db.MfaChallenge{FailedAttempts: 1}, nil)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:  "RecordFailureError",
+			guard: loginGuard{recordErr: sql.ErrConnDone},
+			buildBody: func(t *testing.T, tokenMaker token.Maker) (gin.H, *token.Payload) {
+				challengeToken, payload := newChallengeToken(t, tokenMaker)
+				return gin.H{"mfa_challenge_token": challengeToken, "code": "000000"}, payload
+			},
+			buildStubs: func(store *mockdb.MockStore, challengeID uuid.UUID) {
+				challenge := db.MfaChallenge{ID: challengeID, Username: user.Username, ExpiresAt: time.Now().Add(time.Minute)}
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Eq(challengeID)).Times(1).Return(challenge, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().IncrementMfaChallengeFailedAttempts(gomock.Any(), gomock.Eq(challengeID)).Times(1).
+					Return(db.MfaChallenge{FailedAttempts: 1}, nil)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				// the failed attempt has to be counted by the lockout guard
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name:  "Throttled",
+			guard: loginGuard{checkErr: lockout.ErrLoginThrottled},
+			buildBody: func(t *testing.T, tokenMaker token.Maker) (gin.H, *token.Payload) {
+				challengeToken, payload := newChallengeToken(t, tokenMaker)
+				return gin.H{"mfa_challenge_token": challengeToken, "code": code}, payload
+			},
+			buildStubs: func(store *mockdb.MockStore, challengeID uuid.UUID) {
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+			},
+		},
+		{
+			name: "TooManyAttempts",
+			buildBody: func(t *testing.T, tokenMaker token.Maker) (gin.H, *token.Payload) {
+				challengeToken, payload := newChallengeToken(t, tokenMaker)
+				return gin.H{"mfa_challenge_token": challengeToken, "code": code}, payload
+			},
+			buildStubs: func(store *mockdb.MockStore, challengeID uuid.UUID) {
+				challenge := db.MfaChallenge{
+					ID:             challengeID,
+					Username:       user.Username,
+					FailedAttempts: mfa.MaxFailedAttempts,
+					ExpiresAt:      time.Now().Add(time.Minute),
+				}
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Eq(challengeID)).Times(1).Return(challenge, nil)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusTooManyRequests, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidChallengeToken",
+			buildBody: func(t *testing.T, tokenMaker token.Maker) (gin.H, *token.Payload) {
+				return gin.H{"mfa_challenge_token": "invalid", "code": code}, &token.Payload{}
+			},
+			buildStubs: func(store *mockdb.MockStore, challengeID uuid.UUID) {
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name: "MissingCode",
+			buildBody: func(t *testing.T, tokenMaker token.Maker) (gin.H, *token.Payload) {
+				challengeToken, payload := newChallengeToken(t, tokenMaker)
+				return gin.H{"mfa_challenge_token": challengeToken}, payload
+			},
+			buildStubs: func(store *mockdb.MockStore, challengeID uuid.UUID) {
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+			server.loginGuard = tc.guard
+			recorder := httptest.NewRecorder()
+
+			body, payload := tc.buildBody(t, server.tokenMaker)
+			tc.buildStubs(store, payload.ID)
+
+			data, err := json.Marshal(body)
+			require.NoError(t, err)
+
+			url := "/users/login/mfa"
+			req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, req)
+			tc.checkResponse(recorder)
+		})
+	}
+}
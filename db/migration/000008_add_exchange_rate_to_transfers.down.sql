@@ -0,0 +1,3 @@
+ALTER TABLE "transfers" DROP COLUMN "exchange_rate";
+
+ALTER TABLE "transfers" DROP COLUMN "converted_amount";
\ No newline at end of file
@@ -0,0 +1,7 @@
+ALTER TABLE "transfers" ADD COLUMN "converted_amount" bigint;
+
+UPDATE "transfers" SET "converted_amount" = "amount";
+
+ALTER TABLE "transfers" ALTER COLUMN "converted_amount" SET NOT NULL;
+
+ALTER TABLE "transfers" ADD COLUMN "exchange_rate" double precision NOT NULL DEFAULT 1;
@@ -0,0 +1,17 @@
+CREATE TABLE "currencies" (
+  "code" varchar PRIMARY KEY,
+  "minor_units" int NOT NULL,
+  "enabled" boolean NOT NULL DEFAULT false,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "currencies" ADD CONSTRAINT "minor_units_range" CHECK ("minor_units" BETWEEN 0 AND 4);
+
+INSERT INTO "currencies" ("code", "minor_units", "enabled") VALUES
+  ('USD', 2, true),
+  ('EUR', 2, true),
+  ('CAD', 2, true),
+  ('JPY', 0, false),
+  ('KWD', 3, false);
+
+ALTER TABLE "accounts" ADD FOREIGN KEY ("currency") REFERENCES "currencies" ("code");
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "account_unlocks";
+DROP TABLE IF EXISTS "login_failures";
\ No newline at end of file
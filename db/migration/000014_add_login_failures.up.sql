@@ -0,0 +1,25 @@
+CREATE TABLE "login_failures" (
+  "scope" varchar NOT NULL,
+  "key" varchar NOT NULL,
+  "failed_attempts" int NOT NULL DEFAULT 0,
+  "last_failed_at" timestamptz NOT NULL DEFAULT (now()),
+  "locked_until" timestamptz,
+  PRIMARY KEY ("scope", "key")
+);
+
+CREATE TABLE "account_unlocks" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "hashed_code" varchar NOT NULL,
+  "is_used" bool NOT NULL DEFAULT false,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "expired_at" timestamptz NOT NULL DEFAULT (now() + interval '1 hour')
+);
+
+COMMENT ON COLUMN "login_failures"."scope" IS 'username or client_ip';
+
+COMMENT ON COLUMN "login_failures"."key" IS 'not a foreign key, failures are also tracked for usernames that don''t exist';
+
+COMMENT ON COLUMN "account_unlocks"."hashed_code" IS 'SHA-256 hash of the secret code sent in the unlock link';
+
+ALTER TABLE "account_unlocks" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
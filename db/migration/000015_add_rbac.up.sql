@@ -0,0 +1,62 @@
+CREATE TABLE "roles" (
+  "name" varchar PRIMARY KEY,
+  "description" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "role_permissions" (
+  "role" varchar NOT NULL,
+  "permission" varchar NOT NULL,
+  PRIMARY KEY ("role", "permission")
+);
+
+CREATE TABLE "user_roles" (
+  "username" varchar NOT NULL,
+  "role" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("username", "role")
+);
+
+COMMENT ON COLUMN "role_permissions"."permission" IS 'resource:action:scope, the scope is own or any';
+
+ALTER TABLE "role_permissions" ADD FOREIGN KEY ("role") REFERENCES "roles" ("name") ON DELETE CASCADE;
+
+ALTER TABLE "user_roles" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "user_roles" ADD FOREIGN KEY ("role") REFERENCES "roles" ("name") ON DELETE CASCADE;
+
+INSERT INTO "roles" ("name", "description") VALUES
+  ('depositor', 'Customer managing their own accounts'),
+  ('banker', 'Employee who can view and manage the accounts of all customers'),
+  ('admin', 'Administrator who can grant and revoke roles');
+
+INSERT INTO "role_permissions" ("role", "permission") VALUES
+  ('depositor', 'users:update:own'),
+  ('depositor', 'mfa:manage:own'),
+  ('depositor', 'sessions:read:own'),
+  ('depositor', 'sessions:revoke:own'),
+  ('depositor', 'accounts:create:own'),
+  ('depositor', 'accounts:read:own'),
+  ('depositor', 'accounts:delete:own'),
+  ('depositor', 'transfers:create:own'),
+  ('depositor', 'transfers:read:own'),
+  ('depositor', 'scheduled_transfers:create:own'),
+  ('depositor', 'scheduled_transfers:read:own'),
+  ('depositor', 'scheduled_transfers:cancel:own'),
+  ('banker', 'users:update:any'),
+  ('banker', 'mfa:manage:own'),
+  ('banker', 'sessions:read:any'),
+  ('banker', 'sessions:revoke:any'),
+  ('banker', 'accounts:create:own'),
+  ('banker', 'accounts:read:any'),
+  ('banker', 'accounts:delete:any'),
+  ('banker', 'transfers:create:own'),
+  ('banker', 'transfers:read:any'),
+  ('banker', 'scheduled_transfers:create:own'),
+  ('banker', 'scheduled_transfers:read:own'),
+  ('banker', 'scheduled_transfers:cancel:any'),
+  ('admin', 'roles:manage:any');
+
+-- every existing user keeps the role they had, new users are granted theirs by CreateUserTx
+INSERT INTO "user_roles" ("username", "role")
+SELECT "username", "role" FROM "users";
@@ -0,0 +1,22 @@
+CREATE TABLE "outbox" (
+  "id" bigserial PRIMARY KEY,
+  "task_type" varchar NOT NULL,
+  "payload" bytea NOT NULL,
+  "queue" varchar,
+  "max_retry" int,
+  "process_at" timestamptz,
+  "attempts" int NOT NULL DEFAULT 0,
+  "last_error" varchar,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "sent_at" timestamptz
+);
+
+CREATE INDEX ON "outbox" ("sent_at", "id");
+
+COMMENT ON COLUMN "outbox"."queue" IS 'the task queue, or the distributor default if null';
+
+COMMENT ON COLUMN "outbox"."max_retry" IS 'the max number of retries, or the distributor default if null';
+
+COMMENT ON COLUMN "outbox"."attempts" IS 'failed attempts to publish the task';
+
+COMMENT ON COLUMN "outbox"."sent_at" IS 'set once the task has been published to the task queue';
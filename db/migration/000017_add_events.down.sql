@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "events";
\ No newline at end of file
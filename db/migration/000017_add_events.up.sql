@@ -0,0 +1,21 @@
+CREATE TABLE "events" (
+  "id" bigserial PRIMARY KEY,
+  "transaction_id" bigint NOT NULL DEFAULT (pg_current_xact_id()::text::bigint),
+  "type" varchar NOT NULL,
+  "username" varchar NOT NULL,
+  "account_id" bigint,
+  "payload" jsonb NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "events" ("username", "transaction_id", "id");
+
+CREATE INDEX ON "events" ("account_id", "transaction_id", "id");
+
+COMMENT ON COLUMN "events"."transaction_id" IS 'id of the transaction that recorded the event, events are streamed in transaction order';
+
+COMMENT ON COLUMN "events"."username" IS 'user the event is streamed to';
+
+COMMENT ON COLUMN "events"."account_id" IS 'account the event is about, not a foreign key so events outlive deleted accounts';
+
+ALTER TABLE "events" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
@@ -0,0 +1,3 @@
+DELETE FROM "role_permissions" WHERE "permission" LIKE 'webhooks:%';
+DROP TABLE IF EXISTS "webhook_deliveries";
+DROP TABLE IF EXISTS "webhook_endpoints";
\ No newline at end of file
@@ -0,0 +1,44 @@
+CREATE TABLE "webhook_endpoints" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "url" varchar NOT NULL,
+  "secret" varchar NOT NULL,
+  "event_types" varchar[] NOT NULL DEFAULT '{}',
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "webhook_deliveries" (
+  "id" bigserial PRIMARY KEY,
+  "endpoint_id" bigint NOT NULL,
+  "event_id" bigint NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "attempts" int NOT NULL DEFAULT 0,
+  "response_status" int,
+  "last_error" varchar NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "delivered_at" timestamptz
+);
+
+CREATE INDEX ON "webhook_endpoints" ("username");
+
+CREATE INDEX ON "webhook_deliveries" ("endpoint_id", "id");
+
+CREATE INDEX ON "webhook_deliveries" ("status", "id");
+
+COMMENT ON COLUMN "webhook_endpoints"."secret" IS 'key of the HMAC-SHA256 signature of the deliveries, kept in plain text to sign them';
+
+COMMENT ON COLUMN "webhook_endpoints"."event_types" IS 'types of the events delivered, all of them if empty';
+
+COMMENT ON COLUMN "webhook_deliveries"."status" IS 'pending, succeeded or failed once the retries are used up';
+
+COMMENT ON COLUMN "webhook_deliveries"."response_status" IS 'HTTP status of the last attempt, null if no response was received';
+
+ALTER TABLE "webhook_endpoints" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "webhook_deliveries" ADD FOREIGN KEY ("endpoint_id") REFERENCES "webhook_endpoints" ("id");
+
+ALTER TABLE "webhook_deliveries" ADD FOREIGN KEY ("event_id") REFERENCES "events" ("id");
+
+INSERT INTO "role_permissions" ("role", "permission") VALUES
+  ('depositor', 'webhooks:manage:own'),
+  ('banker', 'webhooks:manage:own');
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "notification_preferences";
\ No newline at end of file
@@ -0,0 +1,12 @@
+CREATE TABLE "notification_preferences" (
+  "username" varchar PRIMARY KEY,
+  "transfer_debit_emails" bool NOT NULL DEFAULT true,
+  "transfer_credit_emails" bool NOT NULL DEFAULT true,
+  "updated_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "notification_preferences"."transfer_debit_emails" IS 'email the user when money leaves one of their accounts, users without preferences get the default';
+
+COMMENT ON COLUMN "notification_preferences"."transfer_credit_emails" IS 'email the user when money arrives in one of their accounts';
+
+ALTER TABLE "notification_preferences" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
@@ -0,0 +1,3 @@
+ALTER TABLE "notification_preferences" ADD COLUMN "locale" varchar NOT NULL DEFAULT 'en';
+
+COMMENT ON COLUMN "notification_preferences"."locale" IS 'the locale of the emails sent to the user, like en or fr-CA';
@@ -0,0 +1,7 @@
+ALTER TABLE "outbox" ADD COLUMN "available_at" timestamptz NOT NULL DEFAULT (now());
+
+ALTER TABLE "outbox" ADD COLUMN "dead_at" timestamptz;
+
+COMMENT ON COLUMN "outbox"."available_at" IS 'the message is relayed from then on, failed messages back off';
+
+COMMENT ON COLUMN "outbox"."dead_at" IS 'set once the message failed too many times, it is no longer relayed';
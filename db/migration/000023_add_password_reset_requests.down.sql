@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "password_reset_requests";
\ No newline at end of file
@@ -0,0 +1,8 @@
+CREATE TABLE "password_reset_requests" (
+  "username" varchar PRIMARY KEY,
+  "requested_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+COMMENT ON COLUMN "password_reset_requests"."requested_at" IS 'when the last reset email was sent, another is only sent after a cooldown';
+
+ALTER TABLE "password_reset_requests" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
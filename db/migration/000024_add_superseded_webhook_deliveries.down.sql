@@ -0,0 +1 @@
+COMMENT ON COLUMN "webhook_deliveries"."status" IS 'pending, succeeded or failed once the retries are used up';
\ No newline at end of file
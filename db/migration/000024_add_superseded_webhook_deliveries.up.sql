@@ -0,0 +1 @@
+COMMENT ON COLUMN "webhook_deliveries"."status" IS 'pending, succeeded, failed once the retries are used up or superseded once redelivered';
@@ -96,3 +96,78 @@ func TestListAccounts(t *testing.T) {
 		require.Equal(t, lastAccount.Owner, account.Owner)
 	}
 }
+
+func TestListAccountsAfter(t *testing.T) {
+	user := createRandomUser(t)
+	currencies := []string{"USD", "EUR", "CAD"}
+	accounts := make([]Account, len(currencies))
+	for i, currency := range currencies {
+		account, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+			Owner:    user.Username,
+			Balance:  util.RandomMoney(),
+			Currency: currency,
+		})
+		require.NoError(t, err)
+		accounts[i] = account
+	}
+
+	arg := ListAccountsAfterParams{
+		Owner: user.Username,
+		Limit: 2,
+	}
+	page1, err := testQueries.ListAccountsAfter(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, accounts[:2], page1)
+
+	arg.AfterCreatedAt = page1[len(page1)-1].CreatedAt
+	arg.AfterID = page1[len(page1)-1].ID
+	page2, err := testQueries.ListAccountsAfter(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, accounts[2:], page2)
+
+	// a row committed late with a lower id than the cursor, e.g. by a concurrent transaction, isn't skipped
+	lateAccount, err := testQueries.CreateAccount(context.Background(), CreateAccountParams{
+		Owner:    user.Username,
+		Balance:  util.RandomMoney(),
+		Currency: "JPY",
+	})
+	require.NoError(t, err)
+	// negate the id so it sorts before the ids of the accounts already listed without colliding with another one
+	lateID := -lateAccount.ID
+	_, err = testDB.Exec("UPDATE accounts SET id = $1 WHERE id = $2", lateID, lateAccount.ID)
+	require.NoError(t, err)
+
+	arg.AfterCreatedAt = page2[len(page2)-1].CreatedAt
+	arg.AfterID = page2[len(page2)-1].ID
+	page3, err := testQueries.ListAccountsAfter(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, page3, 1)
+	require.Equal(t, lateID, page3[0].ID)
+}
+
+func TestListVerifiedOwnerAccounts(t *testing.T) {
+	unverifiedAccount := createRandomAccount(t)
+	verifiedAccount := createRandomAccount(t)
+
+	_, err := testQueries.UpdateUser(context.Background(), UpdateUserParams{
+		Username:        verifiedAccount.Owner,
+		IsEmailVerified: sql.NullBool{Bool: true, Valid: true},
+	})
+	require.NoError(t, err)
+
+	arg := ListVerifiedOwnerAccountsParams{
+		CreatedBefore: time.Now().Add(time.Minute),
+		AfterID:       unverifiedAccount.ID - 1,
+		Limit:         100,
+	}
+	accounts, err := testQueries.ListVerifiedOwnerAccounts(context.Background(), arg)
+	require.NoError(t, err)
+	require.Contains(t, accounts, verifiedAccount)
+	require.NotContains(t, accounts, unverifiedAccount)
+
+	// accounts opened after the period aren't listed
+	arg.CreatedBefore = verifiedAccount.CreatedAt
+	accounts, err = testQueries.ListVerifiedOwnerAccounts(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotContains(t, accounts, verifiedAccount)
+}
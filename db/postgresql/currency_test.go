@@ -0,0 +1,38 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xmeizh/simplebank/util"
+)
+
+func TestGetCurrency(t *testing.T) {
+	currency, err := testQueries.GetCurrency(context.Background(), util.USD)
+	require.NoError(t, err)
+	require.Equal(t, util.USD, currency.Code)
+	require.Equal(t, int32(2), currency.MinorUnits)
+	require.True(t, currency.Enabled)
+	require.NotZero(t, currency.CreatedAt)
+
+	_, err = testQueries.GetCurrency(context.Background(), "XXX")
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestListCurrencies(t *testing.T) {
+	currencies, err := testQueries.ListCurrencies(context.Background())
+	require.NoError(t, err)
+	require.NotEmpty(t, currencies)
+
+	codes := make(map[string]Currency, len(currencies))
+	for _, currency := range currencies {
+		codes[currency.Code] = currency
+	}
+
+	for _, code := range []string{util.USD, util.EUR, util.CAD} {
+		require.Contains(t, codes, code)
+		require.True(t, codes[code].Enabled)
+	}
+}
@@ -0,0 +1,24 @@
+package db
+
+import "errors"
+
+var (
+	// ErrAccountUnlockInvalid is returned when an account unlock doesn't exist, its code doesn't match,
+	// or it was already used or has expired
+	ErrAccountUnlockInvalid = errors.New("account unlock is invalid or has expired")
+	// ErrIdempotencyKeyConflict is returned when an idempotency key is reused with a different request
+	ErrIdempotencyKeyConflict = errors.New("idempotency key has already been used for a different request")
+	// ErrInsufficientFunds is returned when a transfer would take an account below its overdraft limit
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	// ErrScheduledTransferNotDue is returned when a run is recorded for a scheduled transfer that is no longer due,
+	// e.g. because the run was already recorded or the schedule was cancelled
+	ErrScheduledTransferNotDue = errors.New("scheduled transfer is not due")
+	// ErrPasswordResetInvalid is returned when a password reset doesn't exist, its code doesn't match,
+	// or it was already used or has expired
+	ErrPasswordResetInvalid = errors.New("password reset is invalid or has expired")
+	// ErrWebhookDeliveryNotFailed is returned when a delivery is redelivered that didn't fail,
+	// or was already redelivered
+	ErrWebhookDeliveryNotFailed = errors.New("webhook delivery is not failed")
+	// ErrSessionRotated is returned when a session whose refresh token was already exchanged is rotated again
+	ErrSessionRotated = errors.New("session has already been rotated")
+)
@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+// Types of the domain events recorded by the store transactions
+const (
+	EventUserCreated       = "user_created"
+	EventEmailVerified     = "email_verified"
+	EventAccountOpened     = "account_opened"
+	EventTransferCompleted = "transfer_completed"
+)
+
+// IsEventType reports whether the type is one of the event types above
+func IsEventType(eventType string) bool {
+	switch eventType {
+	case EventUserCreated, EventEmailVerified, EventAccountOpened, EventTransferCompleted:
+		return true
+	}
+	return false
+}
+
+// Statuses of the webhook deliveries
+const (
+	WebhookDeliveryPending    = "pending"
+	WebhookDeliverySucceeded  = "succeeded"
+	WebhookDeliveryFailed     = "failed"
+	WebhookDeliverySuperseded = "superseded"
+)
+
+// UserCreatedEvent is the payload of EventUserCreated
+type UserCreatedEvent struct {
+	Username string `json:"username"`
+	FullName string `json:"full_name"`
+	Email    string `json:"email"`
+}
+
+// EmailVerifiedEvent is the payload of EventEmailVerified
+type EmailVerifiedEvent struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+// AccountOpenedEvent is the payload of EventAccountOpened
+type AccountOpenedEvent struct {
+	Account Account `json:"account"`
+}
+
+// TransferCompletedEvent is the payload of EventTransferCompleted.
+// It's recorded once for each account of the transfer, so both owners receive it.
+type TransferCompletedEvent struct {
+	Transfer Transfer `json:"transfer"`
+}
+
+// recordEvent writes an event streamed to the given user, about the given account unless it's 0
+func recordEvent(ctx context.Context, q *Queries, eventType string, username string, accountID int64, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("cannot marshal %s event: %w", eventType, err)
+	}
+
+	event, err := q.CreateEvent(ctx, CreateEventParams{
+		Type:     eventType,
+		Username: username,
+		AccountID: sql.NullInt64{
+			Int64: accountID,
+			Valid: accountID != 0,
+		},
+		Payload: data,
+	})
+	if err != nil {
+		return err
+	}
+
+	// the deliveries are committed with the event, so endpoints never miss one
+	return q.CreateEventWebhookDeliveries(ctx, CreateEventWebhookDeliveriesParams{
+		EventID:   event.ID,
+		Username:  username,
+		EventType: eventType,
+	})
+}
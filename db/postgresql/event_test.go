@@ -0,0 +1,87 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xmeizh/simplebank/util"
+)
+
+func TestCreateAccountTx(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+
+	result, err := store.CreateAccountTx(context.Background(), CreateAccountTxParams{
+		CreateAccountParams: CreateAccountParams{
+			Owner:    user.Username,
+			Currency: util.RandomCurrency(),
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, user.Username, result.Account.Owner)
+
+	events, err := store.ListEventsAfter(context.Background(), ListEventsAfterParams{
+		Username: user.Username,
+		Limit:    10,
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, EventAccountOpened, events[0].Type)
+	require.Equal(t, result.Account.ID, events[0].AccountID.Int64)
+	require.NotZero(t, events[0].TransactionID)
+
+	var payload AccountOpenedEvent
+	require.NoError(t, json.Unmarshal(events[0].Payload, &payload))
+	require.Equal(t, result.Account.ID, payload.Account.ID)
+}
+
+func TestListEventsAfter(t *testing.T) {
+	store := NewStore(testDB)
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	// both owners receive the transfer
+	for _, account := range []Account{account1, account2} {
+		events, err := store.ListEventsAfter(context.Background(), ListEventsAfterParams{
+			Username: account.Owner,
+			Limit:    10,
+		})
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		require.Equal(t, EventTransferCompleted, events[0].Type)
+		require.Equal(t, account.ID, events[0].AccountID.Int64)
+
+		var payload TransferCompletedEvent
+		require.NoError(t, json.Unmarshal(events[0].Payload, &payload))
+		require.Equal(t, result.Transfer.ID, payload.Transfer.ID)
+
+		// nothing is left after the last event
+		events, err = store.ListEventsAfter(context.Background(), ListEventsAfterParams{
+			Username:           account.Owner,
+			AfterTransactionID: events[0].TransactionID,
+			AfterID:            events[0].ID,
+			Limit:              10,
+		})
+		require.NoError(t, err)
+		require.Empty(t, events)
+	}
+
+	// events can be narrowed down to one account
+	events, err := store.ListEventsAfter(context.Background(), ListEventsAfterParams{
+		Username:  account1.Owner,
+		AccountID: sql.NullInt64{Int64: account2.ID, Valid: true},
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	require.Empty(t, events)
+}
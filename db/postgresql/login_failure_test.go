@@ -0,0 +1,168 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xmeizh/simplebank/util"
+)
+
+func TestRecordLoginFailure(t *testing.T) {
+	key := GetLoginFailureParams{
+		Scope: LoginFailureScopeUsername,
+		Key:   util.RandomOwner(),
+	}
+	arg := RecordLoginFailureParams{
+		Scope:       key.Scope,
+		Key:         key.Key,
+		ResetBefore: time.Now().Add(-time.Hour),
+	}
+
+	failure, err := testQueries.RecordLoginFailure(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), failure.FailedAttempts)
+	require.WithinDuration(t, time.Now(), failure.LastFailedAt, time.Second)
+	require.False(t, failure.LockedUntil.Valid)
+
+	failure, err = testQueries.RecordLoginFailure(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), failure.FailedAttempts)
+
+	lockedUntil := time.Now().Add(time.Minute)
+	failure, err = testQueries.LockLoginFailure(context.Background(), LockLoginFailureParams{
+		Scope:       key.Scope,
+		Key:         key.Key,
+		LockedUntil: sql.NullTime{Time: lockedUntil, Valid: true},
+	})
+	require.NoError(t, err)
+	require.WithinDuration(t, lockedUntil, failure.LockedUntil.Time, time.Second)
+
+	// a lock isn't set again while it lasts, nor cleared by further failures
+	_, err = testQueries.LockLoginFailure(context.Background(), LockLoginFailureParams{
+		Scope:       key.Scope,
+		Key:         key.Key,
+		LockedUntil: sql.NullTime{Time: lockedUntil.Add(time.Minute), Valid: true},
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	failure, err = testQueries.RecordLoginFailure(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(3), failure.FailedAttempts)
+	require.WithinDuration(t, lockedUntil, failure.LockedUntil.Time, time.Second)
+
+	// failures older than reset_before are forgotten
+	arg.ResetBefore = time.Now().Add(time.Second)
+	failure, err = testQueries.RecordLoginFailure(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), failure.FailedAttempts)
+	require.False(t, failure.LockedUntil.Valid)
+
+	err = testQueries.DeleteLoginFailure(context.Background(), DeleteLoginFailureParams(key))
+	require.NoError(t, err)
+
+	_, err = testQueries.GetLoginFailure(context.Background(), key)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestRecordLoginFailureTx(t *testing.T) {
+	store := NewStore(testDB)
+	arg := RecordLoginFailureTxParams{
+		RecordLoginFailureParams: RecordLoginFailureParams{
+			Scope:       LoginFailureScopeUsername,
+			Key:         util.RandomOwner(),
+			ResetBefore: time.Now().Add(-time.Hour),
+		},
+		MaxAttempts: 2,
+		LockedUntil: time.Now().Add(time.Minute),
+	}
+
+	locks := 0
+	arg.AfterLock = func(q Querier, failure LoginFailure) error {
+		locks++
+		return nil
+	}
+
+	result, err := store.RecordLoginFailureTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), result.Failure.FailedAttempts)
+	require.False(t, result.Locked)
+	require.Zero(t, locks)
+
+	result, err = store.RecordLoginFailureTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), result.Failure.FailedAttempts)
+	require.True(t, result.Locked)
+	require.WithinDuration(t, arg.LockedUntil, result.Failure.LockedUntil.Time, time.Second)
+	require.Equal(t, 1, locks)
+
+	// further failures while locked don't lock again
+	result, err = store.RecordLoginFailureTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int32(3), result.Failure.FailedAttempts)
+	require.False(t, result.Locked)
+	require.Equal(t, 1, locks)
+
+	// the lock is rolled back if AfterLock fails
+	key := GetLoginFailureParams{Scope: arg.Scope, Key: util.RandomOwner()}
+	arg.Key = key.Key
+	arg.MaxAttempts = 1
+	arg.AfterLock = func(q Querier, failure LoginFailure) error {
+		return sql.ErrConnDone
+	}
+	_, err = store.RecordLoginFailureTx(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrConnDone)
+
+	_, err = store.GetLoginFailure(context.Background(), key)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestUnlockAccountTx(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+
+	_, err := store.RecordLoginFailure(context.Background(), RecordLoginFailureParams{
+		Scope:       LoginFailureScopeUsername,
+		Key:         user.Username,
+		ResetBefore: time.Now().Add(-time.Hour),
+	})
+	require.NoError(t, err)
+
+	secretCode, err := util.GenerateSecretCode()
+	require.NoError(t, err)
+	accountUnlock, err := store.CreateAccountUnlock(context.Background(), CreateAccountUnlockParams{
+		Username:   user.Username,
+		HashedCode: util.HashSecretCode(secretCode),
+	})
+	require.NoError(t, err)
+	require.False(t, accountUnlock.IsUsed)
+
+	// the code has to match
+	_, err = store.UnlockAccountTx(context.Background(), UnlockAccountTxParams{
+		AccountUnlockID: accountUnlock.ID,
+		HashedCode:      util.HashSecretCode("wrong"),
+	})
+	require.ErrorIs(t, err, ErrAccountUnlockInvalid)
+
+	result, err := store.UnlockAccountTx(context.Background(), UnlockAccountTxParams{
+		AccountUnlockID: accountUnlock.ID,
+		HashedCode:      util.HashSecretCode(secretCode),
+	})
+	require.NoError(t, err)
+	require.True(t, result.AccountUnlock.IsUsed)
+
+	_, err = store.GetLoginFailure(context.Background(), GetLoginFailureParams{
+		Scope: LoginFailureScopeUsername,
+		Key:   user.Username,
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// an unlock can only be used once
+	_, err = store.UnlockAccountTx(context.Background(), UnlockAccountTxParams{
+		AccountUnlockID: accountUnlock.ID,
+		HashedCode:      util.HashSecretCode(secretCode),
+	})
+	require.ErrorIs(t, err, ErrAccountUnlockInvalid)
+}
@@ -0,0 +1,59 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomMfaChallenge(t *testing.T, username string) MfaChallenge {
+	arg := CreateMfaChallengeParams{
+		ID:        uuid.New(),
+		Username:  username,
+		ExpiresAt: time.Now().Add(time.Minute),
+	}
+
+	challenge, err := testQueries.CreateMfaChallenge(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.ID, challenge.ID)
+	require.Equal(t, arg.Username, challenge.Username)
+	require.WithinDuration(t, arg.ExpiresAt, challenge.ExpiresAt, time.Second)
+	require.Zero(t, challenge.FailedAttempts)
+	require.False(t, challenge.IsUsed)
+
+	return challenge
+}
+
+func TestIncrementMfaChallengeFailedAttempts(t *testing.T) {
+	user := createRandomUser(t)
+	challenge := createRandomMfaChallenge(t, user.Username)
+
+	updated, err := testQueries.IncrementMfaChallengeFailedAttempts(context.Background(), challenge.ID)
+	require.NoError(t, err)
+	require.Equal(t, int32(1), updated.FailedAttempts)
+
+	updated, err = testQueries.IncrementMfaChallengeFailedAttempts(context.Background(), challenge.ID)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), updated.FailedAttempts)
+}
+
+func TestUseMfaChallenge(t *testing.T) {
+	user := createRandomUser(t)
+	challenge := createRandomMfaChallenge(t, user.Username)
+
+	used, err := testQueries.UseMfaChallenge(context.Background(), challenge.ID)
+	require.NoError(t, err)
+	require.True(t, used.IsUsed)
+
+	// a challenge can only be answered once
+	_, err = testQueries.UseMfaChallenge(context.Background(), challenge.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	fetched, err := testQueries.GetMfaChallenge(context.Background(), challenge.ID)
+	require.NoError(t, err)
+	require.True(t, fetched.IsUsed)
+}
@@ -5,17 +5,37 @@
 package db
 
 import (
+	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type Account struct {
-	ID        int64     `json:"id"`
-	Owner     string    `json:"owner"`
-	Balance   int64     `json:"balance"`
-	Currency  string    `json:"currency"`
-	CreatedAt time.Time `json:"created_at"`
+	ID             int64     `json:"id"`
+	Owner          string    `json:"owner"`
+	Balance        int64     `json:"balance"`
+	Currency       string    `json:"currency"`
+	CreatedAt      time.Time `json:"created_at"`
+	OverdraftLimit int64     `json:"overdraft_limit"`
+}
+
+type AccountUnlock struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	// SHA-256 hash of the secret code sent in the unlock link
+	HashedCode string    `json:"hashed_code"`
+	IsUsed     bool      `json:"is_used"`
+	CreatedAt  time.Time `json:"created_at"`
+	ExpiredAt  time.Time `json:"expired_at"`
+}
+
+type Currency struct {
+	Code       string    `json:"code"`
+	MinorUnits int32     `json:"minor_units"`
+	Enabled    bool      `json:"enabled"`
+	CreatedAt  time.Time `json:"created_at"`
 }
 
 type Entry struct {
@@ -26,17 +46,154 @@ type Entry struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
-type Session struct {
-	ID          uuid.UUID `json:"id"`
-	Username    string    `json:"username"`
-	RefeshToken string    `json:"refesh_token"`
-	UserAgent   string    `json:"user_agent"`
-	ClientIp    string    `json:"client_ip"`
-	IsBlocked   bool      `json:"is_blocked"`
-	ExpiresAt   time.Time `json:"expires_at"`
+type Event struct {
+	ID int64 `json:"id"`
+	// id of the transaction that recorded the event, events are streamed in transaction order
+	TransactionID int64  `json:"transaction_id"`
+	Type          string `json:"type"`
+	// user the event is streamed to
+	Username string `json:"username"`
+	// account the event is about, not a foreign key so events outlive deleted accounts
+	AccountID sql.NullInt64   `json:"account_id"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type IdempotencyKey struct {
+	Username    string          `json:"username"`
+	Key         string          `json:"key"`
+	RequestHash string          `json:"request_hash"`
+	Response    json.RawMessage `json:"response"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+type LoginFailure struct {
+	// username or client_ip
+	Scope string `json:"scope"`
+	// not a foreign key, failures are also tracked for usernames that don't exist
+	Key            string       `json:"key"`
+	FailedAttempts int32        `json:"failed_attempts"`
+	LastFailedAt   time.Time    `json:"last_failed_at"`
+	LockedUntil    sql.NullTime `json:"locked_until"`
+}
+
+type MfaChallenge struct {
+	ID       uuid.UUID `json:"id"`
+	Username string    `json:"username"`
+	// the challenge is rejected after too many wrong codes
+	FailedAttempts int32     `json:"failed_attempts"`
+	IsUsed         bool      `json:"is_used"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type NotificationPreference struct {
+	Username string `json:"username"`
+	// email the user when money leaves one of their accounts, users without preferences get the default
+	TransferDebitEmails bool `json:"transfer_debit_emails"`
+	// email the user when money arrives in one of their accounts
+	TransferCreditEmails bool      `json:"transfer_credit_emails"`
+	UpdatedAt            time.Time `json:"updated_at"`
+	// the locale of the emails sent to the user, like en or fr-CA
+	Locale string `json:"locale"`
+}
+
+type Outbox struct {
+	ID       int64  `json:"id"`
+	TaskType string `json:"task_type"`
+	Payload  []byte `json:"payload"`
+	// the task queue, or the distributor default if null
+	Queue sql.NullString `json:"queue"`
+	// the max number of retries, or the distributor default if null
+	MaxRetry  sql.NullInt32 `json:"max_retry"`
+	ProcessAt sql.NullTime  `json:"process_at"`
+	// failed attempts to publish the task
+	Attempts  int32          `json:"attempts"`
+	LastError sql.NullString `json:"last_error"`
+	CreatedAt time.Time      `json:"created_at"`
+	// set once the task has been published to the task queue
+	SentAt sql.NullTime `json:"sent_at"`
+	// the message is relayed from then on, failed messages back off
+	AvailableAt time.Time `json:"available_at"`
+	// set once the message failed too many times, it is no longer relayed
+	DeadAt sql.NullTime `json:"dead_at"`
+}
+
+type PasswordReset struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	// SHA-256 hash of the secret code sent in the reset link
+	HashedCode string    `json:"hashed_code"`
+	IsUsed     bool      `json:"is_used"`
+	CreatedAt  time.Time `json:"created_at"`
+	ExpiredAt  time.Time `json:"expired_at"`
+}
+
+type PasswordResetRequest struct {
+	Username string `json:"username"`
+	// when the last reset email was sent, another is only sent after a cooldown
+	RequestedAt time.Time `json:"requested_at"`
+}
+
+type RecoveryCode struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	// bcrypt hash, the code is only shown once when MFA is enabled
+	HashedCode string       `json:"hashed_code"`
+	UsedAt     sql.NullTime `json:"used_at"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+type Role struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
 	CreatedAt   time.Time `json:"created_at"`
 }
 
+type RolePermission struct {
+	Role string `json:"role"`
+	// resource:action:scope, the scope is own or any
+	Permission string `json:"permission"`
+}
+
+type ScheduledTransfer struct {
+	ID            int64  `json:"id"`
+	Owner         string `json:"owner"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	// must be positive
+	Amount int64 `json:"amount"`
+	// 0 for a one-off transfer
+	IntervalSeconds int64        `json:"interval_seconds"`
+	NextRunAt       time.Time    `json:"next_run_at"`
+	EndAt           sql.NullTime `json:"end_at"`
+	Status          string       `json:"status"`
+	CreatedAt       time.Time    `json:"created_at"`
+}
+
+type ScheduledTransferRun struct {
+	ID                  int64         `json:"id"`
+	ScheduledTransferID int64         `json:"scheduled_transfer_id"`
+	RunAt               time.Time     `json:"run_at"`
+	TransferID          sql.NullInt64 `json:"transfer_id"`
+	Status              string        `json:"status"`
+	FailureReason       string        `json:"failure_reason"`
+	CreatedAt           time.Time     `json:"created_at"`
+}
+
+type Session struct {
+	ID          uuid.UUID    `json:"id"`
+	Username    string       `json:"username"`
+	RefeshToken string       `json:"refesh_token"`
+	UserAgent   string       `json:"user_agent"`
+	ClientIp    string       `json:"client_ip"`
+	IsBlocked   bool         `json:"is_blocked"`
+	ExpiresAt   time.Time    `json:"expires_at"`
+	CreatedAt   time.Time    `json:"created_at"`
+	FamilyID    uuid.UUID    `json:"family_id"`
+	RotatedAt   sql.NullTime `json:"rotated_at"`
+}
+
 type Transfer struct {
 	ID            int64 `json:"id"`
 	FromAccountID int64 `json:"from_account_id"`
@@ -44,6 +201,10 @@ type Transfer struct {
 	// must be positive
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"created_at"`
+	// amount credited to the destination account in its currency
+	ConvertedAmount int64 `json:"converted_amount"`
+	// units of the destination currency per unit of the source currency
+	ExchangeRate float64 `json:"exchange_rate"`
 }
 
 type User struct {
@@ -54,7 +215,19 @@ type User struct {
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
 	IsEmailVerified   bool      `json:"is_email_verified"`
-	Role              string    `json:"role"`
+	// role granted at sign up, what a user may do is decided by user_roles
+	Role string `json:"role"`
+	// base32 TOTP secret, set at enrollment and only used once is_mfa_enabled is true
+	TotpSecret   string `json:"totp_secret"`
+	IsMfaEnabled bool   `json:"is_mfa_enabled"`
+	// time step of the last accepted TOTP code, codes of this step or earlier are rejected so they can't be replayed
+	TotpLastStep int64 `json:"totp_last_step"`
+}
+
+type UserRole struct {
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 type VerifyEmail struct {
@@ -66,3 +239,28 @@ type VerifyEmail struct {
 	CreatedAt  time.Time `json:"created_at"`
 	ExpiredAt  time.Time `json:"expired_at"`
 }
+
+type WebhookDelivery struct {
+	ID         int64 `json:"id"`
+	EndpointID int64 `json:"endpoint_id"`
+	EventID    int64 `json:"event_id"`
+	// pending, succeeded, failed once the retries are used up or superseded once redelivered
+	Status   string `json:"status"`
+	Attempts int32  `json:"attempts"`
+	// HTTP status of the last attempt, null if no response was received
+	ResponseStatus sql.NullInt32 `json:"response_status"`
+	LastError      string        `json:"last_error"`
+	CreatedAt      time.Time     `json:"created_at"`
+	DeliveredAt    sql.NullTime  `json:"delivered_at"`
+}
+
+type WebhookEndpoint struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+	Url      string `json:"url"`
+	// key of the HMAC-SHA256 signature of the deliveries, kept in plain text to sign them
+	Secret string `json:"secret"`
+	// types of the events delivered, all of them if empty
+	EventTypes []string  `json:"event_types"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,42 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpsertNotificationPreferences(t *testing.T) {
+	user := createRandomUser(t)
+
+	_, err := testQueries.GetNotificationPreferences(context.Background(), user.Username)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// the preferences that aren't set default to on
+	preferences, err := testQueries.UpsertNotificationPreferences(context.Background(), UpsertNotificationPreferencesParams{
+		Username:            user.Username,
+		TransferDebitEmails: sql.NullBool{Bool: false, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, user.Username, preferences.Username)
+	require.False(t, preferences.TransferDebitEmails)
+	require.True(t, preferences.TransferCreditEmails)
+	require.Equal(t, "en", preferences.Locale)
+
+	// the preferences that aren't set are left unchanged
+	preferences, err = testQueries.UpsertNotificationPreferences(context.Background(), UpsertNotificationPreferencesParams{
+		Username:             user.Username,
+		TransferCreditEmails: sql.NullBool{Bool: false, Valid: true},
+		Locale:               sql.NullString{String: "fr", Valid: true},
+	})
+	require.NoError(t, err)
+	require.False(t, preferences.TransferDebitEmails)
+	require.False(t, preferences.TransferCreditEmails)
+	require.Equal(t, "fr", preferences.Locale)
+
+	stored, err := testQueries.GetNotificationPreferences(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, preferences, stored)
+}
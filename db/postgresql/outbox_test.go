@@ -0,0 +1,197 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xmeizh/simplebank/util"
+)
+
+func createRandomOutboxMessage(t *testing.T, q Querier) Outbox {
+	arg := CreateOutboxMessageParams{
+		TaskType:  "task:" + util.RandomString(8),
+		Payload:   []byte(`{"username":"` + util.RandomOwner() + `"}`),
+		Queue:     sql.NullString{String: "critical", Valid: true},
+		MaxRetry:  sql.NullInt32{Int32: 10, Valid: true},
+		ProcessAt: sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true},
+	}
+
+	message, err := q.CreateOutboxMessage(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, message.ID)
+	require.Equal(t, arg.TaskType, message.TaskType)
+	require.Equal(t, arg.Payload, message.Payload)
+	require.Equal(t, arg.Queue, message.Queue)
+	require.Equal(t, arg.MaxRetry, message.MaxRetry)
+	require.WithinDuration(t, arg.ProcessAt.Time, message.ProcessAt.Time, time.Second)
+	require.Zero(t, message.Attempts)
+	require.False(t, message.SentAt.Valid)
+	return message
+}
+
+func TestRelayOutboxTx(t *testing.T) {
+	store := NewStore(testDB)
+	message1 := createRandomOutboxMessage(t, store)
+	message2 := createRandomOutboxMessage(t, store)
+
+	// message2 fails to publish and stays pending
+	published := make(map[int64]bool)
+	result, err := store.RelayOutboxTx(context.Background(), RelayOutboxTxParams{
+		Limit: 1000,
+		Publish: func(message Outbox) error {
+			if message.ID == message2.ID {
+				return errors.New("redis is down")
+			}
+			published[message.ID] = true
+			return nil
+		},
+		MaxAttempts: 10,
+	})
+	require.NoError(t, err)
+	require.True(t, published[message1.ID])
+	require.GreaterOrEqual(t, result.Sent, 1)
+	require.Equal(t, 1, result.Failed)
+
+	pending, err := store.ListPendingOutboxMessages(context.Background(), 1000)
+	require.NoError(t, err)
+	var failedMessage Outbox
+	for _, message := range pending {
+		require.NotEqual(t, message1.ID, message.ID)
+		if message.ID == message2.ID {
+			failedMessage = message
+		}
+	}
+	require.Equal(t, message2.ID, failedMessage.ID)
+	require.Equal(t, int32(1), failedMessage.Attempts)
+	require.Equal(t, "redis is down", failedMessage.LastError.String)
+
+	result, err = store.RelayOutboxTx(context.Background(), RelayOutboxTxParams{
+		Limit: 1000,
+		Publish: func(message Outbox) error {
+			return nil
+		},
+		MaxAttempts: 10,
+	})
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, result.Sent, 1)
+	require.Zero(t, result.Failed)
+
+	deleted, err := store.DeleteSentOutboxMessages(context.Background(), sql.NullTime{Time: time.Now().Add(time.Second), Valid: true})
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, deleted, int64(2))
+}
+
+func TestRelayOutboxTxPoisonMessage(t *testing.T) {
+	store := NewStore(testDB)
+
+	// relay the messages left by the other tests, so the poison message is the oldest pending one
+	_, err := store.RelayOutboxTx(context.Background(), RelayOutboxTxParams{
+		Limit: 1000,
+		Publish: func(message Outbox) error {
+			return nil
+		},
+		MaxAttempts: 10,
+	})
+	require.NoError(t, err)
+
+	poisonMessage := createRandomOutboxMessage(t, store)
+	nextMessage := createRandomOutboxMessage(t, store)
+
+	published := make(map[int64]bool)
+	arg := RelayOutboxTxParams{
+		Limit: 1,
+		Publish: func(message Outbox) error {
+			if message.ID == poisonMessage.ID {
+				return errors.New("payload is too large")
+			}
+			published[message.ID] = true
+			return nil
+		},
+		MaxAttempts: 2,
+		RetryDelay: func(attempts int32) time.Duration {
+			return time.Hour
+		},
+	}
+
+	// the poison message fails and backs off, so it doesn't hold up the next message
+	result, err := store.RelayOutboxTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Zero(t, result.Sent)
+	require.Equal(t, 1, result.Failed)
+	require.Zero(t, result.Dead)
+
+	result, err = store.RelayOutboxTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Sent)
+	require.Zero(t, result.Failed)
+	require.True(t, published[nextMessage.ID])
+
+	pending, err := store.ListPendingOutboxMessages(context.Background(), 1000)
+	require.NoError(t, err)
+	for _, message := range pending {
+		require.NotEqual(t, poisonMessage.ID, message.ID)
+	}
+
+	// once it has failed MaxAttempts times, the message is dead
+	deadMessage := createRandomOutboxMessage(t, store)
+	arg.Publish = func(message Outbox) error {
+		return errors.New("payload is too large")
+	}
+	arg.MaxAttempts = 1
+	result, err = store.RelayOutboxTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, 1, result.Failed)
+	require.Equal(t, 1, result.Dead)
+
+	pending, err = store.ListPendingOutboxMessages(context.Background(), 1000)
+	require.NoError(t, err)
+	for _, message := range pending {
+		require.NotEqual(t, deadMessage.ID, message.ID)
+	}
+}
+
+func TestCreateUserTxOutbox(t *testing.T) {
+	store := NewStore(testDB)
+
+	hashedPassword, err := util.HashPassword(util.RandomString(6))
+	require.NoError(t, err)
+	arg := CreateUserTxParams{
+		CreateUserParams: CreateUserParams{
+			Username:       util.RandomOwner(),
+			HashedPassword: hashedPassword,
+			FullName:       util.RandomOwner(),
+			Email:          util.RandomEmail(),
+		},
+	}
+
+	// the outbox message is written with the user, and dropped with it when the transaction fails
+	var message Outbox
+	arg.AfterCreate = func(q Querier, user User) error {
+		message = createRandomOutboxMessage(t, q)
+		return errors.New("failed after create")
+	}
+	_, err = store.CreateUserTx(context.Background(), arg)
+	require.Error(t, err)
+
+	pending, err := store.ListPendingOutboxMessages(context.Background(), 1000)
+	require.NoError(t, err)
+	for _, pendingMessage := range pending {
+		require.NotEqual(t, message.ID, pendingMessage.ID)
+	}
+
+	arg.AfterCreate = func(q Querier, user User) error {
+		message = createRandomOutboxMessage(t, q)
+		return nil
+	}
+	result, err := store.CreateUserTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Username, result.User.Username)
+
+	pending, err = store.ListPendingOutboxMessages(context.Background(), 1000)
+	require.NoError(t, err)
+	require.Contains(t, pending, message)
+}
@@ -0,0 +1,118 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xmeizh/simplebank/util"
+)
+
+func TestResetPasswordTx(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+
+	session, err := store.CreateSession(context.Background(), randomSessionParams(t, user.Username))
+	require.NoError(t, err)
+
+	secretCode, err := util.GenerateSecretCode()
+	require.NoError(t, err)
+	passwordReset, err := store.CreatePasswordReset(context.Background(), CreatePasswordResetParams{
+		Username:   user.Username,
+		HashedCode: util.HashSecretCode(secretCode),
+	})
+	require.NoError(t, err)
+	require.False(t, passwordReset.IsUsed)
+
+	// the code has to match
+	_, err = store.ResetPasswordTx(context.Background(), ResetPasswordTxParams{
+		PasswordResetID: passwordReset.ID,
+		HashedCode:      util.HashSecretCode("wrong"),
+		HashedPassword:  util.RandomString(60),
+	})
+	require.ErrorIs(t, err, ErrPasswordResetInvalid)
+
+	newHashedPassword, err := util.HashPassword(util.RandomString(8))
+	require.NoError(t, err)
+	result, err := store.ResetPasswordTx(context.Background(), ResetPasswordTxParams{
+		PasswordResetID: passwordReset.ID,
+		HashedCode:      util.HashSecretCode(secretCode),
+		HashedPassword:  newHashedPassword,
+	})
+	require.NoError(t, err)
+	require.True(t, result.PasswordReset.IsUsed)
+	require.Equal(t, newHashedPassword, result.User.HashedPassword)
+	require.True(t, result.User.PasswordChangedAt.After(user.PasswordChangedAt))
+
+	blocked, err := store.GetSession(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.True(t, blocked.IsBlocked)
+
+	// a reset can only be used once
+	_, err = store.ResetPasswordTx(context.Background(), ResetPasswordTxParams{
+		PasswordResetID: passwordReset.ID,
+		HashedCode:      util.HashSecretCode(secretCode),
+		HashedPassword:  newHashedPassword,
+	})
+	require.ErrorIs(t, err, ErrPasswordResetInvalid)
+}
+
+func TestGetUserByEmail(t *testing.T) {
+	user1 := createRandomUser(t)
+
+	user2, err := testQueries.GetUserByEmail(context.Background(), user1.Email)
+	require.NoError(t, err)
+	require.Equal(t, user1.Username, user2.Username)
+}
+
+func TestRequestPasswordResetTx(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+
+	requests := 0
+	arg := RequestPasswordResetTxParams{
+		Username:        user.Username,
+		RequestedBefore: time.Now().Add(-time.Minute),
+		AfterRequest: func(q Querier) error {
+			requests++
+			return nil
+		},
+	}
+
+	result, err := store.RequestPasswordResetTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, result.Requested)
+	require.Equal(t, 1, requests)
+
+	// another request during the cooldown sends nothing
+	result, err = store.RequestPasswordResetTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.False(t, result.Requested)
+	require.Equal(t, 1, requests)
+
+	// once the cooldown is over the user can request again
+	arg.RequestedBefore = time.Now().Add(time.Second)
+	result, err = store.RequestPasswordResetTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, result.Requested)
+	require.Equal(t, 2, requests)
+
+	// the request is rolled back if AfterRequest fails
+	arg.RequestedBefore = time.Now().Add(time.Second)
+	arg.AfterRequest = func(q Querier) error {
+		return sql.ErrConnDone
+	}
+	_, err = store.RequestPasswordResetTx(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrConnDone)
+
+	arg.AfterRequest = func(q Querier) error {
+		requests++
+		return nil
+	}
+	result, err = store.RequestPasswordResetTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.True(t, result.Requested)
+	require.Equal(t, 3, requests)
+}
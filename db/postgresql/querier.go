@@ -6,31 +6,114 @@ package db
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
 )
 
 type Querier interface {
 	AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error)
+	BlockSessionFamily(ctx context.Context, familyID uuid.UUID) error
+	BlockUserSessions(ctx context.Context, username string) error
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
+	CreateAccountUnlock(ctx context.Context, arg CreateAccountUnlockParams) (AccountUnlock, error)
 	CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error)
+	CreateEvent(ctx context.Context, arg CreateEventParams) (Event, error)
+	// Queues a delivery of the event to every endpoint of its user subscribed to its type
+	CreateEventWebhookDeliveries(ctx context.Context, arg CreateEventWebhookDeliveriesParams) error
+	CreateIdempotencyKey(ctx context.Context, arg CreateIdempotencyKeyParams) (IdempotencyKey, error)
+	CreateMfaChallenge(ctx context.Context, arg CreateMfaChallengeParams) (MfaChallenge, error)
+	CreateOutboxMessage(ctx context.Context, arg CreateOutboxMessageParams) (Outbox, error)
+	CreatePasswordReset(ctx context.Context, arg CreatePasswordResetParams) (PasswordReset, error)
+	CreateRecoveryCode(ctx context.Context, arg CreateRecoveryCodeParams) (RecoveryCode, error)
+	CreateScheduledTransfer(ctx context.Context, arg CreateScheduledTransferParams) (ScheduledTransfer, error)
+	CreateScheduledTransferRun(ctx context.Context, arg CreateScheduledTransferRunParams) (ScheduledTransferRun, error)
 	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
 	CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
 	CreateVerifyEmail(ctx context.Context, arg CreateVerifyEmailParams) (VerifyEmail, error)
+	CreateWebhookDelivery(ctx context.Context, arg CreateWebhookDeliveryParams) (WebhookDelivery, error)
+	CreateWebhookEndpoint(ctx context.Context, arg CreateWebhookEndpointParams) (WebhookEndpoint, error)
 	DeleteAccount(ctx context.Context, id int64) error
+	DeleteLoginFailure(ctx context.Context, arg DeleteLoginFailureParams) error
+	DeleteRecoveryCodes(ctx context.Context, username string) error
+	DeleteSentOutboxMessages(ctx context.Context, sentAt sql.NullTime) (int64, error)
+	// Marks a pending delivery failed, when its task gave up without recording its last attempt
+	FailWebhookDelivery(ctx context.Context, id int64) error
 	GetAccount(ctx context.Context, id int64) (Account, error)
+	GetAccountBalanceAt(ctx context.Context, arg GetAccountBalanceAtParams) (int64, error)
 	GetAccountForUpdate(ctx context.Context, id int64) (Account, error)
+	GetCurrency(ctx context.Context, code string) (Currency, error)
 	GetEntry(ctx context.Context, id int64) (Entry, error)
+	GetIdempotencyKey(ctx context.Context, arg GetIdempotencyKeyParams) (IdempotencyKey, error)
+	GetLoginFailure(ctx context.Context, arg GetLoginFailureParams) (LoginFailure, error)
+	GetMfaChallenge(ctx context.Context, id uuid.UUID) (MfaChallenge, error)
+	GetNotificationPreferences(ctx context.Context, username string) (NotificationPreference, error)
+	GetScheduledTransfer(ctx context.Context, id int64) (ScheduledTransfer, error)
+	GetScheduledTransferForUpdate(ctx context.Context, id int64) (ScheduledTransfer, error)
 	GetSession(ctx context.Context, id uuid.UUID) (Session, error)
+	GetSessionState(ctx context.Context, id uuid.UUID) (GetSessionStateRow, error)
 	GetTransfer(ctx context.Context, id int64) (Transfer, error)
 	GetUser(ctx context.Context, username string) (User, error)
+	GetUserByEmail(ctx context.Context, email string) (User, error)
+	GetWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error)
+	GetWebhookDeliveryRequest(ctx context.Context, id int64) (GetWebhookDeliveryRequestRow, error)
+	GetWebhookEndpoint(ctx context.Context, id int64) (WebhookEndpoint, error)
+	GrantUserRole(ctx context.Context, arg GrantUserRoleParams) error
+	IncrementMfaChallengeFailedAttempts(ctx context.Context, id uuid.UUID) (MfaChallenge, error)
+	ListAccountStatementEntries(ctx context.Context, arg ListAccountStatementEntriesParams) ([]ListAccountStatementEntriesRow, error)
 	ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error)
+	ListAccountsAfter(ctx context.Context, arg ListAccountsAfterParams) ([]Account, error)
+	ListCurrencies(ctx context.Context) ([]Currency, error)
+	ListDueScheduledTransfers(ctx context.Context, arg ListDueScheduledTransfersParams) ([]ScheduledTransfer, error)
 	ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error)
+	ListEntriesAfter(ctx context.Context, arg ListEntriesAfterParams) ([]Entry, error)
+	// Only events of transactions older than every running transaction are listed,
+	// so an event committed late can't end up before an offset that was already streamed
+	ListEventsAfter(ctx context.Context, arg ListEventsAfterParams) ([]Event, error)
+	// Messages backing off after a failure and dead messages aren't pending
+	ListPendingOutboxMessages(ctx context.Context, limit int32) ([]Outbox, error)
+	ListPendingWebhookDeliveries(ctx context.Context, arg ListPendingWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	ListScheduledTransferRuns(ctx context.Context, arg ListScheduledTransferRunsParams) ([]ScheduledTransferRun, error)
+	ListScheduledTransfers(ctx context.Context, arg ListScheduledTransfersParams) ([]ScheduledTransfer, error)
+	ListScheduledTransfersAfter(ctx context.Context, arg ListScheduledTransfersAfterParams) ([]ScheduledTransfer, error)
+	ListSessions(ctx context.Context, username string) ([]Session, error)
 	ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error)
+	ListTransfersAfter(ctx context.Context, arg ListTransfersAfterParams) ([]Transfer, error)
+	ListUnusedRecoveryCodes(ctx context.Context, username string) ([]RecoveryCode, error)
+	ListUserPermissions(ctx context.Context, username string) ([]string, error)
+	ListUserRoles(ctx context.Context, username string) ([]string, error)
+	ListVerifiedOwnerAccounts(ctx context.Context, arg ListVerifiedOwnerAccountsParams) ([]Account, error)
+	ListWebhookDeliveriesAfter(ctx context.Context, arg ListWebhookDeliveriesAfterParams) ([]WebhookDelivery, error)
+	// LockLoginFailure returns sql.ErrNoRows if the key is already locked,
+	// so only one of concurrent failures reaching the limit sets the lock
+	LockLoginFailure(ctx context.Context, arg LockLoginFailureParams) (LoginFailure, error)
+	MarkOutboxMessageSent(ctx context.Context, id int64) error
+	RecordLoginFailure(ctx context.Context, arg RecordLoginFailureParams) (LoginFailure, error)
+	// Records a failed attempt, the message is retried after the delay unless it's dead
+	RecordOutboxMessageFailure(ctx context.Context, arg RecordOutboxMessageFailureParams) error
+	// RecordPasswordResetRequest returns sql.ErrNoRows if the user already requested a reset since requested_before
+	RecordPasswordResetRequest(ctx context.Context, arg RecordPasswordResetRequestParams) (PasswordResetRequest, error)
+	RevokeUserRole(ctx context.Context, arg RevokeUserRoleParams) (int64, error)
+	RotateSession(ctx context.Context, id uuid.UUID) (Session, error)
+	// Marks a failed delivery superseded by its redelivery, so it can't be redelivered again
+	SupersedeWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error)
 	UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error)
+	UpdateIdempotencyKeyResponse(ctx context.Context, arg UpdateIdempotencyKeyResponseParams) (IdempotencyKey, error)
+	UpdateScheduledTransferNextRun(ctx context.Context, arg UpdateScheduledTransferNextRunParams) (ScheduledTransfer, error)
+	// Only changes active scheduled transfers, so a completed or cancelled one is left as is
+	UpdateScheduledTransferStatus(ctx context.Context, arg UpdateScheduledTransferStatusParams) (ScheduledTransfer, error)
 	UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error)
 	UpdateVerifyEmail(ctx context.Context, arg UpdateVerifyEmailParams) (VerifyEmail, error)
+	UpdateWebhookDeliveryAttempt(ctx context.Context, arg UpdateWebhookDeliveryAttemptParams) (WebhookDelivery, error)
+	// Creates the preferences of the user, or updates the ones that are set
+	UpsertNotificationPreferences(ctx context.Context, arg UpsertNotificationPreferencesParams) (NotificationPreference, error)
+	UseAccountUnlock(ctx context.Context, arg UseAccountUnlockParams) (AccountUnlock, error)
+	UseMfaChallenge(ctx context.Context, id uuid.UUID) (MfaChallenge, error)
+	UsePasswordReset(ctx context.Context, arg UsePasswordResetParams) (PasswordReset, error)
+	UseRecoveryCode(ctx context.Context, id int64) (RecoveryCode, error)
+	// Records the time step of an accepted TOTP code, no row is updated if a code of this step or a later one was already accepted
+	UseTotpStep(ctx context.Context, arg UseTotpStepParams) (int64, error)
 }
 
 var _ Querier = (*Queries)(nil)
@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xmeizh/simplebank/util"
+)
+
+func TestEnableMFATx(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	require.False(t, user.IsMfaEnabled)
+
+	hashedCodes := []string{util.RandomString(60), util.RandomString(60)}
+	result, err := store.EnableMFATx(context.Background(), EnableMFATxParams{
+		Username:            user.Username,
+		HashedRecoveryCodes: hashedCodes,
+	})
+	require.NoError(t, err)
+	require.True(t, result.User.IsMfaEnabled)
+	require.Len(t, result.RecoveryCodes, len(hashedCodes))
+
+	// enabling again replaces the recovery codes
+	newHashedCodes := []string{util.RandomString(60)}
+	_, err = store.EnableMFATx(context.Background(), EnableMFATxParams{
+		Username:            user.Username,
+		HashedRecoveryCodes: newHashedCodes,
+	})
+	require.NoError(t, err)
+
+	recoveryCodes, err := store.ListUnusedRecoveryCodes(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Len(t, recoveryCodes, 1)
+	require.Equal(t, newHashedCodes[0], recoveryCodes[0].HashedCode)
+}
+
+func TestUseRecoveryCode(t *testing.T) {
+	user := createRandomUser(t)
+
+	recoveryCode, err := testQueries.CreateRecoveryCode(context.Background(), CreateRecoveryCodeParams{
+		Username:   user.Username,
+		HashedCode: util.RandomString(60),
+	})
+	require.NoError(t, err)
+	require.False(t, recoveryCode.UsedAt.Valid)
+
+	used, err := testQueries.UseRecoveryCode(context.Background(), recoveryCode.ID)
+	require.NoError(t, err)
+	require.True(t, used.UsedAt.Valid)
+
+	// a recovery code can only be used once
+	_, err = testQueries.UseRecoveryCode(context.Background(), recoveryCode.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	recoveryCodes, err := testQueries.ListUnusedRecoveryCodes(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Empty(t, recoveryCodes)
+}
@@ -0,0 +1,63 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	"github.com/xmeizh/simplebank/util"
+)
+
+func TestUserRoles(t *testing.T) {
+	user := createRandomUser(t)
+
+	roles, err := testQueries.ListUserRoles(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Empty(t, roles)
+
+	for _, role := range []string{util.DepositorRole, util.BankerRole, util.BankerRole} {
+		err = testQueries.GrantUserRole(context.Background(), GrantUserRoleParams{
+			Username: user.Username,
+			Role:     role,
+		})
+		require.NoError(t, err)
+	}
+
+	roles, err = testQueries.ListUserRoles(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, []string{util.BankerRole, util.DepositorRole}, roles)
+
+	permissions, err := testQueries.ListUserPermissions(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Contains(t, permissions, "accounts:read:own")
+	require.Contains(t, permissions, "accounts:read:any")
+	require.NotContains(t, permissions, "roles:manage:any")
+
+	revoked, err := testQueries.RevokeUserRole(context.Background(), RevokeUserRoleParams{
+		Username: user.Username,
+		Role:     util.BankerRole,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), revoked)
+
+	revoked, err = testQueries.RevokeUserRole(context.Background(), RevokeUserRoleParams{
+		Username: user.Username,
+		Role:     util.BankerRole,
+	})
+	require.NoError(t, err)
+	require.Zero(t, revoked)
+
+	permissions, err = testQueries.ListUserPermissions(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.NotContains(t, permissions, "accounts:read:any")
+
+	err = testQueries.GrantUserRole(context.Background(), GrantUserRoleParams{
+		Username: user.Username,
+		Role:     "unknown",
+	})
+	require.Error(t, err)
+	pqErr, ok := err.(*pq.Error)
+	require.True(t, ok)
+	require.Equal(t, "foreign_key_violation", pqErr.Code.Name())
+}
@@ -0,0 +1,183 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xmeizh/simplebank/util"
+)
+
+func createRandomScheduledTransfer(t *testing.T, intervalSeconds int64, endAt sql.NullTime) ScheduledTransfer {
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	arg := CreateScheduledTransferParams{
+		Owner:           account1.Owner,
+		FromAccountID:   account1.ID,
+		ToAccountID:     account2.ID,
+		Amount:          util.RandomMoney(),
+		IntervalSeconds: intervalSeconds,
+		NextRunAt:       time.Now().Add(-time.Minute),
+		EndAt:           endAt,
+	}
+
+	scheduledTransfer, err := testQueries.CreateScheduledTransfer(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, scheduledTransfer)
+	require.Equal(t, arg.Owner, scheduledTransfer.Owner)
+	require.Equal(t, arg.FromAccountID, scheduledTransfer.FromAccountID)
+	require.Equal(t, arg.ToAccountID, scheduledTransfer.ToAccountID)
+	require.Equal(t, arg.Amount, scheduledTransfer.Amount)
+	require.Equal(t, arg.IntervalSeconds, scheduledTransfer.IntervalSeconds)
+	require.WithinDuration(t, arg.NextRunAt, scheduledTransfer.NextRunAt, time.Second)
+	require.Equal(t, ScheduledTransferActive, scheduledTransfer.Status)
+
+	require.NotZero(t, scheduledTransfer.ID)
+	require.NotZero(t, scheduledTransfer.CreatedAt)
+	return scheduledTransfer
+}
+
+func TestCreateScheduledTransfer(t *testing.T) {
+	createRandomScheduledTransfer(t, 3600, sql.NullTime{})
+}
+
+func TestListDueScheduledTransfers(t *testing.T) {
+	scheduledTransfer := createRandomScheduledTransfer(t, 3600, sql.NullTime{})
+
+	scheduledTransfers, err := testQueries.ListDueScheduledTransfers(context.Background(), ListDueScheduledTransfersParams{
+		NextRunAt: scheduledTransfer.NextRunAt,
+		Limit:     1000,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, scheduledTransfers)
+
+	for _, due := range scheduledTransfers {
+		require.Equal(t, ScheduledTransferActive, due.Status)
+		require.False(t, due.NextRunAt.After(scheduledTransfer.NextRunAt))
+	}
+}
+
+func TestRecordScheduledTransferRunTx(t *testing.T) {
+	store := NewStore(testDB)
+	scheduledTransfer := createRandomScheduledTransfer(t, 3600, sql.NullTime{})
+
+	arg := RecordScheduledTransferRunTxParams{
+		ScheduledTransferID: scheduledTransfer.ID,
+		RunAt:               scheduledTransfer.NextRunAt,
+		FailureReason:       ErrInsufficientFunds.Error(),
+	}
+
+	result, err := store.RecordScheduledTransferRunTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	run := result.Run
+	require.Equal(t, scheduledTransfer.ID, run.ScheduledTransferID)
+	require.True(t, scheduledTransfer.NextRunAt.Equal(run.RunAt))
+	require.Equal(t, ScheduledTransferRunFailed, run.Status)
+	require.Equal(t, arg.FailureReason, run.FailureReason)
+	require.False(t, run.TransferID.Valid)
+
+	updated := result.ScheduledTransfer
+	require.Equal(t, ScheduledTransferActive, updated.Status)
+	require.True(t, scheduledTransfer.NextRunAt.Add(time.Hour).Equal(updated.NextRunAt))
+
+	// the same run can't be recorded twice
+	_, err = store.RecordScheduledTransferRunTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrScheduledTransferNotDue)
+
+	runs, err := testQueries.ListScheduledTransferRuns(context.Background(), ListScheduledTransferRunsParams{
+		ScheduledTransferID: scheduledTransfer.ID,
+		Limit:               5,
+		Offset:              0,
+	})
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+}
+
+func TestRecordScheduledTransferRunTxCompleted(t *testing.T) {
+	store := NewStore(testDB)
+
+	oneOff := createRandomScheduledTransfer(t, 0, sql.NullTime{})
+	result, err := store.RecordScheduledTransferRunTx(context.Background(), RecordScheduledTransferRunTxParams{
+		ScheduledTransferID: oneOff.ID,
+		RunAt:               oneOff.NextRunAt,
+		FailureReason:       ErrInsufficientFunds.Error(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, ScheduledTransferCompleted, result.ScheduledTransfer.Status)
+
+	// the next run would be after the end date
+	endAt := sql.NullTime{Time: time.Now(), Valid: true}
+	ending := createRandomScheduledTransfer(t, 3600, endAt)
+	result, err = store.RecordScheduledTransferRunTx(context.Background(), RecordScheduledTransferRunTxParams{
+		ScheduledTransferID: ending.ID,
+		RunAt:               ending.NextRunAt,
+		FailureReason:       ErrInsufficientFunds.Error(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, ScheduledTransferCompleted, result.ScheduledTransfer.Status)
+}
+
+func TestUpdateScheduledTransferStatusOnlyActive(t *testing.T) {
+	scheduledTransfer := createRandomScheduledTransfer(t, 3600, sql.NullTime{})
+
+	arg := UpdateScheduledTransferStatusParams{
+		ID:     scheduledTransfer.ID,
+		Status: ScheduledTransferCancelled,
+	}
+	cancelled, err := testQueries.UpdateScheduledTransferStatus(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, ScheduledTransferCancelled, cancelled.Status)
+
+	// a second cancellation can be told apart from the first
+	_, err = testQueries.UpdateScheduledTransferStatus(context.Background(), arg)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestRecordScheduledTransferRunInTransferTx(t *testing.T) {
+	store := NewStore(testDB)
+	scheduledTransfer := createRandomScheduledTransfer(t, 3600, sql.NullTime{})
+
+	transferArg := TransferTxParams{
+		FromAccountID: scheduledTransfer.FromAccountID,
+		ToAccountID:   scheduledTransfer.ToAccountID,
+		Amount:        scheduledTransfer.Amount,
+	}
+	runArg := RecordScheduledTransferRunTxParams{
+		ScheduledTransferID: scheduledTransfer.ID,
+		RunAt:               scheduledTransfer.NextRunAt,
+	}
+
+	fromAccount, err := testQueries.GetAccount(context.Background(), scheduledTransfer.FromAccountID)
+	require.NoError(t, err)
+
+	// the schedule is cancelled before the run is recorded, so the transfer is rolled back
+	_, err = testQueries.UpdateScheduledTransferStatus(context.Background(), UpdateScheduledTransferStatusParams{
+		ID:     scheduledTransfer.ID,
+		Status: ScheduledTransferCancelled,
+	})
+	require.NoError(t, err)
+
+	transferArg.AfterTransfer = func(q Querier, result TransferTxResult) error {
+		runArg.TransferID = result.Transfer.ID
+		_, err := RecordScheduledTransferRun(context.Background(), q, runArg)
+		return err
+	}
+	_, err = store.TransferTx(context.Background(), transferArg)
+	require.ErrorIs(t, err, ErrScheduledTransferNotDue)
+
+	updatedFromAccount, err := testQueries.GetAccount(context.Background(), scheduledTransfer.FromAccountID)
+	require.NoError(t, err)
+	require.Equal(t, fromAccount.Balance, updatedFromAccount.Balance)
+
+	transfers, err := testQueries.ListTransfers(context.Background(), ListTransfersParams{
+		FromAccountID: scheduledTransfer.FromAccountID,
+		ToAccountID:   scheduledTransfer.ToAccountID,
+		Limit:         10,
+	})
+	require.NoError(t, err)
+	require.Empty(t, transfers)
+}
@@ -0,0 +1,141 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/xmeizh/simplebank/util"
+)
+
+func randomSessionParams(t *testing.T, username string) CreateSessionParams {
+	id, err := uuid.NewRandom()
+	require.NoError(t, err)
+
+	return CreateSessionParams{
+		ID:          id,
+		Username:    username,
+		RefeshToken: util.RandomString(32),
+		UserAgent:   "test",
+		ClientIp:    "127.0.0.1",
+		ExpiresAt:   time.Now().Add(time.Hour),
+		FamilyID:    id,
+	}
+}
+
+func TestRotateSessionTx(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+
+	session1, err := store.CreateSession(context.Background(), randomSessionParams(t, user.Username))
+	require.NoError(t, err)
+	require.Equal(t, session1.ID, session1.FamilyID)
+	require.False(t, session1.RotatedAt.Valid)
+
+	result, err := store.RotateSessionTx(context.Background(), RotateSessionTxParams{
+		SessionID:  session1.ID,
+		NewSession: randomSessionParams(t, user.Username),
+	})
+	require.NoError(t, err)
+	session2 := result.Session
+	require.NotEqual(t, session1.ID, session2.ID)
+	require.Equal(t, session1.FamilyID, session2.FamilyID)
+
+	rotated, err := store.GetSession(context.Background(), session1.ID)
+	require.NoError(t, err)
+	require.True(t, rotated.RotatedAt.Valid)
+
+	// the refresh token of a rotated session can't be exchanged again
+	_, err = store.RotateSessionTx(context.Background(), RotateSessionTxParams{
+		SessionID:  session1.ID,
+		NewSession: randomSessionParams(t, user.Username),
+	})
+	require.ErrorIs(t, err, ErrSessionRotated)
+
+	err = store.BlockSessionFamily(context.Background(), session1.FamilyID)
+	require.NoError(t, err)
+
+	for _, id := range []uuid.UUID{session1.ID, session2.ID} {
+		session, err := store.GetSession(context.Background(), id)
+		require.NoError(t, err)
+		require.True(t, session.IsBlocked)
+	}
+}
+
+func TestListSessions(t *testing.T) {
+	user := createRandomUser(t)
+
+	active, err := testQueries.CreateSession(context.Background(), randomSessionParams(t, user.Username))
+	require.NoError(t, err)
+
+	expiredArg := randomSessionParams(t, user.Username)
+	expiredArg.ExpiresAt = time.Now().Add(-time.Minute)
+	expired, err := testQueries.CreateSession(context.Background(), expiredArg)
+	require.NoError(t, err)
+
+	blockedArg := randomSessionParams(t, user.Username)
+	blockedArg.IsBlocked = true
+	blocked, err := testQueries.CreateSession(context.Background(), blockedArg)
+	require.NoError(t, err)
+
+	sessions, err := testQueries.ListSessions(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	require.Equal(t, active.ID, sessions[0].ID)
+	require.NotEqual(t, expired.ID, sessions[0].ID)
+	require.NotEqual(t, blocked.ID, sessions[0].ID)
+}
+
+func TestBlockUserSessions(t *testing.T) {
+	user := createRandomUser(t)
+	otherUser := createRandomUser(t)
+
+	for i := 0; i < 3; i++ {
+		_, err := testQueries.CreateSession(context.Background(), randomSessionParams(t, user.Username))
+		require.NoError(t, err)
+	}
+	other, err := testQueries.CreateSession(context.Background(), randomSessionParams(t, otherUser.Username))
+	require.NoError(t, err)
+
+	err = testQueries.BlockUserSessions(context.Background(), user.Username)
+	require.NoError(t, err)
+
+	sessions, err := testQueries.ListSessions(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Empty(t, sessions)
+
+	// sessions of other users are left alone
+	other, err = testQueries.GetSession(context.Background(), other.ID)
+	require.NoError(t, err)
+	require.False(t, other.IsBlocked)
+}
+
+func TestGetSessionState(t *testing.T) {
+	user := createRandomUser(t)
+
+	session, err := testQueries.CreateSession(context.Background(), randomSessionParams(t, user.Username))
+	require.NoError(t, err)
+
+	state, err := testQueries.GetSessionState(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.False(t, state.IsBlocked)
+	require.WithinDuration(t, user.PasswordChangedAt, state.PasswordChangedAt, time.Second)
+
+	passwordChangedAt := time.Now()
+	_, err = testQueries.UpdateUser(context.Background(), UpdateUserParams{
+		Username:          user.Username,
+		PasswordChangedAt: sql.NullTime{Time: passwordChangedAt, Valid: true},
+	})
+	require.NoError(t, err)
+
+	err = testQueries.BlockSessionFamily(context.Background(), session.FamilyID)
+	require.NoError(t, err)
+
+	state, err = testQueries.GetSessionState(context.Background(), session.ID)
+	require.NoError(t, err)
+	require.True(t, state.IsBlocked)
+	require.WithinDuration(t, passwordChangedAt, state.PasswordChangedAt, time.Second)
+}
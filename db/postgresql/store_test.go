@@ -4,14 +4,16 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+	"github.com/xmeizh/simplebank/util"
 )
 
 func TestTransferTx(t *testing.T) {
 	store := NewStore(testDB)
 
-	account1 := createRandomAccount(t)
+	account1 := createFundedAccount(t, 1000)
 	account2 := createRandomAccount(t)
 	fmt.Println(">> before:", account1.Balance, account2.Balance)
 
@@ -46,6 +48,8 @@ func TestTransferTx(t *testing.T) {
 		require.Equal(t, account1.ID, transfer.FromAccountID)
 		require.Equal(t, account2.ID, transfer.ToAccountID)
 		require.Equal(t, amount, transfer.Amount)
+		require.Equal(t, amount, transfer.ConvertedAmount)
+		require.Equal(t, 1.0, transfer.ExchangeRate)
 		require.NotZero(t, transfer.ID)
 		require.NotZero(t, transfer.CreatedAt)
 
@@ -109,8 +113,8 @@ func TestTransferTx(t *testing.T) {
 func TestTransferTxDeadlock(t *testing.T) {
 	store := NewStore(testDB)
 
-	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
+	account1 := createFundedAccount(t, 1000)
+	account2 := createFundedAccount(t, 1000)
 	fmt.Println(">> before:", account1.Balance, account2.Balance)
 
 	// run n concurrent transfer transactions
@@ -152,3 +156,211 @@ func TestTransferTxDeadlock(t *testing.T) {
 	require.Equal(t, account1.Balance, updateAccount1.Balance)
 	require.Equal(t, account2.Balance, updateAccount2.Balance)
 }
+
+func TestTransferTxIdempotency(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 1000)
+	account2 := createRandomAccount(t)
+
+	arg := TransferTxParams{
+		FromAccountID:  account1.ID,
+		ToAccountID:    account2.ID,
+		Amount:         10,
+		Currency:       account1.Currency,
+		Username:       account1.Owner,
+		IdempotencyKey: util.RandomString(32),
+	}
+
+	result1, err := store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	// replaying the same request returns the original result
+	result2, err := store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, result1.Transfer.ID, result2.Transfer.ID)
+	require.Equal(t, result1.FromEntry.ID, result2.FromEntry.ID)
+	require.Equal(t, result1.ToEntry.ID, result2.ToEntry.ID)
+
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance-arg.Amount, updatedAccount1.Balance)
+
+	// reusing the key with a different request is rejected
+	arg.Amount = 20
+	_, err = store.TransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrIdempotencyKeyConflict)
+
+	arg.Amount = 10
+	arg.Currency = util.EUR
+	if account1.Currency == util.EUR {
+		arg.Currency = util.USD
+	}
+	_, err = store.TransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrIdempotencyKeyConflict)
+}
+
+func TestHashTransferTxParams(t *testing.T) {
+	arg := TransferTxParams{
+		FromAccountID: 1,
+		ToAccountID:   2,
+		Amount:        10,
+		Currency:      util.USD,
+	}
+	hash := hashTransferTxParams(arg)
+
+	// the rate applied and the hook aren't part of the request
+	rated := arg
+	rated.ConvertedAmount = 5
+	rated.ExchangeRate = 0.5
+	require.Equal(t, hash, hashTransferTxParams(rated))
+
+	otherCurrency := arg
+	otherCurrency.Currency = util.EUR
+	require.NotEqual(t, hash, hashTransferTxParams(otherCurrency))
+}
+
+func TestTransferTxInsufficientFunds(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 100)
+	account2 := createRandomAccount(t)
+
+	arg := TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        account1.Balance + 1,
+	}
+
+	_, err := store.TransferTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// nothing is booked when the transfer is rejected
+	updatedAccount1, err := testQueries.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+
+	updatedAccount2, err := testQueries.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, account2.Balance, updatedAccount2.Balance)
+}
+
+func TestTransferTxCrossCurrency(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 1000)
+	account2 := createRandomAccount(t)
+
+	arg := TransferTxParams{
+		FromAccountID:   account1.ID,
+		ToAccountID:     account2.ID,
+		Amount:          100,
+		ConvertedAmount: 92,
+		ExchangeRate:    0.92,
+	}
+
+	result, err := store.TransferTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.Equal(t, arg.Amount, result.FromAmount)
+	require.Equal(t, arg.ConvertedAmount, result.ToAmount)
+
+	require.Equal(t, arg.Amount, result.Transfer.Amount)
+	require.Equal(t, arg.ConvertedAmount, result.Transfer.ConvertedAmount)
+	require.Equal(t, arg.ExchangeRate, result.Transfer.ExchangeRate)
+
+	require.Equal(t, -arg.Amount, result.FromEntry.Amount)
+	require.Equal(t, arg.ConvertedAmount, result.ToEntry.Amount)
+
+	require.Equal(t, account1.Balance-arg.Amount, result.FromAccount.Balance)
+	require.Equal(t, account2.Balance+arg.ConvertedAmount, result.ToAccount.Balance)
+}
+
+// createFundedAccount creates a random account holding the given balance
+func createFundedAccount(t *testing.T, balance int64) Account {
+	account := createRandomAccount(t)
+
+	account, err := testQueries.UpdateAccount(context.Background(), UpdateAccountParams{
+		ID:      account.ID,
+		Balance: balance,
+	})
+	require.NoError(t, err)
+	return account
+}
+
+func TestAccountStatementTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1 := createFundedAccount(t, 1000)
+	account2 := createFundedAccount(t, 1000)
+	fromTime := time.Now().Add(-time.Minute)
+
+	amounts := []int64{-10, -20, 5}
+	for _, amount := range amounts {
+		arg := TransferTxParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        -amount,
+		}
+		if amount > 0 {
+			arg.FromAccountID, arg.ToAccountID = account2.ID, account1.ID
+			arg.Amount = amount
+		}
+
+		_, err := store.TransferTx(context.Background(), arg)
+		require.NoError(t, err)
+	}
+
+	result, err := store.AccountStatementTx(context.Background(), AccountStatementTxParams{
+		AccountID: account1.ID,
+		FromTime:  fromTime,
+		ToTime:    time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1000), result.OpeningBalance)
+	require.Equal(t, int64(975), result.ClosingBalance)
+	require.Len(t, result.Entries, len(amounts))
+
+	balance := result.OpeningBalance
+	for i, entry := range result.Entries {
+		balance += amounts[i]
+		require.Equal(t, account1.ID, entry.AccountID)
+		require.Equal(t, amounts[i], entry.Amount)
+		require.Equal(t, balance, entry.RunningBalance)
+	}
+
+	// a period without entries opens and closes at the balance at that time
+	result, err = store.AccountStatementTx(context.Background(), AccountStatementTxParams{
+		AccountID: account1.ID,
+		FromTime:  time.Now().Add(time.Hour),
+		ToTime:    time.Now().Add(2 * time.Hour),
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(975), result.OpeningBalance)
+	require.Equal(t, int64(975), result.ClosingBalance)
+	require.Empty(t, result.Entries)
+}
+
+func TestAccountStatementTxOptions(t *testing.T) {
+	store := &SQLStore{
+		Queries: New(testDB),
+		db:      testDB,
+	}
+	user := createRandomUser(t)
+
+	err := store.execTxWithOptions(context.Background(), accountStatementTxOptions, func(q *Queries) error {
+		var isolation string
+		err := q.db.QueryRowContext(context.Background(), "SHOW transaction_isolation").Scan(&isolation)
+		require.NoError(t, err)
+		require.Equal(t, "repeatable read", isolation)
+
+		// the statement can't write
+		_, err = q.CreateAccount(context.Background(), CreateAccountParams{
+			Owner:    user.Username,
+			Balance:  0,
+			Currency: util.USD,
+		})
+		return err
+	})
+	require.ErrorContains(t, err, "read-only transaction")
+}
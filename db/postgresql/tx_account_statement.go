@@ -0,0 +1,63 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// accountStatementTxOptions makes the queries of a statement read the same snapshot,
+// so a transfer committed between them can't make the balances and the entries disagree
+var accountStatementTxOptions = &sql.TxOptions{
+	Isolation: sql.LevelRepeatableRead,
+	ReadOnly:  true,
+}
+
+type AccountStatementTxParams struct {
+	AccountID int64     `json:"account_id"`
+	FromTime  time.Time `json:"from_time"`
+	ToTime    time.Time `json:"to_time"`
+}
+
+type AccountStatementTxResult struct {
+	OpeningBalance int64                            `json:"opening_balance"`
+	ClosingBalance int64                            `json:"closing_balance"`
+	Entries        []ListAccountStatementEntriesRow `json:"entries"`
+}
+
+// AccountStatementTx lists the entries of an account booked in [FromTime, ToTime),
+// each with the balance of the account right after it was booked.
+// The opening balance is derived from the current balance, so it also accounts for balance updates made without entries.
+func (store *SQLStore) AccountStatementTx(ctx context.Context, arg AccountStatementTxParams) (AccountStatementTxResult, error) {
+	var result AccountStatementTxResult
+
+	err := store.execTxWithOptions(ctx, accountStatementTxOptions, func(q *Queries) error {
+		var err error
+
+		result.OpeningBalance, err = q.GetAccountBalanceAt(ctx, GetAccountBalanceAtParams{
+			At:        arg.FromTime,
+			AccountID: arg.AccountID,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.Entries, err = q.ListAccountStatementEntries(ctx, ListAccountStatementEntriesParams{
+			OpeningBalance: result.OpeningBalance,
+			AccountID:      arg.AccountID,
+			FromTime:       arg.FromTime,
+			ToTime:         arg.ToTime,
+		})
+		if err != nil {
+			return err
+		}
+
+		result.ClosingBalance = result.OpeningBalance
+		if n := len(result.Entries); n > 0 {
+			result.ClosingBalance = result.Entries[n-1].RunningBalance
+		}
+		return nil
+	})
+
+	return result, err
+}
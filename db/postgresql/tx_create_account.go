@@ -0,0 +1,31 @@
+package db
+
+import "context"
+
+type CreateAccountTxParams struct {
+	CreateAccountParams
+}
+
+type CreateAccountTxResult struct {
+	Account Account
+}
+
+// CreateAccountTx creates an account and records that it was opened
+func (store *SQLStore) CreateAccountTx(ctx context.Context, arg CreateAccountTxParams) (CreateAccountTxResult, error) {
+	var result CreateAccountTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.Account, err = q.CreateAccount(ctx, arg.CreateAccountParams)
+		if err != nil {
+			return err
+		}
+
+		return recordEvent(ctx, q, EventAccountOpened, result.Account.Owner, result.Account.ID, AccountOpenedEvent{
+			Account: result.Account,
+		})
+	})
+
+	return result, err
+}
@@ -4,7 +4,9 @@ import "context"
 
 type CreateUserTxParams struct {
 	CreateUserParams
-	AfterCreate func(user User) error
+	// AfterCreate runs inside the transaction with its queries, so tasks it writes to the outbox
+	// are only published if the user is committed
+	AfterCreate func(q Querier, user User) error
 }
 
 type CreateUserTxResult struct {
@@ -22,7 +24,24 @@ func (store *SQLStore) CreateUserTx(ctx context.Context, arg CreateUserTxParams)
 			return err
 		}
 
-		return arg.AfterCreate(result.User)
+		err = q.GrantUserRole(ctx, GrantUserRoleParams{
+			Username: result.User.Username,
+			Role:     result.User.Role,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = recordEvent(ctx, q, EventUserCreated, result.User.Username, 0, UserCreatedEvent{
+			Username: result.User.Username,
+			FullName: result.User.FullName,
+			Email:    result.User.Email,
+		})
+		if err != nil {
+			return err
+		}
+
+		return arg.AfterCreate(q, result.User)
 	})
 
 	return result, err
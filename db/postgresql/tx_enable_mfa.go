@@ -0,0 +1,56 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+type EnableMFATxParams struct {
+	Username string
+	// HashedRecoveryCodes replace any recovery codes left from a previous enrollment
+	HashedRecoveryCodes []string
+}
+
+type EnableMFATxResult struct {
+	User          User
+	RecoveryCodes []RecoveryCode
+}
+
+// EnableMFATx turns on MFA for a user whose TOTP secret has been confirmed and stores their new recovery codes.
+func (store *SQLStore) EnableMFATx(ctx context.Context, arg EnableMFATxParams) (EnableMFATxResult, error) {
+	var result EnableMFATxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.User, err = q.UpdateUser(ctx, UpdateUserParams{
+			Username: arg.Username,
+			IsMfaEnabled: sql.NullBool{
+				Bool:  true,
+				Valid: true,
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		err = q.DeleteRecoveryCodes(ctx, arg.Username)
+		if err != nil {
+			return err
+		}
+
+		for _, hashedCode := range arg.HashedRecoveryCodes {
+			code, err := q.CreateRecoveryCode(ctx, CreateRecoveryCodeParams{
+				Username:   arg.Username,
+				HashedCode: hashedCode,
+			})
+			if err != nil {
+				return err
+			}
+			result.RecoveryCodes = append(result.RecoveryCodes, code)
+		}
+		return nil
+	})
+
+	return result, err
+}
@@ -0,0 +1,60 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type RecordLoginFailureTxParams struct {
+	RecordLoginFailureParams
+	// MaxAttempts is the number of failed attempts after which the key is locked
+	MaxAttempts int32
+	LockedUntil time.Time
+	// AfterLock runs inside the transaction with its queries when this failure locked the key,
+	// so tasks it writes to the outbox are only published if the lock is committed
+	AfterLock func(q Querier, failure LoginFailure) error
+}
+
+type RecordLoginFailureTxResult struct {
+	Failure LoginFailure
+	// Locked reports whether this failure locked the key, it's false if the key was already locked
+	Locked bool
+}
+
+// RecordLoginFailureTx counts a failed login attempt and locks its key once it reaches MaxAttempts
+func (store *SQLStore) RecordLoginFailureTx(ctx context.Context, arg RecordLoginFailureTxParams) (RecordLoginFailureTxResult, error) {
+	var result RecordLoginFailureTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.Failure, err = q.RecordLoginFailure(ctx, arg.RecordLoginFailureParams)
+		if err != nil {
+			return err
+		}
+
+		if result.Failure.FailedAttempts < arg.MaxAttempts {
+			return nil
+		}
+
+		failure, err := q.LockLoginFailure(ctx, LockLoginFailureParams{
+			Scope:       arg.Scope,
+			Key:         arg.Key,
+			LockedUntil: sql.NullTime{Time: arg.LockedUntil, Valid: true},
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil
+			}
+			return err
+		}
+
+		result.Failure = failure
+		result.Locked = true
+
+		return arg.AfterLock(q, result.Failure)
+	})
+
+	return result, err
+}
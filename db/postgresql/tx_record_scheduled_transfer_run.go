@@ -0,0 +1,106 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Statuses of a scheduled transfer
+const (
+	ScheduledTransferActive    = "active"
+	ScheduledTransferCompleted = "completed"
+	ScheduledTransferCancelled = "cancelled"
+)
+
+// Outcomes of a scheduled transfer run
+const (
+	ScheduledTransferRunSucceeded = "succeeded"
+	ScheduledTransferRunFailed    = "failed"
+)
+
+type RecordScheduledTransferRunTxParams struct {
+	ScheduledTransferID int64     `json:"scheduled_transfer_id"`
+	RunAt               time.Time `json:"run_at"`
+	// TransferID is set when the transfer was booked, FailureReason when it wasn't
+	TransferID    int64  `json:"transfer_id"`
+	FailureReason string `json:"failure_reason"`
+}
+
+type RecordScheduledTransferRunTxResult struct {
+	ScheduledTransfer ScheduledTransfer    `json:"scheduled_transfer"`
+	Run               ScheduledTransferRun `json:"run"`
+}
+
+// RecordScheduledTransferRunTx records the outcome of a scheduled transfer run and moves the schedule to its next run.
+// It fails with ErrScheduledTransferNotDue if the run doesn't match the next run of an active schedule.
+func (store *SQLStore) RecordScheduledTransferRunTx(ctx context.Context, arg RecordScheduledTransferRunTxParams) (RecordScheduledTransferRunTxResult, error) {
+	var result RecordScheduledTransferRunTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		result, err = RecordScheduledTransferRun(ctx, q, arg)
+		return err
+	})
+
+	return result, err
+}
+
+// RecordScheduledTransferRun is RecordScheduledTransferRunTx within the transaction of q.
+// A successful run is recorded from the AfterTransfer of its transfer, so the transfer is rolled back
+// if the schedule was cancelled or already ran since the run was picked.
+func RecordScheduledTransferRun(ctx context.Context, q Querier, arg RecordScheduledTransferRunTxParams) (RecordScheduledTransferRunTxResult, error) {
+	var result RecordScheduledTransferRunTxResult
+
+	// the lock makes a concurrent cancellation wait until the run is recorded
+	scheduledTransfer, err := q.GetScheduledTransferForUpdate(ctx, arg.ScheduledTransferID)
+	if err != nil {
+		return result, err
+	}
+
+	if scheduledTransfer.Status != ScheduledTransferActive || !scheduledTransfer.NextRunAt.Equal(arg.RunAt) {
+		return result, ErrScheduledTransferNotDue
+	}
+
+	runArg := CreateScheduledTransferRunParams{
+		ScheduledTransferID: arg.ScheduledTransferID,
+		RunAt:               arg.RunAt,
+		Status:              ScheduledTransferRunSucceeded,
+		TransferID: sql.NullInt64{
+			Int64: arg.TransferID,
+			Valid: arg.TransferID != 0,
+		},
+	}
+	if arg.FailureReason != "" {
+		runArg.Status = ScheduledTransferRunFailed
+		runArg.FailureReason = arg.FailureReason
+	}
+
+	result.Run, err = q.CreateScheduledTransferRun(ctx, runArg)
+	if err != nil {
+		return result, err
+	}
+
+	nextRunAt, status := nextScheduledRun(scheduledTransfer)
+	result.ScheduledTransfer, err = q.UpdateScheduledTransferNextRun(ctx, UpdateScheduledTransferNextRunParams{
+		ID:        scheduledTransfer.ID,
+		NextRunAt: nextRunAt,
+		Status:    status,
+	})
+	return result, err
+}
+
+// nextScheduledRun returns when the scheduled transfer runs next.
+// One-off transfers and schedules past their end date are completed instead.
+// Runs missed while the worker was down are caught up one after the other.
+func nextScheduledRun(scheduledTransfer ScheduledTransfer) (time.Time, string) {
+	if scheduledTransfer.IntervalSeconds <= 0 {
+		return scheduledTransfer.NextRunAt, ScheduledTransferCompleted
+	}
+
+	nextRunAt := scheduledTransfer.NextRunAt.Add(time.Duration(scheduledTransfer.IntervalSeconds) * time.Second)
+	if scheduledTransfer.EndAt.Valid && nextRunAt.After(scheduledTransfer.EndAt.Time) {
+		return scheduledTransfer.NextRunAt, ScheduledTransferCompleted
+	}
+	return nextRunAt, ScheduledTransferActive
+}
@@ -0,0 +1,43 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+type RedeliverWebhookTxParams struct {
+	DeliveryID int64
+}
+
+type RedeliverWebhookTxResult struct {
+	// Superseded is the failed delivery, now superseded by Delivery
+	Superseded WebhookDelivery
+	Delivery   WebhookDelivery
+}
+
+// RedeliverWebhookTx queues a new delivery of the event of a failed one, and marks the failed one superseded
+// so it's only ever redelivered once.
+// It returns ErrWebhookDeliveryNotFailed if the delivery isn't failed.
+func (store *SQLStore) RedeliverWebhookTx(ctx context.Context, arg RedeliverWebhookTxParams) (RedeliverWebhookTxResult, error) {
+	var result RedeliverWebhookTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.Superseded, err = q.SupersedeWebhookDelivery(ctx, arg.DeliveryID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrWebhookDeliveryNotFailed
+			}
+			return err
+		}
+
+		result.Delivery, err = q.CreateWebhookDelivery(ctx, CreateWebhookDeliveryParams{
+			EndpointID: result.Superseded.EndpointID,
+			EventID:    result.Superseded.EventID,
+		})
+		return err
+	})
+
+	return result, err
+}
@@ -0,0 +1,76 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type RelayOutboxTxParams struct {
+	Limit int32
+	// Publish hands a message over to the task queue. It's called while the message is locked,
+	// so relays running side by side don't publish the same message.
+	Publish func(message Outbox) error
+	// MaxAttempts is how many times a message fails to publish before it's dead
+	MaxAttempts int32
+	// RetryDelay is how long a message that failed to publish attempts times waits before the next attempt
+	RetryDelay func(attempts int32) time.Duration
+}
+
+type RelayOutboxTxResult struct {
+	Sent   int
+	Failed int
+	// Dead is how many of the failed messages won't be retried
+	Dead int
+}
+
+// RelayOutboxTx publishes the oldest pending outbox messages and marks them sent.
+// A message that fails to publish stays pending with its error recorded, and is retried after RetryDelay,
+// so it doesn't hold up the messages behind it. After MaxAttempts it's dead and no longer relayed.
+func (store *SQLStore) RelayOutboxTx(ctx context.Context, arg RelayOutboxTxParams) (RelayOutboxTxResult, error) {
+	var result RelayOutboxTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		messages, err := q.ListPendingOutboxMessages(ctx, arg.Limit)
+		if err != nil {
+			return err
+		}
+
+		for _, message := range messages {
+			publishErr := arg.Publish(message)
+			if publishErr != nil {
+				attempts := message.Attempts + 1
+				failureArg := RecordOutboxMessageFailureParams{
+					ID: message.ID,
+					LastError: sql.NullString{
+						String: publishErr.Error(),
+						Valid:  true,
+					},
+					Dead: attempts >= arg.MaxAttempts,
+				}
+				if arg.RetryDelay != nil {
+					failureArg.RetryDelaySeconds = arg.RetryDelay(attempts).Seconds()
+				}
+
+				err = q.RecordOutboxMessageFailure(ctx, failureArg)
+				if err != nil {
+					return err
+				}
+				result.Failed++
+				if failureArg.Dead {
+					result.Dead++
+				}
+				continue
+			}
+
+			err = q.MarkOutboxMessageSent(ctx, message.ID)
+			if err != nil {
+				return err
+			}
+			result.Sent++
+		}
+		return nil
+	})
+
+	return result, err
+}
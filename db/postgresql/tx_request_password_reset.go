@@ -0,0 +1,45 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type RequestPasswordResetTxParams struct {
+	Username string
+	// RequestedBefore is the end of the cooldown, no email is sent if the user requested a reset since
+	RequestedBefore time.Time
+	// AfterRequest runs inside the transaction with its queries unless the user is cooling down,
+	// so tasks it writes to the outbox are only published if the request is committed
+	AfterRequest func(q Querier) error
+}
+
+type RequestPasswordResetTxResult struct {
+	// Requested is false if the user already requested a reset during the cooldown
+	Requested bool
+}
+
+// RequestPasswordResetTx records a password reset request of the user, at most one per cooldown
+func (store *SQLStore) RequestPasswordResetTx(ctx context.Context, arg RequestPasswordResetTxParams) (RequestPasswordResetTxResult, error) {
+	var result RequestPasswordResetTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		_, err := q.RecordPasswordResetRequest(ctx, RecordPasswordResetRequestParams{
+			Username:        arg.Username,
+			RequestedBefore: arg.RequestedBefore,
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil
+			}
+			return err
+		}
+
+		result.Requested = true
+
+		return arg.AfterRequest(q)
+	})
+
+	return result, err
+}
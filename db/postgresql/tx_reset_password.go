@@ -0,0 +1,67 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type ResetPasswordTxParams struct {
+	PasswordResetID int64
+	HashedCode      string
+	HashedPassword  string
+}
+
+type ResetPasswordTxResult struct {
+	User          User
+	PasswordReset PasswordReset
+}
+
+// ResetPasswordTx uses up a password reset, sets the new password and blocks all sessions of the user,
+// so whoever knew the old password is logged out everywhere. It also lifts a login lockout of the user.
+// It returns ErrPasswordResetInvalid if the reset can't be used.
+func (store *SQLStore) ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (ResetPasswordTxResult, error) {
+	var result ResetPasswordTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.PasswordReset, err = q.UsePasswordReset(ctx, UsePasswordResetParams{
+			ID:         arg.PasswordResetID,
+			HashedCode: arg.HashedCode,
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrPasswordResetInvalid
+			}
+			return err
+		}
+
+		result.User, err = q.UpdateUser(ctx, UpdateUserParams{
+			Username: result.PasswordReset.Username,
+			HashedPassword: sql.NullString{
+				String: arg.HashedPassword,
+				Valid:  true,
+			},
+			PasswordChangedAt: sql.NullTime{
+				Time:  time.Now(),
+				Valid: true,
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		err = q.BlockUserSessions(ctx, result.User.Username)
+		if err != nil {
+			return err
+		}
+
+		return q.DeleteLoginFailure(ctx, DeleteLoginFailureParams{
+			Scope: LoginFailureScopeUsername,
+			Key:   result.User.Username,
+		})
+	})
+
+	return result, err
+}
@@ -0,0 +1,42 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+type RotateSessionTxParams struct {
+	SessionID uuid.UUID
+	// NewSession replaces the rotated session, its family is taken from the rotated session
+	NewSession CreateSessionParams
+}
+
+type RotateSessionTxResult struct {
+	Session Session
+}
+
+// RotateSessionTx marks a session as rotated and creates the session replacing it in the same family.
+// It returns ErrSessionRotated if the session was already rotated, i.e. its refresh token is being reused.
+func (store *SQLStore) RotateSessionTx(ctx context.Context, arg RotateSessionTxParams) (RotateSessionTxResult, error) {
+	var result RotateSessionTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		rotated, err := q.RotateSession(ctx, arg.SessionID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrSessionRotated
+			}
+			return err
+		}
+
+		newSession := arg.NewSession
+		newSession.FamilyID = rotated.FamilyID
+
+		result.Session, err = q.CreateSession(ctx, newSession)
+		return err
+	})
+
+	return result, err
+}
@@ -1,11 +1,32 @@
 package db
 
-import "context"
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
 
 type TransferTxParams struct {
 	FromAccountID int64 `json:"from_account_id"`
 	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	// Amount is debited from the source account in its currency
+	Amount int64 `json:"amount"`
+	// Currency is optional, it's the currency of the source account the client asked to transfer
+	Currency string `json:"currency"`
+	// ConvertedAmount and ExchangeRate are only needed when the accounts hold different currencies.
+	// ConvertedAmount is credited to the destination account in its currency, it defaults to Amount.
+	ConvertedAmount int64   `json:"converted_amount"`
+	ExchangeRate    float64 `json:"exchange_rate"`
+	// Username and IdempotencyKey are optional. When the key is set, replaying
+	// a transfer with the same key returns the original result instead of booking it again.
+	Username       string `json:"username"`
+	IdempotencyKey string `json:"idempotency_key"`
+	// AfterTransfer is optional and runs inside the transaction with its queries, so tasks it writes
+	// to the outbox are only published if the transfer is committed. A replayed transfer skips it.
+	AfterTransfer func(q Querier, result TransferTxResult) error `json:"-"`
 }
 
 type TransferTxResult struct {
@@ -14,6 +35,9 @@ type TransferTxResult struct {
 	ToAccount   Account  `json:"to_account"`
 	FromEntry   Entry    `json:"from_entry"`
 	ToEntry     Entry    `json:"to_entry"`
+	// amounts moved in the currency of each account
+	FromAmount int64 `json:"from_amount"`
+	ToAmount   int64 `json:"to_amount"`
 }
 
 // TransferTx performs a money transfer from one account to the other,
@@ -21,10 +45,33 @@ type TransferTxResult struct {
 func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
 	var result TransferTxResult
 
+	if arg.ConvertedAmount == 0 {
+		arg.ConvertedAmount = arg.Amount
+		arg.ExchangeRate = 1
+	}
+
 	err := store.execTx(ctx, func(q *Queries) error {
 		var err error
 
-		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams(arg))
+		if arg.IdempotencyKey != "" {
+			replayed, err := claimIdempotencyKey(ctx, q, arg, &result)
+			if err != nil || replayed {
+				return err
+			}
+		}
+
+		err = checkSufficientFunds(ctx, q, arg)
+		if err != nil {
+			return err
+		}
+
+		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
+			FromAccountID:   arg.FromAccountID,
+			ToAccountID:     arg.ToAccountID,
+			Amount:          arg.Amount,
+			ConvertedAmount: arg.ConvertedAmount,
+			ExchangeRate:    arg.ExchangeRate,
+		})
 		if err != nil {
 			return err
 		}
@@ -39,7 +86,7 @@ func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (Tr
 
 		result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
 			AccountID: arg.ToAccountID,
-			Amount:    arg.Amount,
+			Amount:    arg.ConvertedAmount,
 		})
 
 		if err != nil {
@@ -48,21 +95,157 @@ func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (Tr
 
 		// update accounts' balances
 		if arg.FromAccountID < arg.ToAccountID {
-			result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
+			result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.ConvertedAmount)
 		} else {
-			result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, arg.Amount, arg.FromAccountID, -arg.Amount)
+			result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, arg.ConvertedAmount, arg.FromAccountID, -arg.Amount)
+		}
+
+		if err != nil {
+			return err
 		}
 
+		result.FromAmount = arg.Amount
+		result.ToAmount = arg.ConvertedAmount
+
+		err = recordTransferCompleted(ctx, q, result)
 		if err != nil {
 			return err
 		}
 
+		if arg.AfterTransfer != nil {
+			err = arg.AfterTransfer(q, result)
+			if err != nil {
+				return err
+			}
+		}
+
+		if arg.IdempotencyKey != "" {
+			return saveIdempotentResult(ctx, q, arg, result)
+		}
 		return nil
 	})
 
 	return result, err
 }
 
+// recordTransferCompleted records the transfer for the owners of both accounts
+func recordTransferCompleted(ctx context.Context, q *Queries, result TransferTxResult) error {
+	payload := TransferCompletedEvent{
+		Transfer: result.Transfer,
+	}
+
+	err := recordEvent(ctx, q, EventTransferCompleted, result.FromAccount.Owner, result.FromAccount.ID, payload)
+	if err != nil {
+		return err
+	}
+	return recordEvent(ctx, q, EventTransferCompleted, result.ToAccount.Owner, result.ToAccount.ID, payload)
+}
+
+// claimIdempotencyKey reserves the idempotency key of the transfer.
+// If the key was already used by the same request, the stored result is loaded and replayed is true.
+// A concurrent request holding the same key blocks the insert until its transaction is done.
+func claimIdempotencyKey(ctx context.Context, q *Queries, arg TransferTxParams, result *TransferTxResult) (replayed bool, err error) {
+	requestHash := hashTransferTxParams(arg)
+
+	_, err = q.CreateIdempotencyKey(ctx, CreateIdempotencyKeyParams{
+		Username:    arg.Username,
+		Key:         arg.IdempotencyKey,
+		RequestHash: requestHash,
+	})
+	if err == nil {
+		return false, nil
+	}
+	if err != sql.ErrNoRows {
+		return false, err
+	}
+
+	// the key already exists
+	idempotencyKey, err := q.GetIdempotencyKey(ctx, GetIdempotencyKeyParams{
+		Username: arg.Username,
+		Key:      arg.IdempotencyKey,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if idempotencyKey.RequestHash != requestHash {
+		return false, ErrIdempotencyKeyConflict
+	}
+
+	err = json.Unmarshal(idempotencyKey.Response, result)
+	if err != nil {
+		return false, fmt.Errorf("cannot unmarshal stored transfer result: %w", err)
+	}
+	return true, nil
+}
+
+func saveIdempotentResult(ctx context.Context, q *Queries, arg TransferTxParams, result TransferTxResult) error {
+	response, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("cannot marshal transfer result: %w", err)
+	}
+
+	_, err = q.UpdateIdempotencyKeyResponse(ctx, UpdateIdempotencyKeyResponseParams{
+		Username: arg.Username,
+		Key:      arg.IdempotencyKey,
+		Response: response,
+	})
+	return err
+}
+
+// transferRequest is what the client asked for, which the idempotency key of a transfer is bound to
+type transferRequest struct {
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	Amount        int64  `json:"amount"`
+	Currency      string `json:"currency"`
+}
+
+// hashTransferTxParams only covers what the client asked for.
+// The converted amount depends on the current rate, so a retry replays the rate applied the first time.
+// The request is JSON encoded, so different requests can't be hashed from the same bytes.
+func hashTransferTxParams(arg TransferTxParams) string {
+	request, _ := json.Marshal(transferRequest{
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+		Currency:      arg.Currency,
+	})
+	hash := sha256.Sum256(request)
+	return hex.EncodeToString(hash[:])
+}
+
+// checkSufficientFunds locks both accounts and makes sure the transfer
+// doesn't take the source account below its overdraft limit.
+// Accounts are always locked in the same order to avoid deadlocks.
+func checkSufficientFunds(ctx context.Context, q *Queries, arg TransferTxParams) error {
+	var fromAccount Account
+	var err error
+
+	if arg.FromAccountID < arg.ToAccountID {
+		fromAccount, err = q.GetAccountForUpdate(ctx, arg.FromAccountID)
+		if err != nil {
+			return err
+		}
+		_, err = q.GetAccountForUpdate(ctx, arg.ToAccountID)
+	} else {
+		_, err = q.GetAccountForUpdate(ctx, arg.ToAccountID)
+		if err != nil {
+			return err
+		}
+		fromAccount, err = q.GetAccountForUpdate(ctx, arg.FromAccountID)
+	}
+	if err != nil {
+		return err
+	}
+
+	if fromAccount.Balance-arg.Amount < -fromAccount.OverdraftLimit {
+		return fmt.Errorf("%w: account [%d] has balance %d and overdraft limit %d, cannot transfer %d",
+			ErrInsufficientFunds, fromAccount.ID, fromAccount.Balance, fromAccount.OverdraftLimit, arg.Amount)
+	}
+	return nil
+}
+
 func addMoney(ctx context.Context, q *Queries, accountID1, amount1, accountID2, amount2 int64) (account1 Account, account2 Account, err error) {
 	account1, err = q.AddAccountBalance(ctx, AddAccountBalanceParams{
 		Amount: amount1,
@@ -0,0 +1,49 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+// scopes of the failed login attempts tracked in login_failures
+const (
+	LoginFailureScopeUsername = "username"
+	LoginFailureScopeClientIP = "client_ip"
+)
+
+type UnlockAccountTxParams struct {
+	AccountUnlockID int64
+	HashedCode      string
+}
+
+type UnlockAccountTxResult struct {
+	AccountUnlock AccountUnlock
+}
+
+// UnlockAccountTx uses up an account unlock and clears the failed login attempts of its user.
+// It returns ErrAccountUnlockInvalid if the unlock can't be used.
+func (store *SQLStore) UnlockAccountTx(ctx context.Context, arg UnlockAccountTxParams) (UnlockAccountTxResult, error) {
+	var result UnlockAccountTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+
+		result.AccountUnlock, err = q.UseAccountUnlock(ctx, UseAccountUnlockParams{
+			ID:         arg.AccountUnlockID,
+			HashedCode: arg.HashedCode,
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return ErrAccountUnlockInvalid
+			}
+			return err
+		}
+
+		return q.DeleteLoginFailure(ctx, DeleteLoginFailureParams{
+			Scope: LoginFailureScopeUsername,
+			Key:   result.AccountUnlock.Username,
+		})
+	})
+
+	return result, err
+}
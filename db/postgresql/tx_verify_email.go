@@ -36,7 +36,14 @@ func (store *SQLStore) VerifyEmailTx(ctx context.Context, arg VerifyEmailTxParam
 				Valid: true,
 			},
 		})
-		return err
+		if err != nil {
+			return err
+		}
+
+		return recordEvent(ctx, q, EventEmailVerified, result.User.Username, 0, EmailVerifiedEvent{
+			Username: result.User.Username,
+			Email:    result.VerifyEmail.Email,
+		})
 	})
 
 	return result, err
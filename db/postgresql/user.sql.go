@@ -19,7 +19,7 @@ INSERT INTO users (
 ) VALUES (
   $1, $2, $3, $4
 )
-RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, totp_secret, is_mfa_enabled, totp_last_step
 `
 
 type CreateUserParams struct {
@@ -46,12 +46,15 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.CreatedAt,
 		&i.IsEmailVerified,
 		&i.Role,
+		&i.TotpSecret,
+		&i.IsMfaEnabled,
+		&i.TotpLastStep,
 	)
 	return i, err
 }
 
 const getUser = `-- name: GetUser :one
-SELECT username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role FROM users
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, totp_secret, is_mfa_enabled, totp_last_step FROM users
 WHERE username = $1 LIMIT 1
 `
 
@@ -67,6 +70,33 @@ func (q *Queries) GetUser(ctx context.Context, username string) (User, error) {
 		&i.CreatedAt,
 		&i.IsEmailVerified,
 		&i.Role,
+		&i.TotpSecret,
+		&i.IsMfaEnabled,
+		&i.TotpLastStep,
+	)
+	return i, err
+}
+
+const getUserByEmail = `-- name: GetUserByEmail :one
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, totp_secret, is_mfa_enabled, totp_last_step FROM users
+WHERE email = $1 LIMIT 1
+`
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByEmail, email)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.IsEmailVerified,
+		&i.Role,
+		&i.TotpSecret,
+		&i.IsMfaEnabled,
+		&i.TotpLastStep,
 	)
 	return i, err
 }
@@ -78,10 +108,12 @@ SET
   password_changed_at = coalesce($2, password_changed_at),
   full_name = coalesce($3, full_name),
   email = coalesce($4, email),
-  is_email_verified = coalesce($5, is_email_verified)
+  is_email_verified = coalesce($5, is_email_verified),
+  totp_secret = coalesce($6, totp_secret),
+  is_mfa_enabled = coalesce($7, is_mfa_enabled)
 WHERE
-  username = $6
-RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role
+  username = $8
+RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, is_email_verified, role, totp_secret, is_mfa_enabled, totp_last_step
 `
 
 type UpdateUserParams struct {
@@ -90,6 +122,8 @@ type UpdateUserParams struct {
 	FullName          sql.NullString `json:"full_name"`
 	Email             sql.NullString `json:"email"`
 	IsEmailVerified   sql.NullBool   `json:"is_email_verified"`
+	TotpSecret        sql.NullString `json:"totp_secret"`
+	IsMfaEnabled      sql.NullBool   `json:"is_mfa_enabled"`
 	Username          string         `json:"username"`
 }
 
@@ -100,6 +134,8 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		arg.FullName,
 		arg.Email,
 		arg.IsEmailVerified,
+		arg.TotpSecret,
+		arg.IsMfaEnabled,
 		arg.Username,
 	)
 	var i User
@@ -112,6 +148,29 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.CreatedAt,
 		&i.IsEmailVerified,
 		&i.Role,
+		&i.TotpSecret,
+		&i.IsMfaEnabled,
+		&i.TotpLastStep,
 	)
 	return i, err
 }
+
+const useTotpStep = `-- name: UseTotpStep :execrows
+UPDATE users
+SET totp_last_step = $1
+WHERE username = $2 AND totp_last_step < $1
+`
+
+type UseTotpStepParams struct {
+	Step     int64  `json:"step"`
+	Username string `json:"username"`
+}
+
+// Records the time step of an accepted TOTP code, no row is updated if a code of this step or a later one was already accepted
+func (q *Queries) UseTotpStep(ctx context.Context, arg UseTotpStepParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, useTotpStep, arg.Step, arg.Username)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
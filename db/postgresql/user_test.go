@@ -134,3 +134,24 @@ func TestUpdateUserAllFields(t *testing.T) {
 	require.Equal(t, newFullName, user.FullName)
 	require.Equal(t, newEmail, user.Email)
 }
+
+func TestUpdateUserOnlyTotpSecret(t *testing.T) {
+	oldUser := createRandomUser(t)
+	require.Empty(t, oldUser.TotpSecret)
+	require.False(t, oldUser.IsMfaEnabled)
+
+	newSecret := util.RandomString(32)
+	user, err := testQueries.UpdateUser(context.Background(), UpdateUserParams{
+		TotpSecret: sql.NullString{
+			String: newSecret,
+			Valid:  true,
+		},
+		Username: oldUser.Username,
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, newSecret, user.TotpSecret)
+	require.False(t, user.IsMfaEnabled)
+	require.Equal(t, oldUser.HashedPassword, user.HashedPassword)
+	require.Equal(t, oldUser.Email, user.Email)
+}
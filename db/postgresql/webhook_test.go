@@ -0,0 +1,168 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xmeizh/simplebank/util"
+)
+
+func createRandomWebhookEndpoint(t *testing.T, username string, eventTypes ...string) WebhookEndpoint {
+	arg := CreateWebhookEndpointParams{
+		Username:   username,
+		Url:        "https://example.com/" + util.RandomString(6),
+		Secret:     util.RandomString(32),
+		EventTypes: eventTypes,
+	}
+	if arg.EventTypes == nil {
+		arg.EventTypes = []string{}
+	}
+
+	endpoint, err := testQueries.CreateWebhookEndpoint(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, endpoint)
+	require.Equal(t, arg.Username, endpoint.Username)
+	require.Equal(t, arg.Url, endpoint.Url)
+	require.Equal(t, arg.Secret, endpoint.Secret)
+	require.Equal(t, arg.EventTypes, endpoint.EventTypes)
+	require.NotZero(t, endpoint.CreatedAt)
+
+	return endpoint
+}
+
+func TestCreateEventWebhookDeliveries(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	allEndpoint := createRandomWebhookEndpoint(t, user.Username)
+	transferEndpoint := createRandomWebhookEndpoint(t, user.Username, EventTransferCompleted)
+
+	result, err := store.CreateAccountTx(context.Background(), CreateAccountTxParams{
+		CreateAccountParams: CreateAccountParams{
+			Owner:    user.Username,
+			Currency: util.RandomCurrency(),
+		},
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, result.Account)
+
+	// only the endpoint subscribed to every event receives the account opening
+	deliveries, err := store.ListWebhookDeliveriesAfter(context.Background(), ListWebhookDeliveriesAfterParams{
+		Username: user.Username,
+		Limit:    10,
+	})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	require.Equal(t, allEndpoint.ID, deliveries[0].EndpointID)
+	require.Equal(t, WebhookDeliveryPending, deliveries[0].Status)
+	require.NotEqual(t, transferEndpoint.ID, deliveries[0].EndpointID)
+
+	request, err := store.GetWebhookDeliveryRequest(context.Background(), deliveries[0].ID)
+	require.NoError(t, err)
+	require.Equal(t, allEndpoint.Url, request.Url)
+	require.Equal(t, allEndpoint.Secret, request.Secret)
+	require.Equal(t, EventAccountOpened, request.EventType)
+	require.Equal(t, result.Account.ID, request.AccountID.Int64)
+}
+
+func TestUpdateWebhookDeliveryAttempt(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	createRandomWebhookEndpoint(t, user.Username)
+
+	_, err := store.CreateAccountTx(context.Background(), CreateAccountTxParams{
+		CreateAccountParams: CreateAccountParams{
+			Owner:    user.Username,
+			Currency: util.RandomCurrency(),
+		},
+	})
+	require.NoError(t, err)
+
+	pending, err := store.ListWebhookDeliveriesAfter(context.Background(), ListWebhookDeliveriesAfterParams{
+		Username: user.Username,
+		Status:   sql.NullString{String: WebhookDeliveryPending, Valid: true},
+		Limit:    10,
+	})
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+
+	delivery, err := store.UpdateWebhookDeliveryAttempt(context.Background(), UpdateWebhookDeliveryAttemptParams{
+		ID:             pending[0].ID,
+		Status:         WebhookDeliveryPending,
+		ResponseStatus: sql.NullInt32{Int32: 500, Valid: true},
+		LastError:      "unexpected response status 500",
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), delivery.Attempts)
+	require.Equal(t, int32(500), delivery.ResponseStatus.Int32)
+	require.False(t, delivery.DeliveredAt.Valid)
+
+	delivery, err = store.UpdateWebhookDeliveryAttempt(context.Background(), UpdateWebhookDeliveryAttemptParams{
+		ID:             delivery.ID,
+		Status:         WebhookDeliverySucceeded,
+		ResponseStatus: sql.NullInt32{Int32: 200, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int32(2), delivery.Attempts)
+	require.Empty(t, delivery.LastError)
+	require.True(t, delivery.DeliveredAt.Valid)
+
+	// the delivery is no longer pending
+	pending, err = store.ListWebhookDeliveriesAfter(context.Background(), ListWebhookDeliveriesAfterParams{
+		Username: user.Username,
+		Status:   sql.NullString{String: WebhookDeliveryPending, Valid: true},
+		Limit:    10,
+	})
+	require.NoError(t, err)
+	require.Empty(t, pending)
+}
+
+func TestRedeliverWebhookTx(t *testing.T) {
+	store := NewStore(testDB)
+	user := createRandomUser(t)
+	createRandomWebhookEndpoint(t, user.Username)
+
+	_, err := store.CreateAccountTx(context.Background(), CreateAccountTxParams{
+		CreateAccountParams: CreateAccountParams{
+			Owner:    user.Username,
+			Currency: util.RandomCurrency(),
+		},
+	})
+	require.NoError(t, err)
+
+	pending, err := store.ListWebhookDeliveriesAfter(context.Background(), ListWebhookDeliveriesAfterParams{
+		Username: user.Username,
+		Status:   sql.NullString{String: WebhookDeliveryPending, Valid: true},
+		Limit:    10,
+	})
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+
+	// only failed deliveries can be redelivered
+	_, err = store.RedeliverWebhookTx(context.Background(), RedeliverWebhookTxParams{DeliveryID: pending[0].ID})
+	require.ErrorIs(t, err, ErrWebhookDeliveryNotFailed)
+
+	err = store.FailWebhookDelivery(context.Background(), pending[0].ID)
+	require.NoError(t, err)
+
+	result, err := store.RedeliverWebhookTx(context.Background(), RedeliverWebhookTxParams{DeliveryID: pending[0].ID})
+	require.NoError(t, err)
+	require.Equal(t, WebhookDeliverySuperseded, result.Superseded.Status)
+	require.NotEqual(t, pending[0].ID, result.Delivery.ID)
+	require.Equal(t, pending[0].EventID, result.Delivery.EventID)
+	require.Equal(t, pending[0].EndpointID, result.Delivery.EndpointID)
+	require.Equal(t, WebhookDeliveryPending, result.Delivery.Status)
+
+	// a delivery is only redelivered once
+	_, err = store.RedeliverWebhookTx(context.Background(), RedeliverWebhookTxParams{DeliveryID: pending[0].ID})
+	require.ErrorIs(t, err, ErrWebhookDeliveryNotFailed)
+
+	// only pending deliveries are marked failed
+	err = store.FailWebhookDelivery(context.Background(), pending[0].ID)
+	require.NoError(t, err)
+
+	delivery, err := store.GetWebhookDelivery(context.Background(), pending[0].ID)
+	require.NoError(t, err)
+	require.Equal(t, WebhookDeliverySuperseded, delivery.Status)
+}
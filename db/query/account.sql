@@ -22,6 +22,22 @@ ORDER BY id
 LIMIT $2
 OFFSET $3;
 
+-- name: ListAccountsAfter :many
+SELECT * FROM accounts
+WHERE owner = sqlc.arg(owner)
+  AND (created_at, id) > (sqlc.arg(after_created_at), sqlc.arg(after_id))
+ORDER BY created_at, id
+LIMIT sqlc.arg('limit');
+
+-- name: ListVerifiedOwnerAccounts :many
+SELECT accounts.* FROM accounts
+JOIN users ON users.username = accounts.owner
+WHERE users.is_email_verified = true
+  AND accounts.created_at < sqlc.arg(created_before)
+  AND accounts.id > sqlc.arg(after_id)
+ORDER BY accounts.id
+LIMIT sqlc.arg('limit');
+
 -- name: UpdateAccount :one
 UPDATE accounts SET balance = $2
 WHERE id = $1
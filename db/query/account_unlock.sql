@@ -0,0 +1,18 @@
+-- name: CreateAccountUnlock :one
+INSERT INTO account_unlocks (
+  username,
+  hashed_code
+) VALUES (
+  $1, $2
+) RETURNING *;
+
+-- name: UseAccountUnlock :one
+UPDATE account_unlocks
+SET
+  is_used = TRUE
+WHERE
+  id = @id
+  AND hashed_code = @hashed_code
+  AND is_used = FALSE
+  AND expired_at > now()
+RETURNING *;
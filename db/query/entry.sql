@@ -16,3 +16,30 @@ WHERE account_id = $1
 ORDER BY id
 LIMIT $2
 OFFSET $3;
+
+-- name: ListEntriesAfter :many
+SELECT * FROM entries
+WHERE account_id = sqlc.arg(account_id)
+  AND (created_at, id) > (sqlc.arg(after_created_at), sqlc.arg(after_id))
+ORDER BY created_at, id
+LIMIT sqlc.arg('limit');
+
+-- name: GetAccountBalanceAt :one
+SELECT (accounts.balance - coalesce(sum(entries.amount), 0))::bigint AS balance
+FROM accounts
+LEFT JOIN entries ON entries.account_id = accounts.id AND entries.created_at >= sqlc.arg(at)
+WHERE accounts.id = sqlc.arg(account_id)
+GROUP BY accounts.id;
+
+-- name: ListAccountStatementEntries :many
+SELECT
+  id,
+  account_id,
+  amount,
+  created_at,
+  (sqlc.arg(opening_balance)::bigint + sum(amount) OVER (ORDER BY created_at, id))::bigint AS running_balance
+FROM entries
+WHERE account_id = sqlc.arg(account_id)
+  AND created_at >= sqlc.arg(from_time)
+  AND created_at < sqlc.arg(to_time)
+ORDER BY created_at, id;
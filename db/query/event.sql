@@ -0,0 +1,20 @@
+-- name: CreateEvent :one
+INSERT INTO events (
+  type,
+  username,
+  account_id,
+  payload
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: ListEventsAfter :many
+-- Only events of transactions older than every running transaction are listed,
+-- so an event committed late can't end up before an offset that was already streamed
+SELECT * FROM events
+WHERE username = @username
+  AND (sqlc.narg(account_id)::bigint IS NULL OR account_id = sqlc.narg(account_id))
+  AND (transaction_id, id) > (@after_transaction_id::bigint, @after_id::bigint)
+  AND transaction_id < pg_snapshot_xmin(pg_current_snapshot())::text::bigint
+ORDER BY transaction_id, id
+LIMIT @limit;
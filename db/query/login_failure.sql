@@ -0,0 +1,38 @@
+-- name: GetLoginFailure :one
+SELECT * FROM login_failures
+WHERE scope = $1 AND key = $2 LIMIT 1;
+
+-- name: RecordLoginFailure :one
+INSERT INTO login_failures (
+  scope,
+  key,
+  failed_attempts,
+  last_failed_at
+) VALUES (
+  @scope, @key, 1, now()
+)
+ON CONFLICT (scope, key) DO UPDATE
+SET
+  failed_attempts = CASE
+    WHEN login_failures.last_failed_at < @reset_before THEN 1
+    ELSE login_failures.failed_attempts + 1
+  END,
+  last_failed_at = now(),
+  locked_until = CASE
+    WHEN login_failures.last_failed_at < @reset_before THEN NULL
+    ELSE login_failures.locked_until
+  END
+RETURNING *;
+
+-- name: LockLoginFailure :one
+-- LockLoginFailure returns sql.ErrNoRows if the key is already locked,
+-- so only one of concurrent failures reaching the limit sets the lock
+UPDATE login_failures
+SET locked_until = $3
+WHERE scope = $1 AND key = $2
+  AND (locked_until IS NULL OR locked_until <= now())
+RETURNING *;
+
+-- name: DeleteLoginFailure :exec
+DELETE FROM login_failures
+WHERE scope = $1 AND key = $2;
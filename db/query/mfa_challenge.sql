@@ -0,0 +1,25 @@
+-- name: CreateMfaChallenge :one
+INSERT INTO mfa_challenges (
+  id,
+  username,
+  expires_at
+) VALUES (
+  $1, $2, $3
+)
+RETURNING *;
+
+-- name: GetMfaChallenge :one
+SELECT * FROM mfa_challenges
+WHERE id = $1 LIMIT 1;
+
+-- name: IncrementMfaChallengeFailedAttempts :one
+UPDATE mfa_challenges
+SET failed_attempts = failed_attempts + 1
+WHERE id = $1
+RETURNING *;
+
+-- name: UseMfaChallenge :one
+UPDATE mfa_challenges
+SET is_used = true
+WHERE id = $1 AND is_used = false
+RETURNING *;
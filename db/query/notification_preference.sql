@@ -0,0 +1,23 @@
+-- name: GetNotificationPreferences :one
+SELECT * FROM notification_preferences
+WHERE username = $1 LIMIT 1;
+
+-- name: UpsertNotificationPreferences :one
+-- Creates the preferences of the user, or updates the ones that are set
+INSERT INTO notification_preferences (
+  username,
+  transfer_debit_emails,
+  transfer_credit_emails,
+  locale
+) VALUES (
+  sqlc.arg(username),
+  COALESCE(sqlc.narg(transfer_debit_emails), true),
+  COALESCE(sqlc.narg(transfer_credit_emails), true),
+  COALESCE(sqlc.narg(locale), 'en')
+)
+ON CONFLICT (username) DO UPDATE SET
+  transfer_debit_emails = COALESCE(sqlc.narg(transfer_debit_emails), notification_preferences.transfer_debit_emails),
+  transfer_credit_emails = COALESCE(sqlc.narg(transfer_credit_emails), notification_preferences.transfer_credit_emails),
+  locale = COALESCE(sqlc.narg(locale), notification_preferences.locale),
+  updated_at = now()
+RETURNING *;
@@ -0,0 +1,37 @@
+-- name: CreateOutboxMessage :one
+INSERT INTO outbox (
+  task_type,
+  payload,
+  queue,
+  max_retry,
+  process_at
+) VALUES (
+  $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: ListPendingOutboxMessages :many
+-- Messages backing off after a failure and dead messages aren't pending
+SELECT * FROM outbox
+WHERE sent_at IS NULL AND dead_at IS NULL AND available_at <= now()
+ORDER BY id
+LIMIT $1
+FOR UPDATE SKIP LOCKED;
+
+-- name: MarkOutboxMessageSent :exec
+UPDATE outbox
+SET sent_at = now()
+WHERE id = $1;
+
+-- name: RecordOutboxMessageFailure :exec
+-- Records a failed attempt, the message is retried after the delay unless it's dead
+UPDATE outbox
+SET
+  attempts = attempts + 1,
+  last_error = sqlc.arg(last_error),
+  available_at = now() + make_interval(secs => sqlc.arg(retry_delay_seconds)::float8),
+  dead_at = CASE WHEN sqlc.arg(dead)::bool THEN now() END
+WHERE id = sqlc.arg(id);
+
+-- name: DeleteSentOutboxMessages :execrows
+DELETE FROM outbox
+WHERE sent_at < $1;
@@ -0,0 +1,31 @@
+-- name: CreatePasswordReset :one
+INSERT INTO password_resets (
+  username,
+  hashed_code
+) VALUES (
+  $1, $2
+) RETURNING *;
+
+-- name: UsePasswordReset :one
+UPDATE password_resets
+SET
+  is_used = TRUE
+WHERE
+  id = @id
+  AND hashed_code = @hashed_code
+  AND is_used = FALSE
+  AND expired_at > now()
+RETURNING *;
+
+-- name: RecordPasswordResetRequest :one
+-- RecordPasswordResetRequest returns sql.ErrNoRows if the user already requested a reset since requested_before
+INSERT INTO password_reset_requests (
+  username,
+  requested_at
+) VALUES (
+  @username, now()
+)
+ON CONFLICT (username) DO UPDATE
+SET requested_at = now()
+WHERE password_reset_requests.requested_at < @requested_before
+RETURNING *;
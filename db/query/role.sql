@@ -0,0 +1,24 @@
+-- name: GrantUserRole :exec
+INSERT INTO user_roles (
+  username,
+  role
+) VALUES (
+  $1, $2
+)
+ON CONFLICT (username, role) DO NOTHING;
+
+-- name: RevokeUserRole :execrows
+DELETE FROM user_roles
+WHERE username = $1 AND role = $2;
+
+-- name: ListUserRoles :many
+SELECT role FROM user_roles
+WHERE username = $1
+ORDER BY role;
+
+-- name: ListUserPermissions :many
+SELECT DISTINCT role_permissions.permission
+FROM user_roles
+JOIN role_permissions ON role_permissions.role = user_roles.role
+WHERE user_roles.username = $1
+ORDER BY role_permissions.permission;
@@ -13,6 +13,10 @@ RETURNING *;
 SELECT * FROM users
 WHERE username = $1 LIMIT 1;
 
+-- name: GetUserByEmail :one
+SELECT * FROM users
+WHERE email = $1 LIMIT 1;
+
 
 -- name: UpdateUser :one
 UPDATE users
@@ -21,7 +25,15 @@ SET
   password_changed_at = coalesce(sqlc.narg('password_changed_at'), password_changed_at),
   full_name = coalesce(sqlc.narg('full_name'), full_name),
   email = coalesce(sqlc.narg('email'), email),
-  is_email_verified = coalesce(sqlc.narg('is_email_verified'), is_email_verified)
+  is_email_verified = coalesce(sqlc.narg('is_email_verified'), is_email_verified),
+  totp_secret = coalesce(sqlc.narg('totp_secret'), totp_secret),
+  is_mfa_enabled = coalesce(sqlc.narg('is_mfa_enabled'), is_mfa_enabled)
 WHERE
   username = sqlc.arg(username)
-RETURNING *;
\ No newline at end of file
+RETURNING *;
+
+-- name: UseTotpStep :execrows
+-- Records the time step of an accepted TOTP code, no row is updated if a code of this step or a later one was already accepted
+UPDATE users
+SET totp_last_step = sqlc.arg(step)
+WHERE username = sqlc.arg(username) AND totp_last_step < sqlc.arg(step);
@@ -0,0 +1,91 @@
+-- name: CreateWebhookEndpoint :one
+INSERT INTO webhook_endpoints (
+  username,
+  url,
+  secret,
+  event_types
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetWebhookEndpoint :one
+SELECT * FROM webhook_endpoints
+WHERE id = $1 LIMIT 1;
+
+-- name: CreateEventWebhookDeliveries :exec
+-- Queues a delivery of the event to every endpoint of its user subscribed to its type
+INSERT INTO webhook_deliveries (
+  endpoint_id,
+  event_id
+)
+SELECT id, sqlc.arg(event_id)::bigint FROM webhook_endpoints
+WHERE username = sqlc.arg(username)
+  AND (cardinality(event_types) = 0 OR sqlc.arg(event_type)::varchar = ANY(event_types));
+
+-- name: CreateWebhookDelivery :one
+INSERT INTO webhook_deliveries (
+  endpoint_id,
+  event_id
+) VALUES (
+  $1, $2
+) RETURNING *;
+
+-- name: GetWebhookDelivery :one
+SELECT * FROM webhook_deliveries
+WHERE id = $1 LIMIT 1;
+
+-- name: GetWebhookDeliveryRequest :one
+SELECT
+  webhook_deliveries.id,
+  webhook_deliveries.status,
+  webhook_endpoints.url,
+  webhook_endpoints.secret,
+  events.id AS event_id,
+  events.type AS event_type,
+  events.account_id,
+  events.payload,
+  events.created_at AS event_created_at
+FROM webhook_deliveries
+JOIN webhook_endpoints ON webhook_endpoints.id = webhook_deliveries.endpoint_id
+JOIN events ON events.id = webhook_deliveries.event_id
+WHERE webhook_deliveries.id = $1 LIMIT 1;
+
+-- name: ListPendingWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE status = 'pending'
+  AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg('limit');
+
+-- name: ListWebhookDeliveriesAfter :many
+SELECT webhook_deliveries.* FROM webhook_deliveries
+JOIN webhook_endpoints ON webhook_endpoints.id = webhook_deliveries.endpoint_id
+WHERE webhook_endpoints.username = sqlc.arg(username)
+  AND (sqlc.narg(status)::varchar IS NULL OR webhook_deliveries.status = sqlc.narg(status))
+  AND (webhook_deliveries.created_at, webhook_deliveries.id) > (sqlc.arg(after_created_at), sqlc.arg(after_id))
+ORDER BY webhook_deliveries.created_at, webhook_deliveries.id
+LIMIT sqlc.arg('limit');
+
+-- name: UpdateWebhookDeliveryAttempt :one
+UPDATE webhook_deliveries
+SET
+  status = sqlc.arg(status),
+  attempts = attempts + 1,
+  response_status = sqlc.narg(response_status),
+  last_error = sqlc.arg(last_error),
+  delivered_at = CASE WHEN sqlc.arg(status)::varchar = 'succeeded' THEN now() ELSE delivered_at END
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: FailWebhookDelivery :exec
+-- Marks a pending delivery failed, when its task gave up without recording its last attempt
+UPDATE webhook_deliveries
+SET status = 'failed'
+WHERE id = $1 AND status = 'pending';
+
+-- name: SupersedeWebhookDelivery :one
+-- Marks a failed delivery superseded by its redelivery, so it can't be redelivered again
+UPDATE webhook_deliveries
+SET status = 'superseded'
+WHERE id = $1 AND status = 'failed'
+RETURNING *;
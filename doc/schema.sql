@@ -10,7 +10,10 @@ CREATE TABLE "users" (
   "email" varchar UNIQUE NOT NULL,
   "is_email_verified" bool NOT NULL DEFAULT false,
   "password_changed_at" timestamptz NOT NULL DEFAULT '0001-01-01 00:00:00Z',
-  "created_at" timestamptz NOT NULL DEFAULT (now())
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "totp_secret" varchar NOT NULL DEFAULT '',
+  "is_mfa_enabled" bool NOT NULL DEFAULT false,
+  "totp_last_step" bigint NOT NULL DEFAULT 0
 );
 
 CREATE TABLE "verify_emails" (
@@ -23,11 +26,83 @@ CREATE TABLE "verify_emails" (
   "expired_at" timestamptz NOT NULL DEFAULT (now() + interval '15 minutes')
 );
 
+CREATE TABLE "password_resets" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "hashed_code" varchar NOT NULL,
+  "is_used" bool NOT NULL DEFAULT false,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "expired_at" timestamptz NOT NULL DEFAULT (now() + interval '15 minutes')
+);
+
+CREATE TABLE "password_reset_requests" (
+  "username" varchar PRIMARY KEY,
+  "requested_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "login_failures" (
+  "scope" varchar NOT NULL,
+  "key" varchar NOT NULL,
+  "failed_attempts" int NOT NULL DEFAULT 0,
+  "last_failed_at" timestamptz NOT NULL DEFAULT (now()),
+  "locked_until" timestamptz,
+  PRIMARY KEY ("scope", "key")
+);
+
+CREATE TABLE "account_unlocks" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "hashed_code" varchar NOT NULL,
+  "is_used" bool NOT NULL DEFAULT false,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "expired_at" timestamptz NOT NULL DEFAULT (now() + interval '1 hour')
+);
+
+CREATE TABLE "roles" (
+  "name" varchar PRIMARY KEY,
+  "description" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "role_permissions" (
+  "role" varchar NOT NULL,
+  "permission" varchar NOT NULL,
+  PRIMARY KEY ("role", "permission")
+);
+
+CREATE TABLE "user_roles" (
+  "username" varchar NOT NULL,
+  "role" varchar NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("username", "role")
+);
+
+CREATE TABLE "sessions" (
+  "id" uuid PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "refesh_token" varchar NOT NULL,
+  "user_agent" varchar NOT NULL,
+  "client_ip" varchar NOT NULL,
+  "is_blocked" boolean NOT NULL DEFAULT false,
+  "expires_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "family_id" uuid NOT NULL,
+  "rotated_at" timestamptz
+);
+
+CREATE TABLE "currencies" (
+  "code" varchar PRIMARY KEY,
+  "minor_units" int NOT NULL,
+  "enabled" bool NOT NULL DEFAULT false,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
 CREATE TABLE "accounts" (
   "id" bigserial PRIMARY KEY,
   "owner" varchar NOT NULL,
   "balance" bigint NOT NULL,
   "currency" varchar NOT NULL,
+  "overdraft_limit" bigint NOT NULL DEFAULT 0,
   "created_at" timestamptz NOT NULL DEFAULT (now())
 );
 
@@ -43,31 +118,284 @@ CREATE TABLE "transfers" (
   "from_account_id" bigint NOT NULL,
   "to_account_id" bigint NOT NULL,
   "amount" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "converted_amount" bigint NOT NULL,
+  "exchange_rate" double precision NOT NULL DEFAULT 1
+);
+
+CREATE TABLE "idempotency_keys" (
+  "username" varchar NOT NULL,
+  "key" varchar NOT NULL,
+  "request_hash" varchar NOT NULL,
+  "response" jsonb NOT NULL DEFAULT '{}',
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("username", "key")
+);
+
+CREATE TABLE "scheduled_transfers" (
+  "id" bigserial PRIMARY KEY,
+  "owner" varchar NOT NULL,
+  "from_account_id" bigint NOT NULL,
+  "to_account_id" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "interval_seconds" bigint NOT NULL DEFAULT 0,
+  "next_run_at" timestamptz NOT NULL,
+  "end_at" timestamptz,
+  "status" varchar NOT NULL DEFAULT 'active',
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "scheduled_transfer_runs" (
+  "id" bigserial PRIMARY KEY,
+  "scheduled_transfer_id" bigint NOT NULL,
+  "run_at" timestamptz NOT NULL,
+  "transfer_id" bigint,
+  "status" varchar NOT NULL,
+  "failure_reason" varchar NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "recovery_codes" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "hashed_code" varchar NOT NULL,
+  "used_at" timestamptz,
   "created_at" timestamptz NOT NULL DEFAULT (now())
 );
 
+CREATE TABLE "mfa_challenges" (
+  "id" uuid PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "failed_attempts" int NOT NULL DEFAULT 0,
+  "is_used" bool NOT NULL DEFAULT false,
+  "expires_at" timestamptz NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "events" (
+  "id" bigserial PRIMARY KEY,
+  "transaction_id" bigint NOT NULL DEFAULT (pg_current_xact_id()::text::bigint),
+  "type" varchar NOT NULL,
+  "username" varchar NOT NULL,
+  "account_id" bigint,
+  "payload" jsonb NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "webhook_endpoints" (
+  "id" bigserial PRIMARY KEY,
+  "username" varchar NOT NULL,
+  "url" varchar NOT NULL,
+  "secret" varchar NOT NULL,
+  "event_types" varchar[] NOT NULL DEFAULT '{}',
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "webhook_deliveries" (
+  "id" bigserial PRIMARY KEY,
+  "endpoint_id" bigint NOT NULL,
+  "event_id" bigint NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'pending',
+  "attempts" int NOT NULL DEFAULT 0,
+  "response_status" int,
+  "last_error" varchar NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "delivered_at" timestamptz
+);
+
+CREATE TABLE "notification_preferences" (
+  "username" varchar PRIMARY KEY,
+  "transfer_debit_emails" bool NOT NULL DEFAULT true,
+  "transfer_credit_emails" bool NOT NULL DEFAULT true,
+  "updated_at" timestamptz NOT NULL DEFAULT (now()),
+  "locale" varchar NOT NULL DEFAULT 'en'
+);
+
+CREATE TABLE "outbox" (
+  "id" bigserial PRIMARY KEY,
+  "task_type" varchar NOT NULL,
+  "payload" bytea NOT NULL,
+  "queue" varchar,
+  "max_retry" int,
+  "process_at" timestamptz,
+  "attempts" int NOT NULL DEFAULT 0,
+  "last_error" varchar,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  "sent_at" timestamptz,
+  "available_at" timestamptz NOT NULL DEFAULT (now()),
+  "dead_at" timestamptz
+);
+
+CREATE INDEX ON "sessions" ("family_id");
+
 CREATE INDEX ON "accounts" ("owner");
 
 CREATE UNIQUE INDEX ON "accounts" ("owner", "currency");
 
+CREATE INDEX ON "accounts" ("owner", "created_at", "id");
+
 CREATE INDEX ON "entries" ("account_id");
 
+CREATE INDEX ON "entries" ("account_id", "created_at", "id");
+
 CREATE INDEX ON "transfers" ("from_account_id");
 
 CREATE INDEX ON "transfers" ("to_account_id");
 
 CREATE INDEX ON "transfers" ("from_account_id", "to_account_id");
 
+CREATE INDEX ON "transfers" ("from_account_id", "created_at", "id");
+
+CREATE INDEX ON "transfers" ("to_account_id", "created_at", "id");
+
+CREATE INDEX ON "scheduled_transfers" ("owner");
+
+CREATE INDEX ON "scheduled_transfers" ("status", "next_run_at");
+
+CREATE INDEX ON "scheduled_transfers" ("owner", "created_at", "id");
+
+CREATE UNIQUE INDEX ON "scheduled_transfer_runs" ("scheduled_transfer_id", "run_at");
+
+CREATE INDEX ON "recovery_codes" ("username");
+
+CREATE INDEX ON "events" ("username", "transaction_id", "id");
+
+CREATE INDEX ON "events" ("account_id", "transaction_id", "id");
+
+CREATE INDEX ON "webhook_endpoints" ("username");
+
+CREATE INDEX ON "webhook_deliveries" ("endpoint_id", "id");
+
+CREATE INDEX ON "webhook_deliveries" ("status", "id");
+
+CREATE INDEX ON "webhook_deliveries" ("endpoint_id", "created_at", "id");
+
+CREATE INDEX ON "outbox" ("sent_at", "id");
+
+COMMENT ON COLUMN "users"."role" IS 'role granted at sign up, what a user may do is decided by user_roles';
+
+COMMENT ON COLUMN "users"."totp_secret" IS 'base32 TOTP secret, set at enrollment and only used once is_mfa_enabled is true';
+
+COMMENT ON COLUMN "users"."totp_last_step" IS 'time step of the last accepted TOTP code, codes of this step or earlier are rejected so they can''t be replayed';
+
+COMMENT ON COLUMN "password_resets"."hashed_code" IS 'SHA-256 hash of the secret code sent in the reset link';
+
+COMMENT ON COLUMN "password_reset_requests"."requested_at" IS 'when the last reset email was sent, another is only sent after a cooldown';
+
+COMMENT ON COLUMN "login_failures"."scope" IS 'username or client_ip';
+
+COMMENT ON COLUMN "login_failures"."key" IS 'not a foreign key, failures are also tracked for usernames that don''t exist';
+
+COMMENT ON COLUMN "account_unlocks"."hashed_code" IS 'SHA-256 hash of the secret code sent in the unlock link';
+
+COMMENT ON COLUMN "role_permissions"."permission" IS 'resource:action:scope, the scope is own or any';
+
+COMMENT ON COLUMN "sessions"."family_id" IS 'id of the session created at login, shared by all sessions rotated from it';
+
+COMMENT ON COLUMN "sessions"."rotated_at" IS 'set once the refresh token has been exchanged for a new one';
+
+COMMENT ON COLUMN "currencies"."code" IS 'ISO 4217 code';
+
+COMMENT ON COLUMN "currencies"."minor_units" IS 'number of decimal places, between 0 and 4';
+
+COMMENT ON COLUMN "accounts"."overdraft_limit" IS 'must be non-negative';
+
 COMMENT ON COLUMN "entries"."amount" IS 'can be negative or positive';
 
 COMMENT ON COLUMN "transfers"."amount" IS 'must be positive';
 
+COMMENT ON COLUMN "transfers"."converted_amount" IS 'amount credited to the destination account in its currency';
+
+COMMENT ON COLUMN "transfers"."exchange_rate" IS 'units of the destination currency per unit of the source currency';
+
+COMMENT ON COLUMN "scheduled_transfers"."amount" IS 'must be positive';
+
+COMMENT ON COLUMN "scheduled_transfers"."interval_seconds" IS '0 for a one-off transfer';
+
+COMMENT ON COLUMN "recovery_codes"."hashed_code" IS 'bcrypt hash, the code is only shown once when MFA is enabled';
+
+COMMENT ON COLUMN "mfa_challenges"."failed_attempts" IS 'the challenge is rejected after too many wrong codes';
+
+COMMENT ON COLUMN "events"."transaction_id" IS 'id of the transaction that recorded the event, events are streamed in transaction order';
+
+COMMENT ON COLUMN "events"."username" IS 'user the event is streamed to';
+
+COMMENT ON COLUMN "events"."account_id" IS 'account the event is about, not a foreign key so events outlive deleted accounts';
+
+COMMENT ON COLUMN "webhook_endpoints"."secret" IS 'key of the HMAC-SHA256 signature of the deliveries, kept in plain text to sign them';
+
+COMMENT ON COLUMN "webhook_endpoints"."event_types" IS 'types of the events delivered, all of them if empty';
+
+COMMENT ON COLUMN "webhook_deliveries"."status" IS 'pending, succeeded, failed once the retries are used up or superseded once redelivered';
+
+COMMENT ON COLUMN "webhook_deliveries"."response_status" IS 'HTTP status of the last attempt, null if no response was received';
+
+COMMENT ON COLUMN "notification_preferences"."transfer_debit_emails" IS 'email the user when money leaves one of their accounts, users without preferences get the default';
+
+COMMENT ON COLUMN "notification_preferences"."transfer_credit_emails" IS 'email the user when money arrives in one of their accounts';
+
+COMMENT ON COLUMN "notification_preferences"."locale" IS 'the locale of the emails sent to the user, like en or fr-CA';
+
+COMMENT ON COLUMN "outbox"."queue" IS 'the task queue, or the distributor default if null';
+
+COMMENT ON COLUMN "outbox"."max_retry" IS 'the max number of retries, or the distributor default if null';
+
+COMMENT ON COLUMN "outbox"."attempts" IS 'failed attempts to publish the task';
+
+COMMENT ON COLUMN "outbox"."sent_at" IS 'set once the task has been published to the task queue';
+
+COMMENT ON COLUMN "outbox"."available_at" IS 'the message is relayed from then on, failed messages back off';
+
+COMMENT ON COLUMN "outbox"."dead_at" IS 'set once the message failed too many times, it is no longer relayed';
+
 ALTER TABLE "verify_emails" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
 
+ALTER TABLE "password_resets" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "password_reset_requests" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "account_unlocks" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "role_permissions" ADD FOREIGN KEY ("role") REFERENCES "roles" ("name") ON DELETE CASCADE;
+
+ALTER TABLE "user_roles" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "user_roles" ADD FOREIGN KEY ("role") REFERENCES "roles" ("name") ON DELETE CASCADE;
+
+ALTER TABLE "sessions" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
 ALTER TABLE "accounts" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
 
+ALTER TABLE "accounts" ADD FOREIGN KEY ("currency") REFERENCES "currencies" ("code");
+
 ALTER TABLE "entries" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
 
 ALTER TABLE "transfers" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
 
 ALTER TABLE "transfers" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "idempotency_keys" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "scheduled_transfer_runs" ADD FOREIGN KEY ("scheduled_transfer_id") REFERENCES "scheduled_transfers" ("id");
+
+ALTER TABLE "scheduled_transfer_runs" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "recovery_codes" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "mfa_challenges" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "events" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "webhook_endpoints" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "webhook_deliveries" ADD FOREIGN KEY ("endpoint_id") REFERENCES "webhook_endpoints" ("id");
+
+ALTER TABLE "webhook_deliveries" ADD FOREIGN KEY ("event_id") REFERENCES "events" ("id");
+
+ALTER TABLE "notification_preferences" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
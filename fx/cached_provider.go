@@ -0,0 +1,63 @@
+package fx
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"time"
+)
+
+type currencyPair struct {
+	from string
+	to   string
+}
+
+type cachedRate struct {
+	rate      *big.Rat
+	expiredAt time.Time
+}
+
+// CachedRateProvider keeps the rates returned by another provider for a limited time
+type CachedRateProvider struct {
+	provider RateProvider
+	duration time.Duration
+	mutex    sync.Mutex
+	rates    map[currencyPair]cachedRate
+}
+
+// NewCachedRateProvider creates a new CachedRateProvider
+func NewCachedRateProvider(provider RateProvider, duration time.Duration) *CachedRateProvider {
+	return &CachedRateProvider{
+		provider: provider,
+		duration: duration,
+		rates:    make(map[currencyPair]cachedRate),
+	}
+}
+
+// Rate returns the cached rate if it hasn't expired, otherwise it asks the underlying provider.
+// Failed lookups are not cached.
+func (cache *CachedRateProvider) Rate(ctx context.Context, from string, to string) (*big.Rat, error) {
+	pair := currencyPair{from: from, to: to}
+
+	cache.mutex.Lock()
+	cached, ok := cache.rates[pair]
+	cache.mutex.Unlock()
+
+	if ok && time.Now().Before(cached.expiredAt) {
+		return cached.rate, nil
+	}
+
+	rate, err := cache.provider.Rate(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	cache.mutex.Lock()
+	cache.rates[pair] = cachedRate{
+		rate:      rate,
+		expiredAt: time.Now().Add(cache.duration),
+	}
+	cache.mutex.Unlock()
+
+	return rate, nil
+}
@@ -0,0 +1,67 @@
+package fx
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xmeizh/simplebank/util"
+)
+
+type countingProvider struct {
+	rates Rates
+	calls int
+}
+
+func (provider *countingProvider) Rate(ctx context.Context, from string, to string) (*big.Rat, error) {
+	provider.calls++
+	return provider.rates.Rate(ctx, from, to)
+}
+
+func TestCachedRateProvider(t *testing.T) {
+	provider := &countingProvider{
+		rates: Rates{
+			Base:  util.USD,
+			Rates: map[string]float64{util.EUR: 0.8},
+		},
+	}
+	cache := NewCachedRateProvider(provider, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		rate, err := cache.Rate(context.Background(), util.USD, util.EUR)
+		require.NoError(t, err)
+		require.Equal(t, "4/5", rate.String())
+	}
+	require.Equal(t, 1, provider.calls)
+
+	// the reverse pair is cached separately
+	rate, err := cache.Rate(context.Background(), util.EUR, util.USD)
+	require.NoError(t, err)
+	require.Equal(t, "5/4", rate.String())
+	require.Equal(t, 2, provider.calls)
+
+	// errors are not cached
+	for i := 0; i < 2; i++ {
+		_, err = cache.Rate(context.Background(), util.USD, util.CAD)
+		require.ErrorIs(t, err, ErrRateNotFound)
+	}
+	require.Equal(t, 4, provider.calls)
+}
+
+func TestCachedRateProviderExpired(t *testing.T) {
+	provider := &countingProvider{
+		rates: Rates{
+			Base:  util.USD,
+			Rates: map[string]float64{util.EUR: 0.8},
+		},
+	}
+	cache := NewCachedRateProvider(provider, -time.Minute)
+
+	for i := 0; i < 2; i++ {
+		_, err := cache.Rate(context.Background(), util.USD, util.EUR)
+		require.NoError(t, err)
+	}
+	require.Equal(t, 2, provider.calls)
+}
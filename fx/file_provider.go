@@ -0,0 +1,45 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+// FileRateProvider reads exchange rates from a JSON file.
+// The file is read on every lookup, so rates can be updated without a restart.
+type FileRateProvider struct {
+	path string
+}
+
+// NewFileRateProvider creates a new FileRateProvider
+func NewFileRateProvider(path string) *FileRateProvider {
+	return &FileRateProvider{
+		path: path,
+	}
+}
+
+// Rate returns the cross rate between two currencies listed in the file
+func (provider *FileRateProvider) Rate(ctx context.Context, from string, to string) (*big.Rat, error) {
+	rates, err := provider.load()
+	if err != nil {
+		return nil, err
+	}
+	return rates.Rate(ctx, from, to)
+}
+
+func (provider *FileRateProvider) load() (Rates, error) {
+	data, err := os.ReadFile(provider.path)
+	if err != nil {
+		return Rates{}, fmt.Errorf("cannot read rates file: %w", err)
+	}
+
+	var rates Rates
+	err = json.Unmarshal(data, &rates)
+	if err != nil {
+		return Rates{}, fmt.Errorf("cannot parse rates file: %w", err)
+	}
+	return rates, nil
+}
@@ -0,0 +1,48 @@
+package fx
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xmeizh/simplebank/util"
+)
+
+func TestFileRateProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	provider := NewFileRateProvider(path)
+
+	_, err := provider.Rate(context.Background(), util.USD, util.EUR)
+	require.Error(t, err)
+
+	err = os.WriteFile(path, []byte(`{"base": "USD", "rates": {"EUR": 0.5}}`), 0600)
+	require.NoError(t, err)
+
+	rate, err := provider.Rate(context.Background(), util.USD, util.EUR)
+	require.NoError(t, err)
+	require.Equal(t, "1/2", rate.String())
+
+	// updated rates are picked up without recreating the provider
+	err = os.WriteFile(path, []byte(`{"base": "USD", "rates": {"EUR": 0.25}}`), 0600)
+	require.NoError(t, err)
+
+	rate, err = provider.Rate(context.Background(), util.USD, util.EUR)
+	require.NoError(t, err)
+	require.Equal(t, "1/4", rate.String())
+
+	_, err = provider.Rate(context.Background(), util.USD, util.CAD)
+	require.ErrorIs(t, err, ErrRateNotFound)
+}
+
+func TestFileRateProviderInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	err := os.WriteFile(path, []byte("not json"), 0600)
+	require.NoError(t, err)
+
+	provider := NewFileRateProvider(path)
+	_, err = provider.Rate(context.Background(), util.USD, util.EUR)
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrRateNotFound)
+}
@@ -0,0 +1,107 @@
+package fx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+)
+
+var ErrRateNotFound = errors.New("exchange rate not found")
+
+// ErrAmountOverflow is returned when a converted amount doesn't fit in minor units
+var ErrAmountOverflow = errors.New("converted amount is too large")
+
+// RateProvider is an interface for looking up foreign exchange rates
+type RateProvider interface {
+	// Rate returns how many units of the target currency one unit of the source currency buys.
+	// The rate is exact and may be shared, so it must not be modified.
+	Rate(ctx context.Context, from string, to string) (*big.Rat, error)
+}
+
+// Rates is a fixed table of exchange rates quoted against a base currency
+type Rates struct {
+	Base  string             `json:"base"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+// Rate returns the cross rate between two currencies of the table
+func (rates Rates) Rate(ctx context.Context, from string, to string) (*big.Rat, error) {
+	if from == to {
+		return big.NewRat(1, 1), nil
+	}
+
+	fromRate, err := rates.baseRate(from)
+	if err != nil {
+		return nil, err
+	}
+
+	toRate, err := rates.baseRate(to)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Rat).Quo(toRate, fromRate), nil
+}
+
+// baseRate returns the rate of the currency as the decimal it's quoted with, e.g. 0.92 is exactly 92/100
+func (rates Rates) baseRate(currency string) (*big.Rat, error) {
+	if currency == rates.Base {
+		return big.NewRat(1, 1), nil
+	}
+
+	rate, ok := rates.Rates[currency]
+	if !ok || rate <= 0 {
+		return nil, fmt.Errorf("%w: %s per %s", ErrRateNotFound, currency, rates.Base)
+	}
+
+	exact, ok := new(big.Rat).SetString(strconv.FormatFloat(rate, 'g', -1, 64))
+	if !ok {
+		return nil, fmt.Errorf("%w: %s per %s", ErrRateNotFound, currency, rates.Base)
+	}
+	return exact, nil
+}
+
+// Convert applies the exchange rate to an amount in minor units of the source currency.
+// The result is in minor units of the target currency, rounded to the nearest unit with halves away from zero.
+// The conversion is exact, and fails with ErrAmountOverflow if the result doesn't fit in an int64.
+func Convert(amount int64, rate *big.Rat, fromMinorUnits int32, toMinorUnits int32) (int64, error) {
+	converted := new(big.Rat).Mul(new(big.Rat).SetInt64(amount), rate)
+
+	digits := toMinorUnits - fromMinorUnits
+	scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(digits))), nil))
+	if digits >= 0 {
+		converted.Mul(converted, scale)
+	} else {
+		converted.Quo(converted, scale)
+	}
+
+	rounded := roundHalfAwayFromZero(converted)
+	if !rounded.IsInt64() {
+		return 0, ErrAmountOverflow
+	}
+	return rounded.Int64(), nil
+}
+
+func roundHalfAwayFromZero(x *big.Rat) *big.Int {
+	numerator := new(big.Int).Abs(x.Num())
+	quotient, remainder := new(big.Int).QuoRem(numerator, x.Denom(), new(big.Int))
+
+	// the remainder is at least half of the denominator
+	if remainder.Lsh(remainder, 1).Cmp(x.Denom()) >= 0 {
+		quotient.Add(quotient, big.NewInt(1))
+	}
+
+	if x.Sign() < 0 {
+		quotient.Neg(quotient)
+	}
+	return quotient
+}
+
+func abs(n int32) int32 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
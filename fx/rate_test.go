@@ -0,0 +1,116 @@
+package fx
+
+import (
+	"context"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xmeizh/simplebank/util"
+)
+
+func TestRates(t *testing.T) {
+	rates := Rates{
+		Base: util.USD,
+		Rates: map[string]float64{
+			util.EUR: 0.8,
+			util.CAD: 1.6,
+		},
+	}
+
+	rate, err := rates.Rate(context.Background(), util.USD, util.EUR)
+	require.NoError(t, err)
+	require.Equal(t, "4/5", rate.String())
+
+	rate, err = rates.Rate(context.Background(), util.EUR, util.USD)
+	require.NoError(t, err)
+	require.Equal(t, "5/4", rate.String())
+
+	rate, err = rates.Rate(context.Background(), util.EUR, util.CAD)
+	require.NoError(t, err)
+	require.Equal(t, "2/1", rate.String())
+
+	rate, err = rates.Rate(context.Background(), util.CAD, util.CAD)
+	require.NoError(t, err)
+	require.Equal(t, "1/1", rate.String())
+}
+
+func TestRatesNotFound(t *testing.T) {
+	rates := Rates{
+		Base: util.USD,
+		Rates: map[string]float64{
+			util.EUR: 0.8,
+		},
+	}
+
+	_, err := rates.Rate(context.Background(), util.USD, util.CAD)
+	require.ErrorIs(t, err, ErrRateNotFound)
+
+	_, err = rates.Rate(context.Background(), util.CAD, util.EUR)
+	require.ErrorIs(t, err, ErrRateNotFound)
+}
+
+func TestConvert(t *testing.T) {
+	testCases := []struct {
+		name           string
+		amount         int64
+		rate           *big.Rat
+		fromMinorUnits int32
+		toMinorUnits   int32
+		converted      int64
+	}{
+		{name: "Rate", amount: 100, rate: big.NewRat(4, 5), fromMinorUnits: 2, toMinorUnits: 2, converted: 80},
+		{name: "HalfRoundsUp", amount: 1, rate: big.NewRat(1, 2), fromMinorUnits: 2, toMinorUnits: 2, converted: 1},
+		{name: "BelowHalfRoundsDown", amount: 1, rate: big.NewRat(2, 5), fromMinorUnits: 2, toMinorUnits: 2, converted: 0},
+		// 5 * 0.3 is 1.4999... in floating point
+		{name: "DecimalHalfRoundsUp", amount: 5, rate: big.NewRat(3, 10), fromMinorUnits: 2, toMinorUnits: 2, converted: 2},
+		{name: "NegativeHalfRoundsAwayFromZero", amount: -1, rate: big.NewRat(1, 2), fromMinorUnits: 2, toMinorUnits: 2, converted: -1},
+		// 1.00 USD to JPY, which has no decimals
+		{name: "FewerMinorUnits", amount: 100, rate: big.NewRat(157, 1), fromMinorUnits: 2, toMinorUnits: 0, converted: 157},
+		// 157 JPY to USD
+		{name: "MoreMinorUnits", amount: 157, rate: big.NewRat(1, 157), fromMinorUnits: 0, toMinorUnits: 2, converted: 100},
+		// 1.00 USD to KWD, which has 3 decimals
+		{name: "ThreeMinorUnits", amount: 100, rate: big.NewRat(307, 1000), fromMinorUnits: 2, toMinorUnits: 3, converted: 307},
+		// float64 can't represent the amounts above 2^53 exactly
+		{name: "AboveFloatPrecision", amount: 1<<53 + 1, rate: big.NewRat(1, 1), fromMinorUnits: 2, toMinorUnits: 2, converted: 1<<53 + 1},
+		{name: "MaxAmount", amount: math.MaxInt64, rate: big.NewRat(1, 1), fromMinorUnits: 2, toMinorUnits: 2, converted: math.MaxInt64},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			converted, err := Convert(tc.amount, tc.rate, tc.fromMinorUnits, tc.toMinorUnits)
+			require.NoError(t, err)
+			require.Equal(t, tc.converted, converted)
+		})
+	}
+}
+
+func TestConvertOverflow(t *testing.T) {
+	_, err := Convert(math.MaxInt64, big.NewRat(2, 1), 2, 2)
+	require.ErrorIs(t, err, ErrAmountOverflow)
+
+	_, err = Convert(math.MaxInt64, big.NewRat(1, 1), 0, 2)
+	require.ErrorIs(t, err, ErrAmountOverflow)
+
+	_, err = Convert(math.MinInt64, big.NewRat(2, 1), 2, 2)
+	require.ErrorIs(t, err, ErrAmountOverflow)
+}
+
+func TestRatesAreExactDecimals(t *testing.T) {
+	rates := Rates{
+		Base:  util.USD,
+		Rates: map[string]float64{util.EUR: 0.92, util.CAD: 1.37},
+	}
+
+	rate, err := rates.Rate(context.Background(), util.EUR, util.CAD)
+	require.NoError(t, err)
+	require.Equal(t, "137/92", rate.String())
+
+	// 10.00 EUR are 14.8913... CAD
+	converted, err := Convert(1000, rate, 2, 2)
+	require.NoError(t, err)
+	require.Equal(t, int64(1489), converted)
+}
@@ -2,11 +2,17 @@ package gapi
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
+	"github.com/xmeizh/simplebank/pb"
+	"github.com/xmeizh/simplebank/rbac"
 	"github.com/xmeizh/simplebank/token"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 const (
@@ -14,7 +20,136 @@ const (
 	authorizationTypeBearer = "bearer"
 )
 
-func (server *Server) authorizeUser(ctx context.Context, accessibleRoles []string) (*token.Payload, error) {
+var errPermissionDenied = errors.New("permission denied")
+
+// methodPermissions maps the methods to the action the caller must be granted, the other methods are denied.
+// Handlers still check whether the resource belongs to the user unless the action is granted on any resource.
+var methodPermissions = map[string]rbac.Action{
+	pb.SimpleBank_UpdateUser_FullMethodName:                    rbac.UpdateUsers,
+	pb.SimpleBank_UpdateNotificationPreferences_FullMethodName: rbac.UpdateUsers,
+	pb.SimpleBank_EnrollTOTP_FullMethodName:                    rbac.ManageMFA,
+	pb.SimpleBank_ConfirmTOTP_FullMethodName:                   rbac.ManageMFA,
+	pb.SimpleBank_ListSessions_FullMethodName:                  rbac.ReadSessions,
+	pb.SimpleBank_RevokeSession_FullMethodName:                 rbac.RevokeSessions,
+	pb.SimpleBank_RevokeAllSessions_FullMethodName:             rbac.RevokeSessions,
+	pb.SimpleBank_GrantRole_FullMethodName:                     rbac.ManageRoles,
+	pb.SimpleBank_RevokeRole_FullMethodName:                    rbac.ManageRoles,
+	pb.SimpleBank_CreateAccount_FullMethodName:                 rbac.CreateAccounts,
+	pb.SimpleBank_GetAccount_FullMethodName:                    rbac.ReadAccounts,
+	pb.SimpleBank_ListAccounts_FullMethodName:                  rbac.ReadAccounts,
+	pb.SimpleBank_DeleteAccount_FullMethodName:                 rbac.DeleteAccounts,
+	pb.SimpleBank_GetAccountStatement_FullMethodName:           rbac.ReadAccounts,
+	pb.SimpleBank_ExportAccountStatement_FullMethodName:        rbac.ReadAccounts,
+	pb.SimpleBank_CreateTransfer_FullMethodName:                rbac.CreateTransfers,
+	pb.SimpleBank_ListTransfers_FullMethodName:                 rbac.ReadTransfers,
+	pb.SimpleBank_ListEntries_FullMethodName:                   rbac.ReadAccounts,
+	pb.SimpleBank_SubscribeAccountEvents_FullMethodName:        rbac.ReadAccounts,
+	pb.SimpleBank_CreateScheduledTransfer_FullMethodName:       rbac.CreateScheduledTransfers,
+	pb.SimpleBank_ListScheduledTransfers_FullMethodName:        rbac.ReadScheduledTransfers,
+	pb.SimpleBank_CancelScheduledTransfer_FullMethodName:       rbac.CancelScheduledTransfers,
+	pb.SimpleBank_CreateWebhookEndpoint_FullMethodName:         rbac.ManageWebhooks,
+	pb.SimpleBank_ListWebhookDeliveries_FullMethodName:         rbac.ManageWebhooks,
+	pb.SimpleBank_RedeliverWebhook_FullMethodName:              rbac.ManageWebhooks,
+}
+
+// publicMethods can be called without an access token,
+// every other method is rejected by the auth interceptors unless the caller is authenticated
+var publicMethods = map[string]bool{
+	pb.SimpleBank_CreateUser_FullMethodName:           true,
+	pb.SimpleBank_LoginUser_FullMethodName:            true,
+	pb.SimpleBank_VerifyMFA_FullMethodName:            true,
+	pb.SimpleBank_RenewAccessToken_FullMethodName:     true,
+	pb.SimpleBank_Logout_FullMethodName:               true,
+	pb.SimpleBank_VerifyEmail_FullMethodName:          true,
+	pb.SimpleBank_RequestPasswordReset_FullMethodName: true,
+	pb.SimpleBank_ResetPassword_FullMethodName:        true,
+	pb.SimpleBank_UnlockAccount_FullMethodName:        true,
+}
+
+var errMissingAuthorization = errors.New("missing authorization")
+
+type authContextKey struct{}
+
+// authorization is the authenticated caller of a method
+type authorization struct {
+	payload     *token.Payload
+	permissions rbac.Permissions
+}
+
+func newContextWithAuthorization(ctx context.Context, payload *token.Payload, permissions rbac.Permissions) context.Context {
+	return context.WithValue(ctx, authContextKey{}, authorization{
+		payload:     payload,
+		permissions: permissions,
+	})
+}
+
+// authFromContext returns the caller the auth interceptors stored in the context.
+// It fails for public methods, which are called without authentication.
+func authFromContext(ctx context.Context) (*token.Payload, rbac.Permissions, error) {
+	auth, ok := ctx.Value(authContextKey{}).(authorization)
+	if !ok {
+		return nil, nil, errMissingAuthorization
+	}
+	return auth.payload, auth.permissions, nil
+}
+
+// UnaryAuthInterceptor authenticates the caller of every method but the public ones,
+// checks they are granted the action of the method and stores them in the context for authFromContext
+func (server *Server) UnaryAuthInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := server.authorizeMethod(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// StreamAuthInterceptor is UnaryAuthInterceptor for streaming methods
+func (server *Server) StreamAuthInterceptor(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := server.authorizeMethod(stream.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authorizedStream{ServerStream: stream, ctx: ctx})
+}
+
+// authorizedStream is a server stream whose context carries the authenticated caller
+type authorizedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (stream *authorizedStream) Context() context.Context {
+	return stream.ctx
+}
+
+func (server *Server) authorizeMethod(ctx context.Context, method string) (context.Context, error) {
+	if publicMethods[method] {
+		return ctx, nil
+	}
+
+	payload, err := server.authenticateUser(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	permissions, err := server.authorizer.Permissions(ctx, payload)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get permissions: %s", err)
+	}
+
+	// a method that is neither public nor mapped to an action is denied, so it can't be left unprotected by accident
+	action, ok := methodPermissions[method]
+	if !ok {
+		return nil, status.Errorf(codes.PermissionDenied, "%s to %s", errPermissionDenied, method)
+	}
+	if !permissions.Allows(action) {
+		return nil, status.Errorf(codes.PermissionDenied, "%s to %s", errPermissionDenied, action)
+	}
+
+	return newContextWithAuthorization(ctx, payload, permissions), nil
+}
+
+func (server *Server) authenticateUser(ctx context.Context) (*token.Payload, error) {
 	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
 		return nil, fmt.Errorf("missing metadata")
@@ -43,18 +178,10 @@ func (server *Server) authorizeUser(ctx context.Context, accessibleRoles []strin
 		return nil, fmt.Errorf("invalid access token: %s", err)
 	}
 
-	if !hasPermission(payload.Role, accessibleRoles) {
-		return nil, fmt.Errorf("permission denied")
+	err = server.revocationChecker.CheckToken(ctx, payload.SessionID, payload.IssueAt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid access token: %s", err)
 	}
 
 	return payload, nil
 }
-
-func hasPermission(userRole string, accessibleRoles []string) bool {
-	for _, role := range accessibleRoles {
-		if userRole == role {
-			return true
-		}
-	}
-	return false
-}
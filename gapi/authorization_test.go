@@ -0,0 +1,211 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/xmeizh/simplebank/pb"
+	"github.com/xmeizh/simplebank/rbac"
+	"github.com/xmeizh/simplebank/revocation"
+	"github.com/xmeizh/simplebank/util"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// newContextWithAccessToken returns the context of a call with the access token, as received by the auth interceptors
+func newContextWithAccessToken(t *testing.T, server *Server, username string, role string, duration time.Duration) context.Context {
+	accessToken, _, err := server.tokenMaker.CreateToken(username, role, uuid.New(), duration)
+	require.NoError(t, err)
+	md := metadata.Pairs(authorizationHeader, authorizationTypeBearer+" "+accessToken)
+	return metadata.NewIncomingContext(context.Background(), md)
+}
+
+func TestAuthenticateUserRevokedToken(t *testing.T) {
+	user, _ := randomUser(t)
+
+	for _, checkErr := range []error{nil, revocation.ErrTokenRevoked, sql.ErrConnDone} {
+		server := newTestServer(t, nil, nil)
+		server.revocationChecker = tokenChecker{err: checkErr}
+
+		ctx := newContextWithAccessToken(t, server, user.Username, user.Role, time.Minute)
+		payload, err := server.authenticateUser(ctx)
+		if checkErr == nil {
+			require.NoError(t, err)
+			require.Equal(t, user.Username, payload.Username)
+			continue
+		}
+		require.ErrorContains(t, err, checkErr.Error())
+		require.Nil(t, payload)
+	}
+}
+
+func TestMethodPermissions(t *testing.T) {
+	var methods []string
+	for _, method := range pb.SimpleBank_ServiceDesc.Methods {
+		methods = append(methods, "/"+pb.SimpleBank_ServiceDesc.ServiceName+"/"+method.MethodName)
+	}
+	for _, stream := range pb.SimpleBank_ServiceDesc.Streams {
+		methods = append(methods, "/"+pb.SimpleBank_ServiceDesc.ServiceName+"/"+stream.StreamName)
+	}
+
+	// every method is either public or needs an action, so new methods can't be left unprotected by accident
+	for _, method := range methods {
+		_, needsAction := methodPermissions[method]
+		require.NotEqual(t, publicMethods[method], needsAction, method)
+	}
+	require.Len(t, methods, len(publicMethods)+len(methodPermissions))
+}
+
+func TestUnaryAuthInterceptor(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name         string
+		method       string
+		buildContext func(t *testing.T, server *Server) context.Context
+		setupServer  func(server *Server)
+		checkResult  func(t *testing.T, handlerCtx context.Context, err error)
+	}{
+		{
+			name:   "OK",
+			method: pb.SimpleBank_GetAccount_FullMethodName,
+			buildContext: func(t *testing.T, server *Server) context.Context {
+				return newContextWithAccessToken(t, server, user.Username, util.DepositorRole, time.Minute)
+			},
+			checkResult: func(t *testing.T, handlerCtx context.Context, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, handlerCtx)
+
+				payload, permissions, err := authFromContext(handlerCtx)
+				require.NoError(t, err)
+				require.Equal(t, user.Username, payload.Username)
+				require.True(t, permissions.Allows(rbac.ReadAccounts))
+			},
+		},
+		{
+			name:   "PublicMethod",
+			method: pb.SimpleBank_LoginUser_FullMethodName,
+			buildContext: func(t *testing.T, server *Server) context.Context {
+				return context.Background()
+			},
+			checkResult: func(t *testing.T, handlerCtx context.Context, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, handlerCtx)
+
+				_, _, err = authFromContext(handlerCtx)
+				require.ErrorIs(t, err, errMissingAuthorization)
+			},
+		},
+		{
+			name:   "NoAuthorization",
+			method: pb.SimpleBank_GetAccount_FullMethodName,
+			buildContext: func(t *testing.T, server *Server) context.Context {
+				return context.Background()
+			},
+			checkResult: func(t *testing.T, handlerCtx context.Context, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+				require.Nil(t, handlerCtx)
+			},
+		},
+		{
+			name:   "ExpiredToken",
+			method: pb.SimpleBank_GetAccount_FullMethodName,
+			buildContext: func(t *testing.T, server *Server) context.Context {
+				return newContextWithAccessToken(t, server, user.Username, util.DepositorRole, -time.Minute)
+			},
+			checkResult: func(t *testing.T, handlerCtx context.Context, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+				require.Nil(t, handlerCtx)
+			},
+		},
+		{
+			name:   "PermissionDenied",
+			method: pb.SimpleBank_GrantRole_FullMethodName,
+			buildContext: func(t *testing.T, server *Server) context.Context {
+				return newContextWithAccessToken(t, server, user.Username, util.BankerRole, time.Minute)
+			},
+			checkResult: func(t *testing.T, handlerCtx context.Context, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+				require.Nil(t, handlerCtx)
+			},
+		},
+		{
+			name:   "UnmappedMethod",
+			method: "/" + pb.SimpleBank_ServiceDesc.ServiceName + "/UnknownMethod",
+			buildContext: func(t *testing.T, server *Server) context.Context {
+				return newContextWithAccessToken(t, server, user.Username, util.BankerRole, time.Minute)
+			},
+			checkResult: func(t *testing.T, handlerCtx context.Context, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+				require.Nil(t, handlerCtx)
+			},
+		},
+		{
+			name:   "AuthorizerError",
+			method: pb.SimpleBank_GetAccount_FullMethodName,
+			buildContext: func(t *testing.T, server *Server) context.Context {
+				return newContextWithAccessToken(t, server, user.Username, util.DepositorRole, time.Minute)
+			},
+			setupServer: func(server *Server) {
+				server.authorizer = roleAuthorizer{err: sql.ErrConnDone}
+			},
+			checkResult: func(t *testing.T, handlerCtx context.Context, err error) {
+				require.Equal(t, codes.Internal, status.Code(err))
+				require.Nil(t, handlerCtx)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t, nil, nil)
+			if tc.setupServer != nil {
+				tc.setupServer(server)
+			}
+
+			var handlerCtx context.Context
+			handler := func(ctx context.Context, req any) (any, error) {
+				handlerCtx = ctx
+				return nil, nil
+			}
+
+			info := &grpc.UnaryServerInfo{FullMethod: tc.method}
+			_, err := server.UnaryAuthInterceptor(tc.buildContext(t, server), nil, info, handler)
+			tc.checkResult(t, handlerCtx, err)
+		})
+	}
+}
+
+func TestStreamAuthInterceptor(t *testing.T) {
+	user, _ := randomUser(t)
+	server := newTestServer(t, nil, nil)
+	info := &grpc.StreamServerInfo{FullMethod: pb.SimpleBank_ListTransfers_FullMethodName, IsServerStream: true}
+
+	var handlerStream grpc.ServerStream
+	handler := func(srv any, stream grpc.ServerStream) error {
+		handlerStream = stream
+		return nil
+	}
+
+	stream := &testServerStream[pb.ListTransfersResponse]{ctx: newContextWithAccessToken(t, server, user.Username, util.DepositorRole, time.Minute)}
+	err := server.StreamAuthInterceptor(nil, stream, info, handler)
+	require.NoError(t, err)
+
+	payload, _, err := authFromContext(handlerStream.Context())
+	require.NoError(t, err)
+	require.Equal(t, user.Username, payload.Username)
+
+	handlerStream = nil
+	stream = &testServerStream[pb.ListTransfersResponse]{ctx: context.Background()}
+	err = server.StreamAuthInterceptor(nil, stream, info, handler)
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+	require.Nil(t, handlerStream)
+}
@@ -1,8 +1,12 @@
 package gapi
 
 import (
+	"encoding/json"
+	"fmt"
+
 	db "github.com/xmeizh/simplebank/db/postgresql"
 	"github.com/xmeizh/simplebank/pb"
+	"github.com/xmeizh/simplebank/util"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -15,3 +19,168 @@ func convertUser(user db.User) *pb.User {
 		CreatedAt:         timestamppb.New(user.CreatedAt),
 	}
 }
+
+func convertAccount(account db.Account) *pb.Account {
+	return &pb.Account{
+		Id:               account.ID,
+		Owner:            account.Owner,
+		Balance:          account.Balance,
+		Currency:         account.Currency,
+		CreatedAt:        timestamppb.New(account.CreatedAt),
+		OverdraftLimit:   account.OverdraftLimit,
+		FormattedBalance: util.FormatAmount(account.Balance, account.Currency),
+	}
+}
+
+func convertTransfer(transfer db.Transfer) *pb.Transfer {
+	return &pb.Transfer{
+		Id:              transfer.ID,
+		FromAccountId:   transfer.FromAccountID,
+		ToAccountId:     transfer.ToAccountID,
+		Amount:          transfer.Amount,
+		CreatedAt:       timestamppb.New(transfer.CreatedAt),
+		ConvertedAmount: transfer.ConvertedAmount,
+		ExchangeRate:    transfer.ExchangeRate,
+	}
+}
+
+func convertEntry(entry db.Entry) *pb.Entry {
+	return &pb.Entry{
+		Id:        entry.ID,
+		AccountId: entry.AccountID,
+		Amount:    entry.Amount,
+		CreatedAt: timestamppb.New(entry.CreatedAt),
+	}
+}
+
+func convertScheduledTransfer(scheduledTransfer db.ScheduledTransfer) *pb.ScheduledTransfer {
+	rsp := &pb.ScheduledTransfer{
+		Id:              scheduledTransfer.ID,
+		Owner:           scheduledTransfer.Owner,
+		FromAccountId:   scheduledTransfer.FromAccountID,
+		ToAccountId:     scheduledTransfer.ToAccountID,
+		Amount:          scheduledTransfer.Amount,
+		IntervalSeconds: scheduledTransfer.IntervalSeconds,
+		NextRunAt:       timestamppb.New(scheduledTransfer.NextRunAt),
+		Status:          scheduledTransfer.Status,
+		CreatedAt:       timestamppb.New(scheduledTransfer.CreatedAt),
+	}
+	if scheduledTransfer.EndAt.Valid {
+		rsp.EndAt = timestamppb.New(scheduledTransfer.EndAt.Time)
+	}
+	return rsp
+}
+
+func convertStatementEntry(entry db.ListAccountStatementEntriesRow) *pb.StatementEntry {
+	return &pb.StatementEntry{
+		Id:             entry.ID,
+		Amount:         entry.Amount,
+		RunningBalance: entry.RunningBalance,
+		CreatedAt:      timestamppb.New(entry.CreatedAt),
+	}
+}
+
+func convertSession(session db.Session) *pb.Session {
+	return &pb.Session{
+		Id:        session.ID.String(),
+		Username:  session.Username,
+		UserAgent: session.UserAgent,
+		ClientIp:  session.ClientIp,
+		CreatedAt: timestamppb.New(session.CreatedAt),
+		ExpiresAt: timestamppb.New(session.ExpiresAt),
+	}
+}
+
+func convertAccountEvent(event db.Event) (*pb.AccountEvent, error) {
+	rsp := &pb.AccountEvent{
+		Offset: util.EncodeEventOffset(util.EventOffset{
+			TransactionID: event.TransactionID,
+			ID:            event.ID,
+		}),
+		Type:      event.Type,
+		AccountId: event.AccountID.Int64,
+		CreatedAt: timestamppb.New(event.CreatedAt),
+	}
+
+	var err error
+	switch event.Type {
+	case db.EventUserCreated:
+		var payload db.UserCreatedEvent
+		err = json.Unmarshal(event.Payload, &payload)
+		rsp.Payload = &pb.AccountEvent_UserCreated{
+			UserCreated: &pb.UserCreatedEvent{
+				Username: payload.Username,
+				FullName: payload.FullName,
+				Email:    payload.Email,
+			},
+		}
+	case db.EventEmailVerified:
+		var payload db.EmailVerifiedEvent
+		err = json.Unmarshal(event.Payload, &payload)
+		rsp.Payload = &pb.AccountEvent_EmailVerified{
+			EmailVerified: &pb.EmailVerifiedEvent{
+				Username: payload.Username,
+				Email:    payload.Email,
+			},
+		}
+	case db.EventAccountOpened:
+		var payload db.AccountOpenedEvent
+		err = json.Unmarshal(event.Payload, &payload)
+		rsp.Payload = &pb.AccountEvent_AccountOpened{
+			AccountOpened: &pb.AccountOpenedEvent{
+				Account: convertAccount(payload.Account),
+			},
+		}
+	case db.EventTransferCompleted:
+		var payload db.TransferCompletedEvent
+		err = json.Unmarshal(event.Payload, &payload)
+		rsp.Payload = &pb.AccountEvent_TransferCompleted{
+			TransferCompleted: &pb.TransferCompletedEvent{
+				Transfer: convertTransfer(payload.Transfer),
+			},
+		}
+	default:
+		return nil, fmt.Errorf("unknown event type %q", event.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot unmarshal %s event [%d]: %w", event.Type, event.ID, err)
+	}
+	return rsp, nil
+}
+
+func convertWebhookEndpoint(endpoint db.WebhookEndpoint) *pb.WebhookEndpoint {
+	return &pb.WebhookEndpoint{
+		Id:         endpoint.ID,
+		Username:   endpoint.Username,
+		Url:        endpoint.Url,
+		EventTypes: endpoint.EventTypes,
+		CreatedAt:  timestamppb.New(endpoint.CreatedAt),
+	}
+}
+
+func convertWebhookDelivery(delivery db.WebhookDelivery) *pb.WebhookDelivery {
+	rsp := &pb.WebhookDelivery{
+		Id:             delivery.ID,
+		EndpointId:     delivery.EndpointID,
+		EventId:        delivery.EventID,
+		Status:         delivery.Status,
+		Attempts:       delivery.Attempts,
+		ResponseStatus: delivery.ResponseStatus.Int32,
+		LastError:      delivery.LastError,
+		CreatedAt:      timestamppb.New(delivery.CreatedAt),
+	}
+	if delivery.DeliveredAt.Valid {
+		rsp.DeliveredAt = timestamppb.New(delivery.DeliveredAt.Time)
+	}
+	return rsp
+}
+
+func convertNotificationPreferences(preferences db.NotificationPreference) *pb.NotificationPreferences {
+	return &pb.NotificationPreferences{
+		Username:             preferences.Username,
+		TransferDebitEmails:  preferences.TransferDebitEmails,
+		TransferCreditEmails: preferences.TransferCreditEmails,
+		UpdatedAt:            timestamppb.New(preferences.UpdatedAt),
+		Locale:               preferences.Locale,
+	}
+}
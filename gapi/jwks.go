@@ -0,0 +1,39 @@
+package gapi
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/xmeizh/simplebank/token"
+)
+
+// JWKSPath is where the gateway publishes the keys verifying access tokens
+const JWKSPath = "/.well-known/jwks.json"
+
+// JWKSHandler serves the public keys of the token maker, so other services can verify access tokens
+// without holding the signing key. The key set is empty when tokens are signed with a symmetric key.
+func (server *Server) JWKSHandler() http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			res.Header().Set("Allow", http.MethodGet)
+			http.Error(res, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		var keys []token.PublicKey
+		if maker, ok := server.tokenMaker.(token.PublicKeyMaker); ok {
+			keys = maker.PublicKeys()
+		}
+
+		keySet, err := token.NewJSONWebKeySet(keys)
+		if err != nil {
+			http.Error(res, "failed to encode public keys", http.StatusInternalServerError)
+			return
+		}
+
+		res.Header().Set("Content-Type", "application/json")
+		// verifiers may cache the keys for a while, rotated keys stay published until their tokens expire
+		res.Header().Set("Cache-Control", "public, max-age=300")
+		json.NewEncoder(res).Encode(keySet)
+	})
+}
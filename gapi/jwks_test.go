@@ -0,0 +1,75 @@
+package gapi
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xmeizh/simplebank/token"
+)
+
+func TestJWKSHandler(t *testing.T) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	publicMaker, err := token.NewJWTPublicMaker("key1", privateKey, nil, "", "")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		method        string
+		publicKeys    bool
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:       "OK",
+			method:     http.MethodGet,
+			publicKeys: true,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var keySet token.JSONWebKeySet
+				err := json.Unmarshal(recorder.Body.Bytes(), &keySet)
+				require.NoError(t, err)
+				require.Len(t, keySet.Keys, 1)
+				require.Equal(t, "key1", keySet.Keys[0].KeyID)
+				require.Equal(t, "EdDSA", keySet.Keys[0].Algorithm)
+			},
+		},
+		{
+			name:   "SymmetricKey",
+			method: http.MethodGet,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, `{"keys":[]}`, recorder.Body.String())
+			},
+		},
+		{
+			name:       "MethodNotAllowed",
+			method:     http.MethodPost,
+			publicKeys: true,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusMethodNotAllowed, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			server := newTestServer(t, nil, nil)
+			if tc.publicKeys {
+				server.tokenMaker = publicMaker
+			}
+
+			recorder := httptest.NewRecorder()
+			request := httptest.NewRequest(tc.method, JWKSPath, nil)
+			server.JWKSHandler().ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
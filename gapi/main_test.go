@@ -6,34 +6,132 @@ import (
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/fx"
+	"github.com/xmeizh/simplebank/rbac"
 	"github.com/xmeizh/simplebank/token"
 	"github.com/xmeizh/simplebank/util"
 	"github.com/xmeizh/simplebank/worker"
 	"google.golang.org/grpc/metadata"
 )
 
+// testRates has no CAD rate, so CAD accounts can't receive cross-currency transfers
+var testRates = fx.Rates{
+	Base: util.USD,
+	Rates: map[string]float64{
+		util.EUR: 0.5,
+	},
+}
+
+// tokenChecker returns err for every token, the revocation package tests the real checks
+type tokenChecker struct {
+	err error
+}
+
+func (checker tokenChecker) CheckToken(ctx context.Context, sessionID uuid.UUID, issuedAt time.Time) error {
+	return checker.err
+}
+
+// loginGuard returns checkErr for every login and recordErr for every failure, the lockout package tests the real guard
+type loginGuard struct {
+	checkErr  error
+	recordErr error
+}
+
+func (guard loginGuard) Check(ctx context.Context, username string, clientIP string) error {
+	return guard.checkErr
+}
+
+func (guard loginGuard) RecordFailure(ctx context.Context, username string, clientIP string) error {
+	return guard.recordErr
+}
+
+func (guard loginGuard) RecordSuccess(ctx context.Context, username string) error {
+	return nil
+}
+
+// rolePermissions are the permissions the migrations grant to each role
+var rolePermissions = map[string]rbac.Permissions{
+	util.DepositorRole: rbac.NewPermissions(
+		"users:update:own",
+		"mfa:manage:own",
+		"sessions:read:own",
+		"sessions:revoke:own",
+		"accounts:create:own",
+		"accounts:read:own",
+		"accounts:delete:own",
+		"transfers:create:own",
+		"transfers:read:own",
+		"scheduled_transfers:create:own",
+		"scheduled_transfers:read:own",
+		"scheduled_transfers:cancel:own",
+		"webhooks:manage:own",
+	),
+	util.BankerRole: rbac.NewPermissions(
+		"users:update:any",
+		"mfa:manage:own",
+		"sessions:read:any",
+		"sessions:revoke:any",
+		"accounts:create:own",
+		"accounts:read:any",
+		"accounts:delete:any",
+		"transfers:create:own",
+		"transfers:read:any",
+		"scheduled_transfers:create:own",
+		"scheduled_transfers:read:own",
+		"scheduled_transfers:cancel:any",
+		"webhooks:manage:own",
+	),
+	util.AdminRole: rbac.NewPermissions(
+		"roles:manage:any",
+	),
+}
+
+// roleAuthorizer grants the permissions of the role in the token unless err is set, the rbac package tests the real lookup
+type roleAuthorizer struct {
+	err error
+}
+
+func (authorizer roleAuthorizer) Permissions(ctx context.Context, payload *token.Payload) (rbac.Permissions, error) {
+	if authorizer.err != nil {
+		return nil, authorizer.err
+	}
+	return rolePermissions[payload.Role], nil
+}
+
 func newTestServer(t *testing.T, store db.Store, taskDistributor worker.TaskDistributor) *Server {
 	config := util.Config{
-		TokenSymmetricKey:   util.RandomString(32),
-		AccessTokenDuration: time.Minute,
+		AccessTokenDuration:   time.Minute,
+		MFAChallengeDuration:  time.Minute,
+		PasswordResetCooldown: time.Minute,
+		TOTPIssuer:            "SimpleBank",
+		EventPollInterval:     10 * time.Millisecond,
 	}
 
-	server, err := NewServer(config, store, taskDistributor)
+	tokenMaker, err := token.NewPasetoMaker(util.RandomString(32))
 	require.NoError(t, err)
 
-	return server
+	return NewServer(config, store, tokenMaker, taskDistributor, testRates, tokenChecker{}, loginGuard{}, roleAuthorizer{})
 }
 
+// newContextWithBearerToken returns the context the auth interceptors pass to the handler when called with the token.
+// The caller is only stored if the token is valid, and is granted the permissions of their role.
 func newContextWithBearerToken(t *testing.T, tokenMaker token.Maker, username string, role string, duration time.Duration) context.Context {
-	token, _, err := tokenMaker.CreateToken(username, role, duration)
+	accessToken, _, err := tokenMaker.CreateToken(username, role, uuid.New(), duration)
 	require.NoError(t, err)
-	bearerToken := fmt.Sprintf("%s %s", authorizationTypeBearer, token)
+	bearerToken := fmt.Sprintf("%s %s", authorizationTypeBearer, accessToken)
 	md := metadata.MD{
 		authorizationHeader: []string{
 			bearerToken,
 		},
 	}
-	return metadata.NewIncomingContext(context.Background(), md)
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+
+	payload, err := tokenMaker.VerifyToken(accessToken)
+	if err != nil {
+		return ctx
+	}
+	return newContextWithAuthorization(ctx, payload, rolePermissions[role])
 }
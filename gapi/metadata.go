@@ -2,6 +2,8 @@ package gapi
 
 import (
 	"context"
+	"net"
+	"strings"
 
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
@@ -23,23 +25,40 @@ type Metadata struct {
 func (server *Server) extractMetadata(ctx context.Context) *Metadata {
 	mtdt := &Metadata{}
 
+	var forwardedFor string
 	if md, ok := metadata.FromIncomingContext(ctx); ok {
-		if userAgents := md.Get(grpcGatewayUserAgentHeader); len(userAgents) > 0 {
+		if userAgents := md.Get(userAgentHeader); len(userAgents) > 0 {
 			mtdt.UserAgent = userAgents[0]
 		}
-		if userAgents := md.Get(userAgentHeader); len(userAgents) > 0 {
+		// the gateway calls with its own user agent and passes on the one of the HTTP client
+		if userAgents := md.Get(grpcGatewayUserAgentHeader); len(userAgents) > 0 {
 			mtdt.UserAgent = userAgents[0]
 		}
 
 		if clientIPs := md.Get(xForwardedForHeader); len(clientIPs) > 0 {
-			mtdt.ClientIP = clientIPs[0]
+			forwardedFor = clientIPs[len(clientIPs)-1]
 		}
-
 	}
 
 	if p, ok := peer.FromContext(ctx); ok {
 		mtdt.ClientIP = p.Addr.String()
 	}
 
+	// The gateway runs on the same host and appends the address of the HTTP client to x-forwarded-for,
+	// the entries before it come from the client and can't be trusted
+	if forwardedFor != "" && (mtdt.ClientIP == "" || isLoopback(mtdt.ClientIP)) {
+		clientIPs := strings.Split(forwardedFor, ",")
+		mtdt.ClientIP = strings.TrimSpace(clientIPs[len(clientIPs)-1])
+	}
+
 	return mtdt
 }
+
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,63 @@
+package gapi
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+func TestExtractMetadata(t *testing.T) {
+	gatewayPeer := &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 51234}}
+	clientPeer := &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("203.0.113.7"), Port: 51234}}
+
+	testCases := []struct {
+		name      string
+		md        metadata.MD
+		peer      *peer.Peer
+		userAgent string
+		clientIP  string
+	}{
+		{
+			name:      "GRPCClient",
+			md:        metadata.Pairs(userAgentHeader, "grpc-go/1.62.1"),
+			peer:      clientPeer,
+			userAgent: "grpc-go/1.62.1",
+			clientIP:  "203.0.113.7:51234",
+		},
+		{
+			name: "Gateway",
+			md: metadata.Pairs(
+				userAgentHeader, "grpc-go/1.62.1",
+				grpcGatewayUserAgentHeader, "curl/8.5.0",
+				xForwardedForHeader, "10.0.0.1, 198.51.100.4",
+			),
+			peer:      gatewayPeer,
+			userAgent: "curl/8.5.0",
+			clientIP:  "198.51.100.4",
+		},
+		{
+			name:     "ForwardedForFromClient",
+			md:       metadata.Pairs(xForwardedForHeader, "198.51.100.4"),
+			peer:     clientPeer,
+			clientIP: "203.0.113.7:51234",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), tc.md)
+			ctx = peer.NewContext(ctx, tc.peer)
+
+			server := newTestServer(t, nil, nil)
+			mtdt := server.extractMetadata(ctx)
+			require.Equal(t, tc.userAgent, mtdt.UserAgent)
+			require.Equal(t, tc.clientIP, mtdt.ClientIP)
+		})
+	}
+}
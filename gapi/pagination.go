@@ -0,0 +1,45 @@
+package gapi
+
+import (
+	"github.com/xmeizh/simplebank/util"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+)
+
+// List RPCs paginate with page tokens following AIP-158
+const (
+	// defaultPageSize is used when a list request doesn't set a page size
+	defaultPageSize = 10
+	// maxPageSize is the largest page returned, larger page sizes are coerced to it
+	maxPageSize = 100
+)
+
+// pageSize returns the number of rows to return for the requested page size
+func pageSize(requested int32) int32 {
+	switch {
+	case requested == 0:
+		return defaultPageSize
+	case requested > maxPageSize:
+		return maxPageSize
+	}
+	return requested
+}
+
+// decodePageToken returns the cursor the requested page starts after
+func decodePageToken(token string, scope string) (util.PageCursor, error) {
+	after, err := util.DecodePageToken(token, scope)
+	if err != nil {
+		return util.PageCursor{}, invalidArgumentError([]*errdetails.BadRequest_FieldViolation{fieldViolation("page_token", err)})
+	}
+	return after, nil
+}
+
+// nextPage trims rows, which are loaded with one row more than the page size,
+// to the page and returns the token of the next page, or an empty token on the last page
+func nextPage[T any](rows []T, size int32, scope string, cursor func(T) util.PageCursor) ([]T, string) {
+	if int32(len(rows)) <= size {
+		return rows, ""
+	}
+
+	rows = rows[:size]
+	return rows, util.EncodePageToken(scope, cursor(rows[len(rows)-1]))
+}
@@ -0,0 +1,66 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+
+	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/pb"
+	"github.com/xmeizh/simplebank/rbac"
+	"github.com/xmeizh/simplebank/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) CancelScheduledTransfer(ctx context.Context, req *pb.CancelScheduledTransferRequest) (*pb.CancelScheduledTransferResponse, error) {
+	authPayload, permissions, err := authFromContext(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateCancelScheduledTransferRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	scheduledTransfer, err := server.store.GetScheduledTransfer(ctx, req.GetId())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Errorf(codes.NotFound, "scheduled transfer not found: %s", err)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get scheduled transfer: %s", err)
+	}
+
+	if !permissions.AllowsAny(rbac.CancelScheduledTransfers) && scheduledTransfer.Owner != authPayload.Username {
+		return nil, status.Errorf(codes.PermissionDenied, "scheduled transfer doesn't belong to the authenticated user")
+	}
+
+	if scheduledTransfer.Status != db.ScheduledTransferActive {
+		return nil, status.Errorf(codes.FailedPrecondition, "scheduled transfer is already %s", scheduledTransfer.Status)
+	}
+
+	scheduledTransfer, err = server.store.UpdateScheduledTransferStatus(ctx, db.UpdateScheduledTransferStatusParams{
+		ID:     scheduledTransfer.ID,
+		Status: db.ScheduledTransferCancelled,
+	})
+	if err != nil {
+		// it completed or was cancelled since it was read
+		if err == sql.ErrNoRows {
+			return nil, status.Errorf(codes.FailedPrecondition, "scheduled transfer is no longer active")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to cancel scheduled transfer: %s", err)
+	}
+
+	rsp := &pb.CancelScheduledTransferResponse{
+		ScheduledTransfer: convertScheduledTransfer(scheduledTransfer),
+	}
+	return rsp, nil
+}
+
+func validateCancelScheduledTransferRequest(req *pb.CancelScheduledTransferRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateScheduledTransferID(req.GetId()); err != nil {
+		violations = append(violations, fieldViolation("id", err))
+	}
+	return violations
+}
@@ -0,0 +1,101 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/lockout"
+	"github.com/xmeizh/simplebank/mfa"
+	"github.com/xmeizh/simplebank/pb"
+	"github.com/xmeizh/simplebank/util"
+	"github.com/xmeizh/simplebank/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) ConfirmTOTP(ctx context.Context, req *pb.ConfirmTOTPRequest) (*pb.ConfirmTOTPResponse, error) {
+	authPayload, _, err := authFromContext(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateConfirmTOTPRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	clientIP := server.extractMetadata(ctx).ClientIP
+	err = server.loginGuard.Check(ctx, authPayload.Username, clientIP)
+	if err != nil {
+		if errors.Is(err, lockout.ErrLoginThrottled) {
+			return nil, status.Errorf(codes.ResourceExhausted, "%s", err)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to check login attempts: %s", err)
+	}
+
+	user, err := server.store.GetUser(ctx, authPayload.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Errorf(codes.NotFound, "user not found: %s", err)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get user: %s", err)
+	}
+
+	if user.IsMfaEnabled {
+		return nil, status.Errorf(codes.FailedPrecondition, "MFA is already enabled")
+	}
+
+	if user.TotpSecret == "" {
+		return nil, status.Errorf(codes.FailedPrecondition, "TOTP is not enrolled")
+	}
+
+	ok, err := mfa.UseCode(ctx, server.store, user, req.GetCode(), time.Now())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to check TOTP code: %s", err)
+	}
+	if !ok {
+		// wrong codes count against the user like wrong passwords
+		err = server.loginGuard.RecordFailure(ctx, user.Username, clientIP)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to record login attempt: %s", err)
+		}
+		return nil, status.Errorf(codes.InvalidArgument, "invalid TOTP code")
+	}
+
+	recoveryCodes, err := mfa.GenerateRecoveryCodes(mfa.RecoveryCodeCount)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to generate recovery codes: %s", err)
+	}
+
+	hashedCodes := make([]string, len(recoveryCodes))
+	for i, code := range recoveryCodes {
+		hashedCodes[i], err = util.HashPassword(mfa.NormalizeRecoveryCode(code))
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to hash recovery code: %s", err)
+		}
+	}
+
+	_, err = server.store.EnableMFATx(ctx, db.EnableMFATxParams{
+		Username:            user.Username,
+		HashedRecoveryCodes: hashedCodes,
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to enable MFA: %s", err)
+	}
+
+	rsp := &pb.ConfirmTOTPResponse{
+		RecoveryCodes: recoveryCodes,
+	}
+	return rsp, nil
+}
+
+func validateConfirmTOTPRequest(req *pb.ConfirmTOTPRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateTOTPCode(req.GetCode()); err != nil {
+		violations = append(violations, fieldViolation("code", err))
+	}
+	return violations
+}
@@ -0,0 +1,255 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/xmeizh/simplebank/db/mock"
+	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/lockout"
+	"github.com/xmeizh/simplebank/mfa"
+	"github.com/xmeizh/simplebank/pb"
+	"github.com/xmeizh/simplebank/token"
+	"github.com/xmeizh/simplebank/util"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestConfirmTOTPAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	secret, err := mfa.GenerateSecret()
+	require.NoError(t, err)
+	user.TotpSecret = secret
+
+	mfaUser := user
+	mfaUser.IsMfaEnabled = true
+
+	notEnrolledUser := user
+	notEnrolledUser.TotpSecret = ""
+
+	now := time.Now()
+	code, err := mfa.GenerateCode(secret, now)
+	require.NoError(t, err)
+	// the time step of code, TOTP codes change every 30 seconds
+	step := now.Unix() / 30
+
+	var hashedRecoveryCodes []string
+
+	testCases := []struct {
+		name          string
+		req           *pb.ConfirmTOTPRequest
+		guard         loginGuard
+		buildStubs    func(store *mockdb.MockStore)
+		buildContext  func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, resp *pb.ConfirmTOTPResponse, err error)
+	}{
+		{
+			name: "OK",
+			req:  &pb.ConfirmTOTPRequest{Code: code},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().UseTotpStep(gomock.Any(), gomock.Any()).Times(1).Return(int64(1), nil)
+				store.EXPECT().EnableMFATx(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.EnableMFATxParams) (db.EnableMFATxResult, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Len(t, arg.HashedRecoveryCodes, mfa.RecoveryCodeCount)
+						return db.EnableMFATxResult{User: mfaUser}, nil
+					})
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, resp *pb.ConfirmTOTPResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, resp.GetRecoveryCodes(), mfa.RecoveryCodeCount)
+			},
+		},
+		{
+			name: "RecoveryCodesStoredHashed",
+			req:  &pb.ConfirmTOTPRequest{Code: code},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().UseTotpStep(gomock.Any(), gomock.Any()).Times(1).Return(int64(1), nil)
+				store.EXPECT().EnableMFATx(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.EnableMFATxParams) (db.EnableMFATxResult, error) {
+						hashedRecoveryCodes = arg.HashedRecoveryCodes
+						return db.EnableMFATxResult{User: mfaUser}, nil
+					})
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, resp *pb.ConfirmTOTPResponse, err error) {
+				require.NoError(t, err)
+				for i, code := range resp.GetRecoveryCodes() {
+					require.NotEqual(t, code, hashedRecoveryCodes[i])
+					require.NoError(t, util.CheckPassword(mfa.NormalizeRecoveryCode(code), hashedRecoveryCodes[i]))
+				}
+			},
+		},
+		{
+			name: "InvalidCode",
+			req:  &pb.ConfirmTOTPRequest{Code: "000000"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().EnableMFATx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, resp *pb.ConfirmTOTPResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+		{
+			name: "ReplayedCode",
+			req:  &pb.ConfirmTOTPRequest{Code: code},
+			buildStubs: func(store *mockdb.MockStore) {
+				replayed := user
+				replayed.TotpLastStep = step
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(replayed, nil)
+				store.EXPECT().UseTotpStep(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().EnableMFATx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, resp *pb.ConfirmTOTPResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+		{
+			name:  "RecordFailureError",
+			req:   &pb.ConfirmTOTPRequest{Code: "000000"},
+			guard: loginGuard{recordErr: sql.ErrConnDone},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().EnableMFATx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, resp *pb.ConfirmTOTPResponse, err error) {
+				// the failed attempt has to be counted by the lockout guard
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Internal, st.Code())
+			},
+		},
+		{
+			name:  "Throttled",
+			req:   &pb.ConfirmTOTPRequest{Code: code},
+			guard: loginGuard{checkErr: lockout.ErrLoginThrottled},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().EnableMFATx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, resp *pb.ConfirmTOTPResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.ResourceExhausted, st.Code())
+			},
+		},
+		{
+			name: "NotEnrolled",
+			req:  &pb.ConfirmTOTPRequest{Code: code},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(notEnrolledUser, nil)
+				store.EXPECT().EnableMFATx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, resp *pb.ConfirmTOTPResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.FailedPrecondition, st.Code())
+			},
+		},
+		{
+			name: "AlreadyEnabled",
+			req:  &pb.ConfirmTOTPRequest{Code: code},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(mfaUser, nil)
+				store.EXPECT().EnableMFATx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, resp *pb.ConfirmTOTPResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.FailedPrecondition, st.Code())
+			},
+		},
+		{
+			name: "InvalidArgument",
+			req:  &pb.ConfirmTOTPRequest{Code: "abc"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, resp *pb.ConfirmTOTPResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+		{
+			name: "NoAuthorization",
+			req:  &pb.ConfirmTOTPRequest{Code: code},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return context.Background()
+			},
+			checkResponse: func(t *testing.T, resp *pb.ConfirmTOTPResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+			server := newTestServer(t, store, nil)
+			server.loginGuard = tc.guard
+
+			ctx := tc.buildContext(t, server.tokenMaker)
+			resp, err := server.ConfirmTOTP(ctx, tc.req)
+
+			tc.checkResponse(t, resp, err)
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/pb"
+	"github.com/xmeizh/simplebank/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// startAtLeeway tolerates clock skew when a client schedules a transfer to start right away
+const startAtLeeway = time.Minute
+
+func (server *Server) CreateScheduledTransfer(ctx context.Context, req *pb.CreateScheduledTransferRequest) (*pb.CreateScheduledTransferResponse, error) {
+	authPayload, _, err := authFromContext(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateCreateScheduledTransferRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	fromAccount, err := server.validAccount(ctx, req.GetFromAccountId(), req.GetCurrency())
+	if err != nil {
+		return nil, err
+	}
+
+	if fromAccount.Owner != authPayload.Username {
+		return nil, status.Errorf(codes.PermissionDenied, "from account doesn't belong to the authenticated user")
+	}
+
+	// scheduled transfers run without a client to agree on an exchange rate,
+	// so both accounts must hold the same currency
+	_, err = server.validAccount(ctx, req.GetToAccountId(), req.GetCurrency())
+	if err != nil {
+		return nil, err
+	}
+
+	arg := db.CreateScheduledTransferParams{
+		Owner:           authPayload.Username,
+		FromAccountID:   req.GetFromAccountId(),
+		ToAccountID:     req.GetToAccountId(),
+		Amount:          req.GetAmount(),
+		IntervalSeconds: req.GetIntervalSeconds(),
+		NextRunAt:       time.Now(),
+	}
+
+	if req.StartAt != nil {
+		arg.NextRunAt = req.GetStartAt().AsTime()
+	}
+
+	if req.EndAt != nil {
+		arg.EndAt = sql.NullTime{
+			Time:  req.GetEndAt().AsTime(),
+			Valid: true,
+		}
+	}
+
+	scheduledTransfer, err := server.store.CreateScheduledTransfer(ctx, arg)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create scheduled transfer: %s", err)
+	}
+
+	rsp := &pb.CreateScheduledTransferResponse{
+		ScheduledTransfer: convertScheduledTransfer(scheduledTransfer),
+	}
+	return rsp, nil
+}
+
+func validateCreateScheduledTransferRequest(req *pb.CreateScheduledTransferRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateAccountID(req.GetFromAccountId()); err != nil {
+		violations = append(violations, fieldViolation("from_account_id", err))
+	}
+
+	if err := val.ValidateAccountID(req.GetToAccountId()); err != nil {
+		violations = append(violations, fieldViolation("to_account_id", err))
+	}
+
+	if err := val.ValidateAmount(req.GetAmount()); err != nil {
+		violations = append(violations, fieldViolation("amount", err))
+	}
+
+	if err := val.ValidateCurrency(req.GetCurrency()); err != nil {
+		violations = append(violations, fieldViolation("currency", err))
+	}
+
+	if err := val.ValidateScheduleInterval(req.GetIntervalSeconds()); err != nil {
+		violations = append(violations, fieldViolation("interval_seconds", err))
+	}
+
+	startAt := time.Now()
+	if req.StartAt != nil {
+		startAt = req.GetStartAt().AsTime()
+		if startAt.Before(time.Now().Add(-startAtLeeway)) {
+			violations = append(violations, fieldViolation("start_at", errors.New("must not be in the past")))
+		}
+	}
+
+	if req.EndAt != nil && !req.GetEndAt().AsTime().After(startAt) {
+		violations = append(violations, fieldViolation("end_at", errors.New("must be after start_at")))
+	}
+	return violations
+}
@@ -0,0 +1,170 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/fx"
+	"github.com/xmeizh/simplebank/pb"
+	"github.com/xmeizh/simplebank/util"
+	"github.com/xmeizh/simplebank/val"
+	"github.com/xmeizh/simplebank/worker"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) CreateTransfer(ctx context.Context, req *pb.CreateTransferRequest) (*pb.CreateTransferResponse, error) {
+	authPayload, _, err := authFromContext(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateCreateTransferRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	fromAccount, err := server.validAccount(ctx, req.GetFromAccountId(), req.GetCurrency())
+	if err != nil {
+		return nil, err
+	}
+
+	if fromAccount.Owner != authPayload.Username {
+		return nil, status.Errorf(codes.PermissionDenied, "from account doesn't belong to the authenticated user")
+	}
+
+	toAccount, err := server.findAccount(ctx, req.GetToAccountId())
+	if err != nil {
+		return nil, err
+	}
+
+	arg := db.TransferTxParams{
+		FromAccountID: req.GetFromAccountId(),
+		ToAccountID:   req.GetToAccountId(),
+		Amount:        req.GetAmount(),
+		Currency:      req.GetCurrency(),
+		AfterTransfer: func(q db.Querier, result db.TransferTxResult) error {
+			return worker.DistributeTransferNotifications(ctx, worker.NewOutboxTaskDistributor(q), result.Transfer)
+		},
+	}
+
+	if toAccount.Currency != fromAccount.Currency {
+		arg.ConvertedAmount, arg.ExchangeRate, err = server.convertAmount(ctx, req.GetAmount(), fromAccount.Currency, toAccount.Currency)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if req.IdempotencyKey != nil {
+		arg.Username = authPayload.Username
+		arg.IdempotencyKey = req.GetIdempotencyKey()
+	}
+
+	result, err := server.store.TransferTx(ctx, arg)
+	if err != nil {
+		if errors.Is(err, db.ErrIdempotencyKeyConflict) {
+			return nil, status.Errorf(codes.AlreadyExists, "%s", err)
+		}
+		if errors.Is(err, db.ErrInsufficientFunds) {
+			return nil, status.Errorf(codes.FailedPrecondition, "%s", err)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to create transfer: %s", err)
+	}
+
+	rsp := &pb.CreateTransferResponse{
+		Transfer:    convertTransfer(result.Transfer),
+		FromAccount: convertAccount(result.FromAccount),
+		ToAccount:   convertAccount(result.ToAccount),
+		FromEntry:   convertEntry(result.FromEntry),
+		ToEntry:     convertEntry(result.ToEntry),
+	}
+	return rsp, nil
+}
+
+// validAccount checks that the account exists and holds the given currency.
+// The returned error is already a gRPC status error.
+func (server *Server) validAccount(ctx context.Context, accountID int64, currency string) (db.Account, error) {
+	account, err := server.findAccount(ctx, accountID)
+	if err != nil {
+		return db.Account{}, err
+	}
+
+	if account.Currency != currency {
+		return db.Account{}, status.Errorf(codes.InvalidArgument, "account [%d] currency mismatch: %s vs %s", account.ID, account.Currency, currency)
+	}
+	return account, nil
+}
+
+// findAccount gets the account and turns a failed lookup into a gRPC status error
+func (server *Server) findAccount(ctx context.Context, accountID int64) (db.Account, error) {
+	account, err := server.store.GetAccount(ctx, accountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return db.Account{}, status.Errorf(codes.NotFound, "account [%d] not found: %s", accountID, err)
+		}
+		return db.Account{}, status.Errorf(codes.Internal, "failed to get account: %s", err)
+	}
+	return account, nil
+}
+
+// convertAmount converts the amount between currencies at the current exchange rate.
+// The returned error is already a gRPC status error.
+func (server *Server) convertAmount(ctx context.Context, amount int64, from string, to string) (int64, float64, error) {
+	rate, err := server.rateProvider.Rate(ctx, from, to)
+	if err != nil {
+		if errors.Is(err, fx.ErrRateNotFound) {
+			return 0, 0, status.Errorf(codes.FailedPrecondition, "cannot transfer from %s to %s: %s", from, to, err)
+		}
+		return 0, 0, status.Errorf(codes.Internal, "failed to get exchange rate: %s", err)
+	}
+
+	fromCurrency, ok := util.GetCurrency(from)
+	if !ok {
+		return 0, 0, status.Errorf(codes.FailedPrecondition, "unknown currency %s", from)
+	}
+
+	toCurrency, ok := util.GetCurrency(to)
+	if !ok {
+		return 0, 0, status.Errorf(codes.FailedPrecondition, "unknown currency %s", to)
+	}
+
+	convertedAmount, err := fx.Convert(amount, rate, fromCurrency.MinorUnits, toCurrency.MinorUnits)
+	if err != nil {
+		return 0, 0, status.Errorf(codes.InvalidArgument, "amount %d %s can't be converted to %s: %s", amount, from, to, err)
+	}
+	if convertedAmount <= 0 {
+		return 0, 0, status.Errorf(codes.InvalidArgument, "amount %d %s is too small to convert to %s", amount, from, to)
+	}
+
+	// the rate is only recorded with the transfer, the amount was converted with the exact rate
+	exchangeRate, _ := rate.Float64()
+	return convertedAmount, exchangeRate, nil
+}
+
+func validateCreateTransferRequest(req *pb.CreateTransferRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateAccountID(req.GetFromAccountId()); err != nil {
+		violations = append(violations, fieldViolation("from_account_id", err))
+	}
+
+	if err := val.ValidateAccountID(req.GetToAccountId()); err != nil {
+		violations = append(violations, fieldViolation("to_account_id", err))
+	}
+
+	if err := val.ValidateAmount(req.GetAmount()); err != nil {
+		violations = append(violations, fieldViolation("amount", err))
+	}
+
+	if err := val.ValidateCurrency(req.GetCurrency()); err != nil {
+		violations = append(violations, fieldViolation("currency", err))
+	}
+
+	if req.IdempotencyKey != nil {
+		if err := val.ValidateIdempotencyKey(req.GetIdempotencyKey()); err != nil {
+			violations = append(violations, fieldViolation("idempotency_key", err))
+		}
+	}
+	return violations
+}
@@ -3,7 +3,6 @@ package gapi
 import (
 	"context"
 	"log"
-	"time"
 
 	"github.com/hibiken/asynq"
 	"github.com/lib/pq"
@@ -34,16 +33,17 @@ func (server *Server) CreateUser(ctx context.Context, req *pb.CreateUserRequest)
 			FullName:       req.GetFullName(),
 			Email:          req.GetEmail(),
 		},
-		AfterCreate: func(user db.User) error {
+		AfterCreate: func(q db.Querier, user db.User) error {
 			SendVerificationEmailPayload := &worker.SendVerificationEmailPayload{
 				Username: user.Username,
 			}
 			opts := []asynq.Option{
 				asynq.MaxRetry(10),
-				asynq.ProcessIn(5 * time.Second),
 				asynq.Queue(worker.QueueCritical),
 			}
-			return server.taskDistributor.DistributeTaskSendVerificationEmail(ctx, SendVerificationEmailPayload, opts...)
+			// written to the outbox, so the email is only sent if the user is committed
+			outbox := worker.NewOutboxTaskDistributor(q)
+			return outbox.DistributeTaskSendVerificationEmail(ctx, SendVerificationEmailPayload, opts...)
 		},
 	}
 
@@ -3,6 +3,7 @@ package gapi
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"testing"
@@ -49,11 +50,24 @@ func TestCreateUserAPI(t *testing.T) {
 					User: user,
 				}
 
-				taskPayload := &worker.SendVerificationEmailPayload{
+				taskPayload, err := json.Marshal(&worker.SendVerificationEmailPayload{
 					Username: user.Username,
+				})
+				require.NoError(t, err)
+				outboxArg := db.CreateOutboxMessageParams{
+					TaskType: worker.TaskSendVerificationEmail,
+					Payload:  taskPayload,
+					Queue:    sql.NullString{String: worker.QueueCritical, Valid: true},
+					MaxRetry: sql.NullInt32{Int32: 10, Valid: true},
 				}
-				taskDistributor.EXPECT().DistributeTaskSendVerificationEmail(gomock.Any(), taskPayload, gomock.Any()).Times(1).Return(nil)
-				store.EXPECT().CreateUserTx(gomock.Any(), EqCreateUserTxParams(arg, password, user)).Times(1).Return(result, nil)
+
+				// the email is written to the outbox with the user instead of being enqueued right away
+				taskDistributor.EXPECT().DistributeTaskSendVerificationEmail(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateOutboxMessage(gomock.Any(), gomock.Eq(outboxArg)).Times(1).Return(db.Outbox{}, nil)
+				store.EXPECT().CreateUserTx(gomock.Any(), EqCreateUserTxParams(arg, password)).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateUserTxParams) (db.CreateUserTxResult, error) {
+						return result, arg.AfterCreate(store, user)
+					})
 			},
 			checkResponse: func(t *testing.T, resp *pb.CreateUserResponse, err error) {
 				require.NoError(t, err)
@@ -73,7 +87,7 @@ func TestCreateUserAPI(t *testing.T) {
 				Email:    user.Email,
 			},
 			buildStubs: func(store *mockdb.MockStore, taskDistributor *mockwk.MockTaskDistributor) {
-				taskDistributor.EXPECT().DistributeTaskSendVerificationEmail(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateOutboxMessage(gomock.Any(), gomock.Any()).Times(0)
 				store.EXPECT().CreateUserTx(gomock.Any(), gomock.Any()).Times(1).Return(db.CreateUserTxResult{}, sql.ErrConnDone)
 			},
 			checkResponse: func(t *testing.T, resp *pb.CreateUserResponse, err error) {
@@ -140,7 +154,6 @@ func randomUser(t *testing.T) (user db.User, password string) {
 type eqCreateUserTxParamsMatcher struct {
 	arg      db.CreateUserTxParams
 	password string
-	user     db.User
 }
 
 func (expected eqCreateUserTxParamsMatcher) Matches(x interface{}) bool {
@@ -156,16 +169,13 @@ func (expected eqCreateUserTxParamsMatcher) Matches(x interface{}) bool {
 	}
 
 	expected.arg.HashedPassword = actualArg.HashedPassword
-	eq := reflect.DeepEqual(expected.arg.CreateUserParams, actualArg.CreateUserParams)
-
-	err = actualArg.AfterCreate(expected.user)
-	return err == nil && eq
+	return reflect.DeepEqual(expected.arg.CreateUserParams, actualArg.CreateUserParams)
 }
 
 func (expected eqCreateUserTxParamsMatcher) String() string {
 	return fmt.Sprintf("matches arg %v and password %v", expected.arg, expected.password)
 }
 
-func EqCreateUserTxParams(arg db.CreateUserTxParams, password string, user db.User) gomock.Matcher {
-	return eqCreateUserTxParamsMatcher{arg, password, user}
+func EqCreateUserTxParams(arg db.CreateUserTxParams, password string) gomock.Matcher {
+	return eqCreateUserTxParamsMatcher{arg, password}
 }
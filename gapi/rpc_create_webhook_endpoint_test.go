@@ -0,0 +1,188 @@
+package gapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/xmeizh/simplebank/db/mock"
+	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/pb"
+	"github.com/xmeizh/simplebank/token"
+	"github.com/xmeizh/simplebank/util"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCreateWebhookEndpointAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	endpoint := randomWebhookEndpoint(user.Username)
+
+	testCases := []struct {
+		name          string
+		req           *pb.CreateWebhookEndpointRequest
+		buildStubs    func(store *mockdb.MockStore)
+		buildContext  func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, resp *pb.CreateWebhookEndpointResponse, err error)
+	}{
+		{
+			name: "OK",
+			req: &pb.CreateWebhookEndpointRequest{
+				Url:        endpoint.Url,
+				EventTypes: endpoint.EventTypes,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateWebhookEndpoint(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.CreateWebhookEndpointParams) (db.WebhookEndpoint, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, endpoint.Url, arg.Url)
+						require.Equal(t, endpoint.EventTypes, arg.EventTypes)
+						require.Len(t, arg.Secret, 43)
+
+						created := endpoint
+						created.Secret = arg.Secret
+						return created, nil
+					})
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, resp *pb.CreateWebhookEndpointResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, endpoint.ID, resp.GetEndpoint().Id)
+				require.Equal(t, endpoint.Url, resp.GetEndpoint().Url)
+				require.Equal(t, endpoint.EventTypes, resp.GetEndpoint().EventTypes)
+				require.Len(t, resp.GetSecret(), 43)
+			},
+		},
+		{
+			name: "AllEventTypes",
+			req: &pb.CreateWebhookEndpointRequest{
+				Url: endpoint.Url,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateWebhookEndpoint(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.CreateWebhookEndpointParams) (db.WebhookEndpoint, error) {
+						require.NotNil(t, arg.EventTypes)
+						require.Empty(t, arg.EventTypes)
+						return endpoint, nil
+					})
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, resp *pb.CreateWebhookEndpointResponse, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "InvalidURL",
+			req: &pb.CreateWebhookEndpointRequest{
+				Url: "ftp://example.com/hook",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateWebhookEndpoint(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, resp *pb.CreateWebhookEndpointResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+		{
+			name: "InternalURL",
+			req: &pb.CreateWebhookEndpointRequest{
+				Url: "http://169.254.169.254/latest/meta-data",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateWebhookEndpoint(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, resp *pb.CreateWebhookEndpointResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+		{
+			name: "InvalidEventType",
+			req: &pb.CreateWebhookEndpointRequest{
+				Url:        endpoint.Url,
+				EventTypes: []string{db.EventTransferCompleted, "account_closed"},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateWebhookEndpoint(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, resp *pb.CreateWebhookEndpointResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+		{
+			name: "NoAuthorization",
+			req: &pb.CreateWebhookEndpointRequest{
+				Url: endpoint.Url,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateWebhookEndpoint(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return context.Background()
+			},
+			checkResponse: func(t *testing.T, resp *pb.CreateWebhookEndpointResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+			server := newTestServer(t, store, nil)
+
+			ctx := tc.buildContext(t, server.tokenMaker)
+			resp, err := server.CreateWebhookEndpoint(ctx, tc.req)
+
+			tc.checkResponse(t, resp, err)
+		})
+	}
+}
+
+func randomWebhookEndpoint(username string) db.WebhookEndpoint {
+	return db.WebhookEndpoint{
+		ID:         util.RandomInt(1, 1000),
+		Username:   username,
+		Url:        "https://example.com/" + util.RandomString(6),
+		Secret:     util.RandomString(43),
+		EventTypes: []string{db.EventAccountOpened, db.EventTransferCompleted},
+		CreatedAt:  time.Now(),
+	}
+}
@@ -0,0 +1,64 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+	"github.com/xmeizh/simplebank/pb"
+	"github.com/xmeizh/simplebank/rbac"
+	"github.com/xmeizh/simplebank/util"
+	"github.com/xmeizh/simplebank/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) DeleteAccount(ctx context.Context, req *pb.DeleteAccountRequest) (*pb.DeleteAccountResponse, error) {
+	authPayload, permissions, err := authFromContext(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateDeleteAccountRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	// Check if the account exists
+	account, err := server.store.GetAccount(ctx, req.GetId())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Errorf(codes.NotFound, "account not found: %s", err)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get account: %s", err)
+	}
+
+	// Check if the account belongs to the user
+	if !permissions.AllowsAny(rbac.DeleteAccounts) && account.Owner != authPayload.Username {
+		return nil, status.Errorf(codes.PermissionDenied, "account doesn't belong to the authenticated user")
+	}
+
+	// the money left in the account would be lost
+	if account.Balance != 0 {
+		return nil, status.Errorf(codes.FailedPrecondition, "account has a balance of %s, it must be empty to be deleted", util.FormatAmount(account.Balance, account.Currency))
+	}
+
+	err = server.store.DeleteAccount(ctx, account.ID)
+	if err != nil {
+		// the account is still referenced, e.g. by its entries and transfers
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "foreign_key_violation" {
+			return nil, status.Errorf(codes.FailedPrecondition, "account has history and can't be deleted: %s", err)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to delete account: %s", err)
+	}
+
+	return &pb.DeleteAccountResponse{}, nil
+}
+
+func validateDeleteAccountRequest(req *pb.DeleteAccountRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateAccountID(req.GetId()); err != nil {
+		violations = append(violations, fieldViolation("id", err))
+	}
+	return violations
+}
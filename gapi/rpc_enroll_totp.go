@@ -0,0 +1,54 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+
+	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/mfa"
+	"github.com/xmeizh/simplebank/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) EnrollTOTP(ctx context.Context, req *pb.EnrollTOTPRequest) (*pb.EnrollTOTPResponse, error) {
+	authPayload, _, err := authFromContext(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	user, err := server.store.GetUser(ctx, authPayload.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Errorf(codes.NotFound, "user not found: %s", err)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get user: %s", err)
+	}
+
+	if user.IsMfaEnabled {
+		return nil, status.Errorf(codes.FailedPrecondition, "MFA is already enabled")
+	}
+
+	secret, err := mfa.GenerateSecret()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to generate TOTP secret: %s", err)
+	}
+
+	// the secret is pending until ConfirmTOTP proves the authenticator app has it, enrolling again replaces it
+	_, err = server.store.UpdateUser(ctx, db.UpdateUserParams{
+		Username: user.Username,
+		TotpSecret: sql.NullString{
+			String: secret,
+			Valid:  true,
+		},
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to store TOTP secret: %s", err)
+	}
+
+	rsp := &pb.EnrollTOTPResponse{
+		Secret: secret,
+		Uri:    mfa.KeyURI(server.config.TOTPIssuer, user.Username, secret),
+	}
+	return rsp, nil
+}
@@ -0,0 +1,84 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/xmeizh/simplebank/pb"
+	"github.com/xmeizh/simplebank/rbac"
+	"github.com/xmeizh/simplebank/statement"
+	"github.com/xmeizh/simplebank/val"
+	"github.com/xmeizh/simplebank/worker"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (server *Server) ExportAccountStatement(ctx context.Context, req *pb.ExportAccountStatementRequest) (*pb.ExportAccountStatementResponse, error) {
+	authPayload, permissions, err := authFromContext(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateExportAccountStatementRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	account, err := server.store.GetAccount(ctx, req.GetAccountId())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Errorf(codes.NotFound, "account not found: %s", err)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get account: %s", err)
+	}
+
+	if !permissions.AllowsAny(rbac.ReadAccounts) && account.Owner != authPayload.Username {
+		return nil, status.Errorf(codes.PermissionDenied, "account doesn't belong to the authenticated user")
+	}
+
+	owner, err := server.store.GetUser(ctx, account.Owner)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get account owner: %s", err)
+	}
+
+	if !owner.IsEmailVerified {
+		return nil, status.Errorf(codes.FailedPrecondition, "email of the account owner is not verified")
+	}
+
+	fromTime, toTime := statement.MonthPeriod(time.Date(int(req.GetYear()), time.Month(req.GetMonth()), 1, 0, 0, 0, 0, time.UTC))
+	payload := &worker.SendAccountStatementPayload{
+		AccountID: account.ID,
+		Month:     fromTime,
+	}
+	opts := []asynq.Option{
+		asynq.MaxRetry(10),
+		asynq.Queue(worker.QueueDefault),
+	}
+
+	err = server.taskDistributor.DistributeTaskSendAccountStatement(ctx, payload, opts...)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to export account statement: %s", err)
+	}
+
+	rsp := &pb.ExportAccountStatementResponse{
+		Account:  convertAccount(account),
+		FromTime: timestamppb.New(fromTime),
+		ToTime:   timestamppb.New(toTime),
+	}
+	return rsp, nil
+}
+
+func validateExportAccountStatementRequest(req *pb.ExportAccountStatementRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateAccountID(req.GetAccountId()); err != nil {
+		violations = append(violations, fieldViolation("account_id", err))
+	}
+
+	if err := val.ValidateStatementMonth(req.GetYear(), req.GetMonth()); err != nil {
+		violations = append(violations, fieldViolation("month", err))
+	}
+	return violations
+}
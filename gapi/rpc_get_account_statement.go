@@ -0,0 +1,80 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/pb"
+	"github.com/xmeizh/simplebank/rbac"
+	"github.com/xmeizh/simplebank/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) GetAccountStatement(ctx context.Context, req *pb.GetAccountStatementRequest) (*pb.GetAccountStatementResponse, error) {
+	authPayload, permissions, err := authFromContext(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateGetAccountStatementRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	account, err := server.store.GetAccount(ctx, req.GetAccountId())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Errorf(codes.NotFound, "account not found: %s", err)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get account: %s", err)
+	}
+
+	if !permissions.AllowsAny(rbac.ReadAccounts) && account.Owner != authPayload.Username {
+		return nil, status.Errorf(codes.PermissionDenied, "account doesn't belong to the authenticated user")
+	}
+
+	statement, err := server.store.AccountStatementTx(ctx, db.AccountStatementTxParams{
+		AccountID: account.ID,
+		FromTime:  req.GetFromTime().AsTime(),
+		ToTime:    req.GetToTime().AsTime(),
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get account statement: %s", err)
+	}
+
+	rsp := &pb.GetAccountStatementResponse{
+		Account:        convertAccount(account),
+		OpeningBalance: statement.OpeningBalance,
+		ClosingBalance: statement.ClosingBalance,
+		Entries:        make([]*pb.StatementEntry, 0, len(statement.Entries)),
+	}
+	for _, entry := range statement.Entries {
+		rsp.Entries = append(rsp.Entries, convertStatementEntry(entry))
+	}
+	return rsp, nil
+}
+
+func validateGetAccountStatementRequest(req *pb.GetAccountStatementRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateAccountID(req.GetAccountId()); err != nil {
+		violations = append(violations, fieldViolation("account_id", err))
+	}
+
+	if req.FromTime == nil {
+		violations = append(violations, fieldViolation("from_time", errors.New("is required")))
+	}
+
+	if req.ToTime == nil {
+		violations = append(violations, fieldViolation("to_time", errors.New("is required")))
+	}
+
+	if req.FromTime != nil && req.ToTime != nil {
+		if err := val.ValidateStatementPeriod(req.GetFromTime().AsTime(), req.GetToTime().AsTime()); err != nil {
+			violations = append(violations, fieldViolation("to_time", err))
+		}
+	}
+	return violations
+}
@@ -0,0 +1,64 @@
+package gapi
+
+import (
+	"context"
+
+	"github.com/lib/pq"
+	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/pb"
+	"github.com/xmeizh/simplebank/rbac"
+	"github.com/xmeizh/simplebank/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) GrantRole(ctx context.Context, req *pb.GrantRoleRequest) (*pb.GrantRoleResponse, error) {
+	_, permissions, err := authFromContext(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	// roles are granted to other users, a user who could only manage their own roles could make themselves admin
+	if !permissions.AllowsAny(rbac.ManageRoles) {
+		return nil, status.Errorf(codes.PermissionDenied, "cannot manage the roles of other users")
+	}
+
+	violations := validateGrantRoleRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	err = server.store.GrantUserRole(ctx, db.GrantUserRoleParams{
+		Username: req.GetUsername(),
+		Role:     req.GetRole(),
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "foreign_key_violation" {
+			return nil, status.Errorf(codes.NotFound, "user or role not found: %s", err)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to grant role: %s", err)
+	}
+
+	roles, err := server.store.ListUserRoles(ctx, req.GetUsername())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list roles: %s", err)
+	}
+
+	rsp := &pb.GrantRoleResponse{
+		Username: req.GetUsername(),
+		Roles:    roles,
+	}
+	return rsp, nil
+}
+
+func validateGrantRoleRequest(req *pb.GrantRoleRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateUsername(req.GetUsername()); err != nil {
+		violations = append(violations, fieldViolation("username", err))
+	}
+
+	if err := val.ValidateRole(req.GetRole()); err != nil {
+		violations = append(violations, fieldViolation("role", err))
+	}
+	return violations
+}
@@ -0,0 +1,92 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+
+	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/pb"
+	"github.com/xmeizh/simplebank/util"
+	"github.com/xmeizh/simplebank/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) ListAccounts(ctx context.Context, req *pb.ListAccountsRequest) (*pb.ListAccountsResponse, error) {
+	authPayload, _, err := authFromContext(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateListAccountsRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	var accounts []db.Account
+	var nextPageToken string
+
+	if req.GetPageId() > 0 {
+		accounts, err = server.store.ListAccounts(ctx, db.ListAccountsParams{
+			Owner:  authPayload.Username,
+			Limit:  req.GetPageSize(),
+			Offset: (req.GetPageId() - 1) * req.GetPageSize(),
+		})
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to list accounts: %s", err)
+		}
+	} else {
+		scope := "accounts:" + authPayload.Username
+		after, err := decodePageToken(req.GetPageToken(), scope)
+		if err != nil {
+			return nil, err
+		}
+
+		size := pageSize(req.GetPageSize())
+		accounts, err = server.store.ListAccountsAfter(ctx, db.ListAccountsAfterParams{
+			Owner:          authPayload.Username,
+			AfterCreatedAt: after.CreatedAt,
+			AfterID:        after.ID,
+			Limit:          size + 1,
+		})
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to list accounts: %s", err)
+		}
+
+		accounts, nextPageToken = nextPage(accounts, size, scope, func(account db.Account) util.PageCursor {
+			return util.PageCursor{CreatedAt: account.CreatedAt, ID: account.ID}
+		})
+	}
+
+	rsp := &pb.ListAccountsResponse{
+		Accounts:      make([]*pb.Account, 0, len(accounts)),
+		NextPageToken: nextPageToken,
+	}
+	for _, account := range accounts {
+		rsp.Accounts = append(rsp.Accounts, convertAccount(account))
+	}
+	return rsp, nil
+}
+
+func validateListAccountsRequest(req *pb.ListAccountsRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if req.GetPageId() == 0 {
+		if err := val.ValidateOptionalPageSize(req.GetPageSize()); err != nil {
+			violations = append(violations, fieldViolation("page_size", err))
+		}
+		return violations
+	}
+
+	if err := val.ValidatePageID(req.GetPageId()); err != nil {
+		violations = append(violations, fieldViolation("page_id", err))
+	}
+
+	if err := val.ValidatePageSize(req.GetPageSize()); err != nil {
+		violations = append(violations, fieldViolation("page_size", err))
+	}
+
+	if req.GetPageToken() != "" {
+		violations = append(violations, fieldViolation("page_token", errors.New("must not be combined with page_id")))
+	}
+	return violations
+}
@@ -0,0 +1,92 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+
+	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/pb"
+	"github.com/xmeizh/simplebank/util"
+	"github.com/xmeizh/simplebank/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) ListScheduledTransfers(ctx context.Context, req *pb.ListScheduledTransfersRequest) (*pb.ListScheduledTransfersResponse, error) {
+	authPayload, _, err := authFromContext(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateListScheduledTransfersRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	var scheduledTransfers []db.ScheduledTransfer
+	var nextPageToken string
+
+	if req.GetPageId() > 0 {
+		scheduledTransfers, err = server.store.ListScheduledTransfers(ctx, db.ListScheduledTransfersParams{
+			Owner:  authPayload.Username,
+			Limit:  req.GetPageSize(),
+			Offset: (req.GetPageId() - 1) * req.GetPageSize(),
+		})
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to list scheduled transfers: %s", err)
+		}
+	} else {
+		scope := "scheduled_transfers:" + authPayload.Username
+		after, err := decodePageToken(req.GetPageToken(), scope)
+		if err != nil {
+			return nil, err
+		}
+
+		size := pageSize(req.GetPageSize())
+		scheduledTransfers, err = server.store.ListScheduledTransfersAfter(ctx, db.ListScheduledTransfersAfterParams{
+			Owner:          authPayload.Username,
+			AfterCreatedAt: after.CreatedAt,
+			AfterID:        after.ID,
+			Limit:          size + 1,
+		})
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to list scheduled transfers: %s", err)
+		}
+
+		scheduledTransfers, nextPageToken = nextPage(scheduledTransfers, size, scope, func(scheduledTransfer db.ScheduledTransfer) util.PageCursor {
+			return util.PageCursor{CreatedAt: scheduledTransfer.CreatedAt, ID: scheduledTransfer.ID}
+		})
+	}
+
+	rsp := &pb.ListScheduledTransfersResponse{
+		ScheduledTransfers: make([]*pb.ScheduledTransfer, 0, len(scheduledTransfers)),
+		NextPageToken:      nextPageToken,
+	}
+	for _, scheduledTransfer := range scheduledTransfers {
+		rsp.ScheduledTransfers = append(rsp.ScheduledTransfers, convertScheduledTransfer(scheduledTransfer))
+	}
+	return rsp, nil
+}
+
+func validateListScheduledTransfersRequest(req *pb.ListScheduledTransfersRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if req.GetPageId() == 0 {
+		if err := val.ValidateOptionalPageSize(req.GetPageSize()); err != nil {
+			violations = append(violations, fieldViolation("page_size", err))
+		}
+		return violations
+	}
+
+	if err := val.ValidatePageID(req.GetPageId()); err != nil {
+		violations = append(violations, fieldViolation("page_id", err))
+	}
+
+	if err := val.ValidatePageSize(req.GetPageSize()); err != nil {
+		violations = append(violations, fieldViolation("page_size", err))
+	}
+
+	if req.GetPageToken() != "" {
+		violations = append(violations, fieldViolation("page_token", errors.New("must not be combined with page_id")))
+	}
+	return violations
+}
@@ -0,0 +1,79 @@
+package gapi
+
+import (
+	"database/sql"
+
+	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/pb"
+	"github.com/xmeizh/simplebank/rbac"
+	"github.com/xmeizh/simplebank/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// listTransfersBatchSize is the number of transfers loaded from the db per query
+// while streaming the transfer history of an account
+const listTransfersBatchSize = 100
+
+func (server *Server) ListTransfers(req *pb.ListTransfersRequest, stream grpc.ServerStreamingServer[pb.ListTransfersResponse]) error {
+	ctx := stream.Context()
+
+	authPayload, permissions, err := authFromContext(ctx)
+	if err != nil {
+		return unauthenticatedError(err)
+	}
+
+	violations := validateListTransfersRequest(req)
+	if violations != nil {
+		return invalidArgumentError(violations)
+	}
+
+	account, err := server.store.GetAccount(ctx, req.GetAccountId())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return status.Errorf(codes.NotFound, "account not found: %s", err)
+		}
+		return status.Errorf(codes.Internal, "failed to get account: %s", err)
+	}
+
+	if !permissions.AllowsAny(rbac.ReadTransfers) && account.Owner != authPayload.Username {
+		return status.Errorf(codes.PermissionDenied, "account doesn't belong to the authenticated user")
+	}
+
+	// Batches are loaded by keyset, so transfers booked while streaming are neither skipped nor sent twice
+	arg := db.ListTransfersAfterParams{
+		AccountID: account.ID,
+		Limit:     listTransfersBatchSize,
+	}
+
+	for {
+		transfers, err := server.store.ListTransfersAfter(ctx, arg)
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to list transfers: %s", err)
+		}
+
+		for _, transfer := range transfers {
+			err = stream.Send(&pb.ListTransfersResponse{
+				Transfer: convertTransfer(transfer),
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(transfers) < listTransfersBatchSize {
+			return nil
+		}
+		arg.AfterCreatedAt = transfers[len(transfers)-1].CreatedAt
+		arg.AfterID = transfers[len(transfers)-1].ID
+	}
+}
+
+func validateListTransfersRequest(req *pb.ListTransfersRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateAccountID(req.GetAccountId()); err != nil {
+		violations = append(violations, fieldViolation("account_id", err))
+	}
+	return violations
+}
@@ -0,0 +1,148 @@
+package gapi
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/xmeizh/simplebank/db/mock"
+	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/pb"
+	"github.com/xmeizh/simplebank/token"
+	"github.com/xmeizh/simplebank/util"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type testServerStream[T any] struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent []*T
+}
+
+func (stream *testServerStream[T]) Context() context.Context {
+	return stream.ctx
+}
+
+func (stream *testServerStream[T]) Send(msg *T) error {
+	stream.sent = append(stream.sent, msg)
+	return nil
+}
+
+func TestListTransfersAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	n := listTransfersBatchSize + 5
+	transfers := make([]db.Transfer, n)
+	for i := range transfers {
+		transfers[i] = db.Transfer{
+			ID:            int64(i + 1),
+			FromAccountID: account.ID,
+			ToAccountID:   util.RandomInt(1, 1000),
+			Amount:        util.RandomMoney(),
+			CreatedAt:     time.Now().Add(time.Duration(i) * time.Second),
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		req           *pb.ListTransfersRequest
+		buildStubs    func(store *mockdb.MockStore)
+		buildContext  func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, sent []*pb.ListTransfersResponse, err error)
+	}{
+		{
+			name: "OK",
+			req: &pb.ListTransfersRequest{
+				AccountId: account.ID,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+
+				arg := db.ListTransfersAfterParams{
+					AccountID: account.ID,
+					Limit:     listTransfersBatchSize,
+				}
+				store.EXPECT().ListTransfersAfter(gomock.Any(), gomock.Eq(arg)).Times(1).Return(transfers[:listTransfersBatchSize], nil)
+
+				arg.AfterCreatedAt = transfers[listTransfersBatchSize-1].CreatedAt
+				arg.AfterID = transfers[listTransfersBatchSize-1].ID
+				store.EXPECT().ListTransfersAfter(gomock.Any(), gomock.Eq(arg)).Times(1).Return(transfers[listTransfersBatchSize:], nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, sent []*pb.ListTransfersResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, sent, n)
+				for i, rsp := range sent {
+					require.Equal(t, transfers[i].ID, rsp.GetTransfer().Id)
+				}
+			},
+		},
+		{
+			name: "PermissionDenied",
+			req: &pb.ListTransfersRequest{
+				AccountId: account.ID,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListTransfersAfter(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, "other_user", util.DepositorRole, time.Minute)
+			},
+			checkResponse: func(t *testing.T, sent []*pb.ListTransfersResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.PermissionDenied, st.Code())
+				require.Empty(t, sent)
+			},
+		},
+		{
+			name: "InvalidAccountID",
+			req: &pb.ListTransfersRequest{
+				AccountId: 0,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ListTransfersAfter(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, sent []*pb.ListTransfersResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+			server := newTestServer(t, store, nil)
+
+			stream := &testServerStream[pb.ListTransfersResponse]{
+				ctx: tc.buildContext(t, server.tokenMaker),
+			}
+			err := server.ListTransfers(tc.req, stream)
+
+			tc.checkResponse(t, stream.sent, err)
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/pb"
+	"github.com/xmeizh/simplebank/util"
+	"github.com/xmeizh/simplebank/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) ListWebhookDeliveries(ctx context.Context, req *pb.ListWebhookDeliveriesRequest) (*pb.ListWebhookDeliveriesResponse, error) {
+	authPayload, _, err := authFromContext(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateListWebhookDeliveriesRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	// the status filter is part of the scope, so a token can't be reused with another filter
+	scope := "webhook_deliveries:" + authPayload.Username + ":" + req.GetStatus()
+	after, err := decodePageToken(req.GetPageToken(), scope)
+	if err != nil {
+		return nil, err
+	}
+
+	size := pageSize(req.GetPageSize())
+	deliveries, err := server.store.ListWebhookDeliveriesAfter(ctx, db.ListWebhookDeliveriesAfterParams{
+		Username: authPayload.Username,
+		Status: sql.NullString{
+			String: req.GetStatus(),
+			Valid:  req.GetStatus() != "",
+		},
+		AfterCreatedAt: after.CreatedAt,
+		AfterID:        after.ID,
+		Limit:          size + 1,
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list webhook deliveries: %s", err)
+	}
+
+	deliveries, nextPageToken := nextPage(deliveries, size, scope, func(delivery db.WebhookDelivery) util.PageCursor {
+		return util.PageCursor{CreatedAt: delivery.CreatedAt, ID: delivery.ID}
+	})
+
+	rsp := &pb.ListWebhookDeliveriesResponse{
+		Deliveries:    make([]*pb.WebhookDelivery, 0, len(deliveries)),
+		NextPageToken: nextPageToken,
+	}
+	for _, delivery := range deliveries {
+		rsp.Deliveries = append(rsp.Deliveries, convertWebhookDelivery(delivery))
+	}
+	return rsp, nil
+}
+
+func validateListWebhookDeliveriesRequest(req *pb.ListWebhookDeliveriesRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	switch req.GetStatus() {
+	case "", db.WebhookDeliveryPending, db.WebhookDeliverySucceeded, db.WebhookDeliveryFailed, db.WebhookDeliverySuperseded:
+	default:
+		violations = append(violations, fieldViolation("status", errors.New("must be pending, succeeded, failed or superseded")))
+	}
+
+	if err := val.ValidateOptionalPageSize(req.GetPageSize()); err != nil {
+		violations = append(violations, fieldViolation("page_size", err))
+	}
+	return violations
+}
@@ -0,0 +1,165 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/xmeizh/simplebank/db/mock"
+	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/pb"
+	"github.com/xmeizh/simplebank/token"
+	"github.com/xmeizh/simplebank/util"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestListWebhookDeliveriesAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	endpoint := randomWebhookEndpoint(user.Username)
+
+	n := 3
+	createdAt := time.Now().UTC().Truncate(time.Second)
+	deliveries := make([]db.WebhookDelivery, n)
+	for i := range deliveries {
+		deliveries[i] = randomWebhookDelivery(endpoint.ID)
+		deliveries[i].ID = int64(i + 1)
+		deliveries[i].Status = db.WebhookDeliveryFailed
+		deliveries[i].CreatedAt = createdAt.Add(time.Duration(i) * time.Second)
+	}
+
+	scope := "webhook_deliveries:" + user.Username + ":" + db.WebhookDeliveryFailed
+	cursor := util.PageCursor{CreatedAt: deliveries[1].CreatedAt, ID: deliveries[1].ID}
+
+	testCases := []struct {
+		name          string
+		req           *pb.ListWebhookDeliveriesRequest
+		buildStubs    func(store *mockdb.MockStore)
+		buildContext  func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, resp *pb.ListWebhookDeliveriesResponse, err error)
+	}{
+		{
+			name: "FirstPage",
+			req: &pb.ListWebhookDeliveriesRequest{
+				Status:   db.WebhookDeliveryFailed,
+				PageSize: 2,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListWebhookDeliveriesAfterParams{
+					Username: user.Username,
+					Status:   sql.NullString{String: db.WebhookDeliveryFailed, Valid: true},
+					Limit:    3,
+				}
+				store.EXPECT().ListWebhookDeliveriesAfter(gomock.Any(), gomock.Eq(arg)).Times(1).Return(deliveries, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, resp *pb.ListWebhookDeliveriesResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, resp.GetDeliveries(), 2)
+				require.Equal(t, util.EncodePageToken(scope, cursor), resp.GetNextPageToken())
+			},
+		},
+		{
+			name: "LastPage",
+			req: &pb.ListWebhookDeliveriesRequest{
+				Status:    db.WebhookDeliveryFailed,
+				PageSize:  2,
+				PageToken: util.EncodePageToken(scope, cursor),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListWebhookDeliveriesAfterParams{
+					Username:       user.Username,
+					Status:         sql.NullString{String: db.WebhookDeliveryFailed, Valid: true},
+					AfterCreatedAt: cursor.CreatedAt,
+					AfterID:        cursor.ID,
+					Limit:          3,
+				}
+				store.EXPECT().ListWebhookDeliveriesAfter(gomock.Any(), gomock.Eq(arg)).Times(1).Return(deliveries[2:], nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, resp *pb.ListWebhookDeliveriesResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, resp.GetDeliveries(), 1)
+				require.Empty(t, resp.GetNextPageToken())
+			},
+		},
+		{
+			name: "TokenOfOtherFilter",
+			req: &pb.ListWebhookDeliveriesRequest{
+				PageToken: util.EncodePageToken(scope, cursor),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListWebhookDeliveriesAfter(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, resp *pb.ListWebhookDeliveriesResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+		{
+			name: "InvalidStatus",
+			req: &pb.ListWebhookDeliveriesRequest{
+				Status: "retrying",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListWebhookDeliveriesAfter(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, resp *pb.ListWebhookDeliveriesResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+		{
+			name: "NoAuthorization",
+			req:  &pb.ListWebhookDeliveriesRequest{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListWebhookDeliveriesAfter(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return context.Background()
+			},
+			checkResponse: func(t *testing.T, resp *pb.ListWebhookDeliveriesResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+			server := newTestServer(t, store, nil)
+
+			ctx := tc.buildContext(t, server.tokenMaker)
+			resp, err := server.ListWebhookDeliveries(ctx, tc.req)
+
+			tc.checkResponse(t, resp, err)
+		})
+	}
+}
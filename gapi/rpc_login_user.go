@@ -3,9 +3,14 @@ package gapi
 import (
 	"context"
 	"database/sql"
+	"errors"
 
+	"github.com/google/uuid"
 	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/lockout"
+	"github.com/xmeizh/simplebank/mfa"
 	"github.com/xmeizh/simplebank/pb"
+	"github.com/xmeizh/simplebank/token"
 	"github.com/xmeizh/simplebank/util"
 	"github.com/xmeizh/simplebank/val"
 	"google.golang.org/genproto/googleapis/rpc/errdetails"
@@ -20,32 +25,94 @@ func (server *Server) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (
 		return nil, invalidArgumentError(violations)
 	}
 
+	clientIP := server.extractMetadata(ctx).ClientIP
+	err := server.loginGuard.Check(ctx, req.GetUsername(), clientIP)
+	if err != nil {
+		if errors.Is(err, lockout.ErrLoginThrottled) {
+			return nil, status.Errorf(codes.ResourceExhausted, "%s", err)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to check login attempts: %s", err)
+	}
+
+	// unknown users and wrong passwords get the same error, so the response does not reveal which usernames exist
 	user, err := server.store.GetUser(ctx, req.GetUsername())
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, status.Errorf(codes.NotFound, "user not found: %s", err)
-		} else {
+		if err != sql.ErrNoRows {
 			return nil, status.Errorf(codes.Internal, "failed to find user: %s", err)
 		}
+		err = util.CheckPasswordOfNoUser(req.GetPassword())
+	} else {
+		err = util.CheckPassword(req.GetPassword(), user.HashedPassword)
+	}
+	if err != nil {
+		err = server.loginGuard.RecordFailure(ctx, req.GetUsername(), clientIP)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to record login attempt: %s", err)
+		}
+		return nil, status.Errorf(codes.Unauthenticated, "invalid username or password")
 	}
 
-	err = util.CheckPassword(req.GetPassword(), user.HashedPassword)
+	// the failed attempts of users with MFA are only cleared once they pass it
+	if user.IsMfaEnabled {
+		challengeToken, challengePayload, err := mfa.CreateChallenge(ctx, server.store, server.tokenMaker, user, server.config.MFAChallengeDuration)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to create MFA challenge: %s", err)
+		}
+
+		rsp := &pb.LoginUserResponse{
+			User:                  convertUser(user),
+			MfaChallengeToken:     challengeToken,
+			MfaChallengeExpiresAt: timestamppb.New(challengePayload.ExpiredAt),
+		}
+		return rsp, nil
+	}
+
+	err = server.loginGuard.RecordSuccess(ctx, user.Username)
 	if err != nil {
-		return nil, status.Errorf(codes.NotFound, "incorrect password: %s", err)
+		return nil, status.Errorf(codes.Internal, "failed to record login attempt: %s", err)
 	}
 
-	accessToken, accessPayload, err := server.tokenMaker.CreateToken(req.GetUsername(), user.Role, server.config.AccessTokenDuration)
+	session, err := server.createSession(ctx, user)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to create access token: %s", err)
+		return nil, err
 	}
 
+	rsp := &pb.LoginUserResponse{
+		SessionId:             session.ID.String(),
+		AccessToken:           session.AccessToken,
+		AccessTokenExpiresAt:  timestamppb.New(session.AccessPayload.ExpiredAt),
+		User:                  convertUser(user),
+		RefreshToken:          session.RefreshToken,
+		RefreshTokenExpiresAt: timestamppb.New(session.RefreshPayload.ExpiredAt),
+	}
+	return rsp, nil
+}
+
+// loginSession is a new session with the access token bound to it
+type loginSession struct {
+	db.Session
+	AccessToken    string
+	AccessPayload  *token.Payload
+	RefreshToken   string
+	RefreshPayload *token.Payload
+}
+
+// createSession starts a new session family for a user who passed all login checks
+func (server *Server) createSession(ctx context.Context, user db.User) (loginSession, error) {
 	refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(
 		user.Username,
 		user.Role,
+		uuid.Nil,
 		server.config.RefreshTokenDuration,
 	)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to create refresh token: %s", err)
+		return loginSession{}, status.Errorf(codes.Internal, "failed to create refresh token: %s", err)
+	}
+
+	// the session is identified by the refresh token, the access token is bound to it so it can be revoked
+	accessToken, accessPayload, err := server.tokenMaker.CreateToken(user.Username, user.Role, refreshPayload.ID, server.config.AccessTokenDuration)
+	if err != nil {
+		return loginSession{}, status.Errorf(codes.Internal, "failed to create access token: %s", err)
 	}
 
 	mtdt := server.extractMetadata(ctx)
@@ -57,21 +124,20 @@ func (server *Server) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (
 		ClientIp:    mtdt.ClientIP,
 		IsBlocked:   false,
 		ExpiresAt:   refreshPayload.ExpiredAt,
+		FamilyID:    refreshPayload.ID,
 	})
 
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to create session: %s", err)
+		return loginSession{}, status.Errorf(codes.Internal, "failed to create session: %s", err)
 	}
 
-	rsp := &pb.LoginUserResponse{
-		SessionId:             session.ID.String(),
-		AccessToken:           accessToken,
-		AccessTokenExpiresAt:  timestamppb.New(accessPayload.ExpiredAt),
-		User:                  convertUser(user),
-		RefreshToken:          refreshToken,
-		RefreshTokenExpiresAt: timestamppb.New(refreshPayload.ExpiredAt),
-	}
-	return rsp, nil
+	return loginSession{
+		Session:        session,
+		AccessToken:    accessToken,
+		AccessPayload:  accessPayload,
+		RefreshToken:   refreshToken,
+		RefreshPayload: refreshPayload,
+	}, nil
 }
 
 func validateLoginUserRequest(req *pb.LoginUserRequest) (violations []*errdetails.BadRequest_FieldViolation) {
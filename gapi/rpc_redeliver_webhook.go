@@ -0,0 +1,73 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/pb"
+	"github.com/xmeizh/simplebank/rbac"
+	"github.com/xmeizh/simplebank/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RedeliverWebhook queues a new delivery of the event of a failed one, which becomes superseded.
+// The new delivery is pending, so the webhook dispatcher enqueues it like any other.
+func (server *Server) RedeliverWebhook(ctx context.Context, req *pb.RedeliverWebhookRequest) (*pb.RedeliverWebhookResponse, error) {
+	authPayload, permissions, err := authFromContext(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	violations := validateRedeliverWebhookRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	delivery, err := server.store.GetWebhookDelivery(ctx, req.GetDeliveryId())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Errorf(codes.NotFound, "webhook delivery not found: %s", err)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get webhook delivery: %s", err)
+	}
+
+	endpoint, err := server.store.GetWebhookEndpoint(ctx, delivery.EndpointID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get webhook endpoint: %s", err)
+	}
+
+	if !permissions.AllowsAny(rbac.ManageWebhooks) && endpoint.Username != authPayload.Username {
+		return nil, status.Errorf(codes.PermissionDenied, "webhook delivery doesn't belong to the authenticated user")
+	}
+
+	if delivery.Status != db.WebhookDeliveryFailed {
+		return nil, status.Errorf(codes.FailedPrecondition, "webhook delivery is %s, only failed deliveries can be redelivered", delivery.Status)
+	}
+
+	result, err := server.store.RedeliverWebhookTx(ctx, db.RedeliverWebhookTxParams{
+		DeliveryID: delivery.ID,
+	})
+	if err != nil {
+		// another request redelivered it in the meantime
+		if errors.Is(err, db.ErrWebhookDeliveryNotFailed) {
+			return nil, status.Errorf(codes.FailedPrecondition, "webhook delivery has already been redelivered")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to redeliver webhook: %s", err)
+	}
+
+	rsp := &pb.RedeliverWebhookResponse{
+		Delivery: convertWebhookDelivery(result.Delivery),
+	}
+	return rsp, nil
+}
+
+func validateRedeliverWebhookRequest(req *pb.RedeliverWebhookRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateWebhookDeliveryID(req.GetDeliveryId()); err != nil {
+		violations = append(violations, fieldViolation("delivery_id", err))
+	}
+	return violations
+}
@@ -0,0 +1,219 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/xmeizh/simplebank/db/mock"
+	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/pb"
+	"github.com/xmeizh/simplebank/token"
+	"github.com/xmeizh/simplebank/util"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRedeliverWebhookAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	otherUser, _ := randomUser(t)
+	endpoint := randomWebhookEndpoint(user.Username)
+
+	failed := randomWebhookDelivery(endpoint.ID)
+	failed.Status = db.WebhookDeliveryFailed
+
+	succeeded := randomWebhookDelivery(endpoint.ID)
+	succeeded.Status = db.WebhookDeliverySucceeded
+
+	redelivery := randomWebhookDelivery(endpoint.ID)
+	redelivery.EventID = failed.EventID
+
+	superseded := failed
+	superseded.Status = db.WebhookDeliverySuperseded
+
+	redeliverArg := db.RedeliverWebhookTxParams{
+		DeliveryID: failed.ID,
+	}
+
+	testCases := []struct {
+		name          string
+		req           *pb.RedeliverWebhookRequest
+		buildStubs    func(store *mockdb.MockStore)
+		buildContext  func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, resp *pb.RedeliverWebhookResponse, err error)
+	}{
+		{
+			name: "OK",
+			req:  &pb.RedeliverWebhookRequest{DeliveryId: failed.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetWebhookDelivery(gomock.Any(), gomock.Eq(failed.ID)).Times(1).Return(failed, nil)
+				store.EXPECT().GetWebhookEndpoint(gomock.Any(), gomock.Eq(endpoint.ID)).Times(1).Return(endpoint, nil)
+				store.EXPECT().RedeliverWebhookTx(gomock.Any(), gomock.Eq(redeliverArg)).Times(1).
+					Return(db.RedeliverWebhookTxResult{Superseded: superseded, Delivery: redelivery}, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, resp *pb.RedeliverWebhookResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, redelivery.ID, resp.GetDelivery().Id)
+				require.Equal(t, failed.EventID, resp.GetDelivery().EventId)
+				require.Equal(t, db.WebhookDeliveryPending, resp.GetDelivery().Status)
+			},
+		},
+		{
+			name: "PermissionDenied",
+			req:  &pb.RedeliverWebhookRequest{DeliveryId: failed.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetWebhookDelivery(gomock.Any(), gomock.Eq(failed.ID)).Times(1).Return(failed, nil)
+				store.EXPECT().GetWebhookEndpoint(gomock.Any(), gomock.Eq(endpoint.ID)).Times(1).Return(endpoint, nil)
+				store.EXPECT().RedeliverWebhookTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, otherUser.Username, otherUser.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, resp *pb.RedeliverWebhookResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.PermissionDenied, st.Code())
+			},
+		},
+		{
+			name: "NotFailed",
+			req:  &pb.RedeliverWebhookRequest{DeliveryId: succeeded.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetWebhookDelivery(gomock.Any(), gomock.Eq(succeeded.ID)).Times(1).Return(succeeded, nil)
+				store.EXPECT().GetWebhookEndpoint(gomock.Any(), gomock.Eq(endpoint.ID)).Times(1).Return(endpoint, nil)
+				store.EXPECT().RedeliverWebhookTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, resp *pb.RedeliverWebhookResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.FailedPrecondition, st.Code())
+			},
+		},
+		{
+			name: "AlreadyRedelivered",
+			req:  &pb.RedeliverWebhookRequest{DeliveryId: superseded.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetWebhookDelivery(gomock.Any(), gomock.Eq(superseded.ID)).Times(1).Return(superseded, nil)
+				store.EXPECT().GetWebhookEndpoint(gomock.Any(), gomock.Eq(endpoint.ID)).Times(1).Return(endpoint, nil)
+				store.EXPECT().RedeliverWebhookTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, resp *pb.RedeliverWebhookResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.FailedPrecondition, st.Code())
+			},
+		},
+		{
+			name: "ConcurrentRedelivery",
+			req:  &pb.RedeliverWebhookRequest{DeliveryId: failed.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetWebhookDelivery(gomock.Any(), gomock.Eq(failed.ID)).Times(1).Return(failed, nil)
+				store.EXPECT().GetWebhookEndpoint(gomock.Any(), gomock.Eq(endpoint.ID)).Times(1).Return(endpoint, nil)
+				store.EXPECT().RedeliverWebhookTx(gomock.Any(), gomock.Eq(redeliverArg)).Times(1).
+					Return(db.RedeliverWebhookTxResult{}, db.ErrWebhookDeliveryNotFailed)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, resp *pb.RedeliverWebhookResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.FailedPrecondition, st.Code())
+			},
+		},
+		{
+			name: "NotFound",
+			req:  &pb.RedeliverWebhookRequest{DeliveryId: failed.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetWebhookDelivery(gomock.Any(), gomock.Any()).Times(1).Return(db.WebhookDelivery{}, sql.ErrNoRows)
+				store.EXPECT().RedeliverWebhookTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, resp *pb.RedeliverWebhookResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.NotFound, st.Code())
+			},
+		},
+		{
+			name: "InvalidID",
+			req:  &pb.RedeliverWebhookRequest{DeliveryId: 0},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetWebhookDelivery(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, resp *pb.RedeliverWebhookResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+		{
+			name: "NoAuthorization",
+			req:  &pb.RedeliverWebhookRequest{DeliveryId: failed.ID},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetWebhookDelivery(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return context.Background()
+			},
+			checkResponse: func(t *testing.T, resp *pb.RedeliverWebhookResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+			server := newTestServer(t, store, nil)
+
+			ctx := tc.buildContext(t, server.tokenMaker)
+			resp, err := server.RedeliverWebhook(ctx, tc.req)
+
+			tc.checkResponse(t, resp, err)
+		})
+	}
+}
+
+func randomWebhookDelivery(endpointID int64) db.WebhookDelivery {
+	return db.WebhookDelivery{
+		ID:         util.RandomInt(1, 1000),
+		EndpointID: endpointID,
+		EventID:    util.RandomInt(1, 1000),
+		Status:     db.WebhookDeliveryPending,
+		CreatedAt:  time.Now(),
+	}
+}
@@ -0,0 +1,60 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/hibiken/asynq"
+	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/pb"
+	"github.com/xmeizh/simplebank/val"
+	"github.com/xmeizh/simplebank/worker"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) RequestPasswordReset(ctx context.Context, req *pb.RequestPasswordResetRequest) (*pb.RequestPasswordResetResponse, error) {
+	violations := validateRequestPasswordResetRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	user, err := server.store.GetUserByEmail(ctx, req.GetEmail())
+	if err != nil {
+		// respond the same way for unknown addresses, so the API can't be used to find out who has an account
+		if err == sql.ErrNoRows {
+			return &pb.RequestPasswordResetResponse{}, nil
+		}
+		return nil, status.Errorf(codes.Internal, "failed to find user: %s", err)
+	}
+
+	// a user gets at most one email per cooldown, so the endpoint can't be used to flood their inbox
+	_, err = server.store.RequestPasswordResetTx(ctx, db.RequestPasswordResetTxParams{
+		Username:        user.Username,
+		RequestedBefore: time.Now().Add(-server.config.PasswordResetCooldown),
+		AfterRequest: func(q db.Querier) error {
+			payload := &worker.SendPasswordResetEmailPayload{
+				Username: user.Username,
+			}
+			opts := []asynq.Option{
+				asynq.MaxRetry(10),
+				asynq.Queue(worker.QueueCritical),
+			}
+			return worker.NewOutboxTaskDistributor(q).DistributeTaskSendPasswordResetEmail(ctx, payload, opts...)
+		},
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to send password reset email: %s", err)
+	}
+
+	return &pb.RequestPasswordResetResponse{}, nil
+}
+
+func validateRequestPasswordResetRequest(req *pb.RequestPasswordResetRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateEmail(req.GetEmail()); err != nil {
+		violations = append(violations, fieldViolation("email", err))
+	}
+	return violations
+}
@@ -0,0 +1,138 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/xmeizh/simplebank/db/mock"
+	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/pb"
+	"github.com/xmeizh/simplebank/worker"
+	mockwk "github.com/xmeizh/simplebank/worker/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRequestPasswordResetAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		req           *pb.RequestPasswordResetRequest
+		buildStubs    func(store *mockdb.MockStore, taskDistributor *mockwk.MockTaskDistributor)
+		checkResponse func(t *testing.T, resp *pb.RequestPasswordResetResponse, err error)
+	}{
+		{
+			name: "OK",
+			req:  &pb.RequestPasswordResetRequest{Email: user.Email},
+			buildStubs: func(store *mockdb.MockStore, taskDistributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).Times(1).Return(user, nil)
+
+				taskPayload, err := json.Marshal(&worker.SendPasswordResetEmailPayload{Username: user.Username})
+				require.NoError(t, err)
+				outboxArg := db.CreateOutboxMessageParams{
+					TaskType: worker.TaskSendPasswordResetEmail,
+					Payload:  taskPayload,
+					Queue:    sql.NullString{String: worker.QueueCritical, Valid: true},
+					MaxRetry: sql.NullInt32{Int32: 10, Valid: true},
+				}
+				store.EXPECT().CreateOutboxMessage(gomock.Any(), gomock.Eq(outboxArg)).Times(1).Return(db.Outbox{}, nil)
+				store.EXPECT().RequestPasswordResetTx(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(_ context.Context, arg db.RequestPasswordResetTxParams) (db.RequestPasswordResetTxResult, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.WithinDuration(t, time.Now().Add(-time.Minute), arg.RequestedBefore, time.Second)
+						return db.RequestPasswordResetTxResult{Requested: true}, arg.AfterRequest(store)
+					})
+				taskDistributor.EXPECT().DistributeTaskSendPasswordResetEmail(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, resp *pb.RequestPasswordResetResponse, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, resp)
+			},
+		},
+		{
+			name: "CoolingDown",
+			req:  &pb.RequestPasswordResetRequest{Email: user.Email},
+			buildStubs: func(store *mockdb.MockStore, taskDistributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).Times(1).Return(user, nil)
+				store.EXPECT().RequestPasswordResetTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.RequestPasswordResetTxResult{Requested: false}, nil)
+				store.EXPECT().CreateOutboxMessage(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, resp *pb.RequestPasswordResetResponse, err error) {
+				// the response doesn't tell whether an email was sent
+				require.NoError(t, err)
+				require.NotNil(t, resp)
+			},
+		},
+		{
+			name: "UnknownEmail",
+			req:  &pb.RequestPasswordResetRequest{Email: user.Email},
+			buildStubs: func(store *mockdb.MockStore, taskDistributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).Times(1).Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().RequestPasswordResetTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, resp *pb.RequestPasswordResetResponse, err error) {
+				// the response doesn't tell whether the address has an account
+				require.NoError(t, err)
+				require.NotNil(t, resp)
+			},
+		},
+		{
+			name: "TxError",
+			req:  &pb.RequestPasswordResetRequest{Email: user.Email},
+			buildStubs: func(store *mockdb.MockStore, taskDistributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Eq(user.Email)).Times(1).Return(user, nil)
+				store.EXPECT().RequestPasswordResetTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.RequestPasswordResetTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, resp *pb.RequestPasswordResetResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Internal, st.Code())
+			},
+		},
+		{
+			name: "InvalidEmail",
+			req:  &pb.RequestPasswordResetRequest{Email: "invalid-email"},
+			buildStubs: func(store *mockdb.MockStore, taskDistributor *mockwk.MockTaskDistributor) {
+				store.EXPECT().GetUserByEmail(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().RequestPasswordResetTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, resp *pb.RequestPasswordResetResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+
+			taskCtrl := gomock.NewController(t)
+			defer taskCtrl.Finish()
+
+			store := mockdb.NewMockStore(storeCtrl)
+			taskDistributor := mockwk.NewMockTaskDistributor(taskCtrl)
+
+			tc.buildStubs(store, taskDistributor)
+
+			server := newTestServer(t, store, taskDistributor)
+			resp, err := server.RequestPasswordReset(context.Background(), tc.req)
+
+			tc.checkResponse(t, resp, err)
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package gapi
+
+import (
+	"context"
+
+	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/pb"
+	"github.com/xmeizh/simplebank/rbac"
+	"github.com/xmeizh/simplebank/util"
+	"github.com/xmeizh/simplebank/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) RevokeRole(ctx context.Context, req *pb.RevokeRoleRequest) (*pb.RevokeRoleResponse, error) {
+	authPayload, permissions, err := authFromContext(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	if !permissions.AllowsAny(rbac.ManageRoles) {
+		return nil, status.Errorf(codes.PermissionDenied, "cannot manage the roles of other users")
+	}
+
+	violations := validateRevokeRoleRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	// otherwise the last admin could lock everyone out of role management
+	if req.GetUsername() == authPayload.Username && req.GetRole() == util.AdminRole {
+		return nil, status.Errorf(codes.FailedPrecondition, "cannot revoke your own admin role")
+	}
+
+	revoked, err := server.store.RevokeUserRole(ctx, db.RevokeUserRoleParams{
+		Username: req.GetUsername(),
+		Role:     req.GetRole(),
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to revoke role: %s", err)
+	}
+	if revoked == 0 {
+		return nil, status.Errorf(codes.NotFound, "user doesn't have the role")
+	}
+
+	roles, err := server.store.ListUserRoles(ctx, req.GetUsername())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list roles: %s", err)
+	}
+
+	rsp := &pb.RevokeRoleResponse{
+		Username: req.GetUsername(),
+		Roles:    roles,
+	}
+	return rsp, nil
+}
+
+func validateRevokeRoleRequest(req *pb.RevokeRoleRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateUsername(req.GetUsername()); err != nil {
+		violations = append(violations, fieldViolation("username", err))
+	}
+
+	if err := val.ValidateRole(req.GetRole()); err != nil {
+		violations = append(violations, fieldViolation("role", err))
+	}
+	return violations
+}
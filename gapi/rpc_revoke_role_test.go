@@ -0,0 +1,137 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/xmeizh/simplebank/db/mock"
+	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/pb"
+	"github.com/xmeizh/simplebank/token"
+	"github.com/xmeizh/simplebank/util"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRevokeRoleAPI(t *testing.T) {
+	admin, _ := randomUser(t)
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		req           *pb.RevokeRoleRequest
+		buildStubs    func(store *mockdb.MockStore)
+		buildContext  func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, resp *pb.RevokeRoleResponse, err error)
+	}{
+		{
+			name: "OK",
+			req:  &pb.RevokeRoleRequest{Username: user.Username, Role: util.BankerRole},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.RevokeUserRoleParams{
+					Username: user.Username,
+					Role:     util.BankerRole,
+				}
+				store.EXPECT().RevokeUserRole(gomock.Any(), gomock.Eq(arg)).Times(1).Return(int64(1), nil)
+				store.EXPECT().ListUserRoles(gomock.Any(), gomock.Eq(user.Username)).Times(1).
+					Return([]string{util.DepositorRole}, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, admin.Username, util.AdminRole, time.Minute)
+			},
+			checkResponse: func(t *testing.T, resp *pb.RevokeRoleResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, user.Username, resp.GetUsername())
+				require.Equal(t, []string{util.DepositorRole}, resp.GetRoles())
+			},
+		},
+		{
+			name: "NotGranted",
+			req:  &pb.RevokeRoleRequest{Username: user.Username, Role: util.BankerRole},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().RevokeUserRole(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+				store.EXPECT().ListUserRoles(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, admin.Username, util.AdminRole, time.Minute)
+			},
+			checkResponse: func(t *testing.T, resp *pb.RevokeRoleResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.NotFound, st.Code())
+			},
+		},
+		{
+			name: "OwnAdminRole",
+			req:  &pb.RevokeRoleRequest{Username: admin.Username, Role: util.AdminRole},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().RevokeUserRole(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, admin.Username, util.AdminRole, time.Minute)
+			},
+			checkResponse: func(t *testing.T, resp *pb.RevokeRoleResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.FailedPrecondition, st.Code())
+			},
+		},
+		{
+			name: "PermissionDenied",
+			req:  &pb.RevokeRoleRequest{Username: user.Username, Role: util.BankerRole},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().RevokeUserRole(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, util.DepositorRole, time.Minute)
+			},
+			checkResponse: func(t *testing.T, resp *pb.RevokeRoleResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.PermissionDenied, st.Code())
+			},
+		},
+		{
+			name: "InternalError",
+			req:  &pb.RevokeRoleRequest{Username: user.Username, Role: util.BankerRole},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().RevokeUserRole(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), sql.ErrConnDone)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, admin.Username, util.AdminRole, time.Minute)
+			},
+			checkResponse: func(t *testing.T, resp *pb.RevokeRoleResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Internal, st.Code())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+			server := newTestServer(t, store, nil)
+
+			ctx := tc.buildContext(t, server.tokenMaker)
+			resp, err := server.RevokeRole(ctx, tc.req)
+
+			tc.checkResponse(t, resp, err)
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package gapi
+
+import (
+	"database/sql"
+	"time"
+
+	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/pb"
+	"github.com/xmeizh/simplebank/rbac"
+	"github.com/xmeizh/simplebank/util"
+	"github.com/xmeizh/simplebank/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// accountEventsBatchSize is the number of events loaded from the db per query
+// while streaming account events
+const accountEventsBatchSize = 100
+
+// SubscribeAccountEvents streams the events recorded so far, then tails the events table
+// until the subscriber goes away. A subscriber resumes by passing the offset of the last event it received.
+func (server *Server) SubscribeAccountEvents(req *pb.SubscribeAccountEventsRequest, stream grpc.ServerStreamingServer[pb.SubscribeAccountEventsResponse]) error {
+	ctx := stream.Context()
+
+	authPayload, permissions, err := authFromContext(ctx)
+	if err != nil {
+		return unauthenticatedError(err)
+	}
+
+	violations := validateSubscribeAccountEventsRequest(req)
+	if violations != nil {
+		return invalidArgumentError(violations)
+	}
+
+	offset, err := util.DecodeEventOffset(req.GetAfterOffset())
+	if err != nil {
+		return invalidArgumentError([]*errdetails.BadRequest_FieldViolation{fieldViolation("after_offset", err)})
+	}
+
+	arg := db.ListEventsAfterParams{
+		Username:           authPayload.Username,
+		AfterTransactionID: offset.TransactionID,
+		AfterID:            offset.ID,
+		Limit:              accountEventsBatchSize,
+	}
+
+	if req.GetAccountId() != 0 {
+		account, err := server.store.GetAccount(ctx, req.GetAccountId())
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return status.Errorf(codes.NotFound, "account not found: %s", err)
+			}
+			return status.Errorf(codes.Internal, "failed to get account: %s", err)
+		}
+
+		if !permissions.AllowsAny(rbac.ReadAccounts) && account.Owner != authPayload.Username {
+			return status.Errorf(codes.PermissionDenied, "account doesn't belong to the authenticated user")
+		}
+
+		arg.Username = account.Owner
+		arg.AccountID = sql.NullInt64{
+			Int64: account.ID,
+			Valid: true,
+		}
+	}
+
+	for {
+		events, err := server.store.ListEventsAfter(ctx, arg)
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to list events: %s", err)
+		}
+
+		for _, event := range events {
+			accountEvent, err := convertAccountEvent(event)
+			if err != nil {
+				return status.Errorf(codes.Internal, "failed to convert event: %s", err)
+			}
+
+			err = stream.Send(&pb.SubscribeAccountEventsResponse{
+				Event: accountEvent,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(events) > 0 {
+			arg.AfterTransactionID = events[len(events)-1].TransactionID
+			arg.AfterID = events[len(events)-1].ID
+		}
+
+		// keep loading while there is a backlog, otherwise wait for new events
+		if len(events) == accountEventsBatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(server.config.EventPollInterval):
+		}
+	}
+}
+
+func validateSubscribeAccountEventsRequest(req *pb.SubscribeAccountEventsRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if req.GetAccountId() != 0 {
+		if err := val.ValidateAccountID(req.GetAccountId()); err != nil {
+			violations = append(violations, fieldViolation("account_id", err))
+		}
+	}
+	return violations
+}
@@ -0,0 +1,276 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/xmeizh/simplebank/db/mock"
+	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/pb"
+	"github.com/xmeizh/simplebank/token"
+	"github.com/xmeizh/simplebank/util"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSubscribeAccountEventsAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	transfer := db.Transfer{
+		ID:            util.RandomInt(1, 1000),
+		FromAccountID: account.ID,
+		ToAccountID:   util.RandomInt(1, 1000),
+		Amount:        util.RandomMoney(),
+	}
+
+	events := []db.Event{
+		randomEvent(t, 10, 1, db.EventUserCreated, user.Username, 0, db.UserCreatedEvent{
+			Username: user.Username,
+			FullName: user.FullName,
+			Email:    user.Email,
+		}),
+		randomEvent(t, 11, 2, db.EventAccountOpened, user.Username, account.ID, db.AccountOpenedEvent{
+			Account: account,
+		}),
+		randomEvent(t, 12, 3, db.EventTransferCompleted, user.Username, account.ID, db.TransferCompletedEvent{
+			Transfer: transfer,
+		}),
+	}
+	lastOffset := util.EventOffset{TransactionID: 12, ID: 3}
+
+	testCases := []struct {
+		name          string
+		req           *pb.SubscribeAccountEventsRequest
+		buildStubs    func(store *mockdb.MockStore, cancel context.CancelFunc)
+		buildContext  func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, sent []*pb.SubscribeAccountEventsResponse, err error)
+	}{
+		{
+			name: "OK",
+			req:  &pb.SubscribeAccountEventsRequest{},
+			buildStubs: func(store *mockdb.MockStore, cancel context.CancelFunc) {
+				arg := db.ListEventsAfterParams{
+					Username: user.Username,
+					Limit:    accountEventsBatchSize,
+				}
+				store.EXPECT().ListEventsAfter(gomock.Any(), gomock.Eq(arg)).Times(1).Return(events, nil)
+
+				// the stream keeps tailing after the last event until the subscriber goes away
+				arg.AfterTransactionID = lastOffset.TransactionID
+				arg.AfterID = lastOffset.ID
+				store.EXPECT().ListEventsAfter(gomock.Any(), gomock.Eq(arg)).Times(1).
+					DoAndReturn(func(_ context.Context, _ db.ListEventsAfterParams) ([]db.Event, error) {
+						cancel()
+						return []db.Event{}, nil
+					})
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, sent []*pb.SubscribeAccountEventsResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, sent, len(events))
+
+				require.Equal(t, user.Email, sent[0].GetEvent().GetUserCreated().GetEmail())
+				require.Zero(t, sent[0].GetEvent().GetAccountId())
+				require.Equal(t, account.Currency, sent[1].GetEvent().GetAccountOpened().GetAccount().GetCurrency())
+				require.Equal(t, transfer.Amount, sent[2].GetEvent().GetTransferCompleted().GetTransfer().GetAmount())
+				require.Equal(t, account.ID, sent[2].GetEvent().GetAccountId())
+				require.Equal(t, util.EncodeEventOffset(lastOffset), sent[2].GetEvent().GetOffset())
+			},
+		},
+		{
+			name: "ResumeFromOffset",
+			req: &pb.SubscribeAccountEventsRequest{
+				AfterOffset: util.EncodeEventOffset(lastOffset),
+				AccountId:   account.ID,
+			},
+			buildStubs: func(store *mockdb.MockStore, cancel context.CancelFunc) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+
+				arg := db.ListEventsAfterParams{
+					Username:           user.Username,
+					AccountID:          sql.NullInt64{Int64: account.ID, Valid: true},
+					AfterTransactionID: lastOffset.TransactionID,
+					AfterID:            lastOffset.ID,
+					Limit:              accountEventsBatchSize,
+				}
+				store.EXPECT().ListEventsAfter(gomock.Any(), gomock.Eq(arg)).Times(1).
+					DoAndReturn(func(_ context.Context, _ db.ListEventsAfterParams) ([]db.Event, error) {
+						cancel()
+						return []db.Event{}, nil
+					})
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, sent []*pb.SubscribeAccountEventsResponse, err error) {
+				require.NoError(t, err)
+				require.Empty(t, sent)
+			},
+		},
+		{
+			name: "BankerSubscribesToOtherAccount",
+			req: &pb.SubscribeAccountEventsRequest{
+				AccountId: account.ID,
+			},
+			buildStubs: func(store *mockdb.MockStore, cancel context.CancelFunc) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+
+				// the events are those streamed to the owner of the account
+				arg := db.ListEventsAfterParams{
+					Username:  account.Owner,
+					AccountID: sql.NullInt64{Int64: account.ID, Valid: true},
+					Limit:     accountEventsBatchSize,
+				}
+				store.EXPECT().ListEventsAfter(gomock.Any(), gomock.Eq(arg)).Times(1).
+					DoAndReturn(func(_ context.Context, _ db.ListEventsAfterParams) ([]db.Event, error) {
+						cancel()
+						return events[1:], nil
+					})
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, "banker", util.BankerRole, time.Minute)
+			},
+			checkResponse: func(t *testing.T, sent []*pb.SubscribeAccountEventsResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, sent, 2)
+			},
+		},
+		{
+			name: "PermissionDenied",
+			req: &pb.SubscribeAccountEventsRequest{
+				AccountId: account.ID,
+			},
+			buildStubs: func(store *mockdb.MockStore, cancel context.CancelFunc) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListEventsAfter(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, "other_user", util.DepositorRole, time.Minute)
+			},
+			checkResponse: func(t *testing.T, sent []*pb.SubscribeAccountEventsResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.PermissionDenied, st.Code())
+				require.Empty(t, sent)
+			},
+		},
+		{
+			name: "AccountNotFound",
+			req: &pb.SubscribeAccountEventsRequest{
+				AccountId: account.ID,
+			},
+			buildStubs: func(store *mockdb.MockStore, cancel context.CancelFunc) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().ListEventsAfter(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, sent []*pb.SubscribeAccountEventsResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.NotFound, st.Code())
+			},
+		},
+		{
+			name: "InvalidOffset",
+			req: &pb.SubscribeAccountEventsRequest{
+				AfterOffset: "invalid",
+			},
+			buildStubs: func(store *mockdb.MockStore, cancel context.CancelFunc) {
+				store.EXPECT().ListEventsAfter(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, sent []*pb.SubscribeAccountEventsResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+		{
+			name: "InternalError",
+			req:  &pb.SubscribeAccountEventsRequest{},
+			buildStubs: func(store *mockdb.MockStore, cancel context.CancelFunc) {
+				store.EXPECT().ListEventsAfter(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, sent []*pb.SubscribeAccountEventsResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Internal, st.Code())
+			},
+		},
+		{
+			name: "NoAuthorization",
+			req:  &pb.SubscribeAccountEventsRequest{},
+			buildStubs: func(store *mockdb.MockStore, cancel context.CancelFunc) {
+				store.EXPECT().ListEventsAfter(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return context.Background()
+			},
+			checkResponse: func(t *testing.T, sent []*pb.SubscribeAccountEventsResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+
+			store := mockdb.NewMockStore(storeCtrl)
+			server := newTestServer(t, store, nil)
+
+			ctx, cancel := context.WithCancel(tc.buildContext(t, server.tokenMaker))
+			defer cancel()
+			tc.buildStubs(store, cancel)
+
+			stream := &testServerStream[pb.SubscribeAccountEventsResponse]{
+				ctx: ctx,
+			}
+			err := server.SubscribeAccountEvents(tc.req, stream)
+
+			tc.checkResponse(t, stream.sent, err)
+		})
+	}
+}
+
+func randomEvent(t *testing.T, transactionID int64, id int64, eventType string, username string, accountID int64, payload any) db.Event {
+	data, err := json.Marshal(payload)
+	require.NoError(t, err)
+
+	return db.Event{
+		ID:            id,
+		TransactionID: transactionID,
+		Type:          eventType,
+		Username:      username,
+		AccountID: sql.NullInt64{
+			Int64: accountID,
+			Valid: accountID != 0,
+		},
+		Payload:   data,
+		CreatedAt: time.Now(),
+	}
+}
@@ -0,0 +1,48 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+
+	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/pb"
+	"github.com/xmeizh/simplebank/util"
+	"github.com/xmeizh/simplebank/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) UnlockAccount(ctx context.Context, req *pb.UnlockAccountRequest) (*pb.UnlockAccountResponse, error) {
+	violations := validateUnlockAccountRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	_, err := server.store.UnlockAccountTx(ctx, db.UnlockAccountTxParams{
+		AccountUnlockID: req.GetUnlockId(),
+		HashedCode:      util.HashSecretCode(req.GetSecretCode()),
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrAccountUnlockInvalid) {
+			return nil, status.Errorf(codes.PermissionDenied, "%s", err)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to unlock account: %s", err)
+	}
+
+	rsp := &pb.UnlockAccountResponse{
+		IsUnlocked: true,
+	}
+	return rsp, nil
+}
+
+func validateUnlockAccountRequest(req *pb.UnlockAccountRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateAccountUnlockID(req.GetUnlockId()); err != nil {
+		violations = append(violations, fieldViolation("unlock_id", err))
+	}
+
+	if err := val.ValidateSecretCode(req.GetSecretCode()); err != nil {
+		violations = append(violations, fieldViolation("secret_code", err))
+	}
+	return violations
+}
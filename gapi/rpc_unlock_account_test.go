@@ -0,0 +1,117 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/xmeizh/simplebank/db/mock"
+	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/pb"
+	"github.com/xmeizh/simplebank/util"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnlockAccountAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	secretCode, err := util.GenerateSecretCode()
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		req           *pb.UnlockAccountRequest
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, resp *pb.UnlockAccountResponse, err error)
+	}{
+		{
+			name: "OK",
+			req: &pb.UnlockAccountRequest{
+				UnlockId:   1,
+				SecretCode: secretCode,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.UnlockAccountTxParams{
+					AccountUnlockID: 1,
+					HashedCode:      util.HashSecretCode(secretCode),
+				}
+				store.EXPECT().UnlockAccountTx(gomock.Any(), gomock.Eq(arg)).Times(1).
+					Return(db.UnlockAccountTxResult{AccountUnlock: db.AccountUnlock{ID: 1, Username: user.Username}}, nil)
+			},
+			checkResponse: func(t *testing.T, resp *pb.UnlockAccountResponse, err error) {
+				require.NoError(t, err)
+				require.True(t, resp.GetIsUnlocked())
+			},
+		},
+		{
+			name: "InvalidUnlock",
+			req: &pb.UnlockAccountRequest{
+				UnlockId:   1,
+				SecretCode: secretCode,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UnlockAccountTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.UnlockAccountTxResult{}, db.ErrAccountUnlockInvalid)
+			},
+			checkResponse: func(t *testing.T, resp *pb.UnlockAccountResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.PermissionDenied, st.Code())
+			},
+		},
+		{
+			name: "InternalError",
+			req: &pb.UnlockAccountRequest{
+				UnlockId:   1,
+				SecretCode: secretCode,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UnlockAccountTx(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.UnlockAccountTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, resp *pb.UnlockAccountResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Internal, st.Code())
+			},
+		},
+		{
+			name: "InvalidArgument",
+			req: &pb.UnlockAccountRequest{
+				UnlockId:   0,
+				SecretCode: "short",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UnlockAccountTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, resp *pb.UnlockAccountResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+			server := newTestServer(t, store, nil)
+
+			resp, err := server.UnlockAccount(context.Background(), tc.req)
+
+			tc.checkResponse(t, resp, err)
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/pb"
+	"github.com/xmeizh/simplebank/rbac"
+	"github.com/xmeizh/simplebank/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) UpdateNotificationPreferences(ctx context.Context, req *pb.UpdateNotificationPreferencesRequest) (*pb.UpdateNotificationPreferencesResponse, error) {
+	authPayload, permissions, err := authFromContext(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	if !permissions.AllowsAny(rbac.UpdateUsers) && authPayload.Username != req.GetUsername() {
+		return nil, status.Errorf(codes.PermissionDenied, "cannot update other user's notification preferences")
+	}
+
+	violations := validateUpdateNotificationPreferencesRequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	preferences, err := server.store.UpsertNotificationPreferences(ctx, db.UpsertNotificationPreferencesParams{
+		Username: req.GetUsername(),
+		TransferDebitEmails: sql.NullBool{
+			Bool:  req.GetTransferDebitEmails(),
+			Valid: req.TransferDebitEmails != nil,
+		},
+		TransferCreditEmails: sql.NullBool{
+			Bool:  req.GetTransferCreditEmails(),
+			Valid: req.TransferCreditEmails != nil,
+		},
+		Locale: sql.NullString{
+			String: req.GetLocale(),
+			Valid:  req.Locale != nil,
+		},
+	})
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code.Name() == "foreign_key_violation" {
+			return nil, status.Errorf(codes.NotFound, "user not found: %s", err)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to update notification preferences: %s", err)
+	}
+
+	rsp := &pb.UpdateNotificationPreferencesResponse{
+		Preferences: convertNotificationPreferences(preferences),
+	}
+	return rsp, nil
+}
+
+func validateUpdateNotificationPreferencesRequest(req *pb.UpdateNotificationPreferencesRequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if err := val.ValidateUsername(req.GetUsername()); err != nil {
+		violations = append(violations, fieldViolation("username", err))
+	}
+
+	if req.Locale != nil {
+		if err := val.ValidateLocale(req.GetLocale()); err != nil {
+			violations = append(violations, fieldViolation("locale", err))
+		}
+	}
+	return violations
+}
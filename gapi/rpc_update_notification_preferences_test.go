@@ -0,0 +1,202 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/xmeizh/simplebank/db/mock"
+	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/pb"
+	"github.com/xmeizh/simplebank/token"
+	"github.com/xmeizh/simplebank/util"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUpdateNotificationPreferencesAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	otherUser, _ := randomUser(t)
+	turnedOff := false
+	locale := "fr-CA"
+	invalidLocale := "french"
+
+	preferences := db.NotificationPreference{
+		Username:             user.Username,
+		TransferDebitEmails:  true,
+		TransferCreditEmails: false,
+		UpdatedAt:            time.Now(),
+		Locale:               "en",
+	}
+
+	testCases := []struct {
+		name          string
+		req           *pb.UpdateNotificationPreferencesRequest
+		buildStubs    func(store *mockdb.MockStore)
+		buildContext  func(t *testing.T, tokenMaker token.Maker) context.Context
+		checkResponse func(t *testing.T, resp *pb.UpdateNotificationPreferencesResponse, err error)
+	}{
+		{
+			name: "OK",
+			req: &pb.UpdateNotificationPreferencesRequest{
+				Username:             user.Username,
+				TransferCreditEmails: &turnedOff,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.UpsertNotificationPreferencesParams{
+					Username:             user.Username,
+					TransferCreditEmails: sql.NullBool{Bool: false, Valid: true},
+				}
+				store.EXPECT().UpsertNotificationPreferences(gomock.Any(), gomock.Eq(arg)).Times(1).Return(preferences, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, resp *pb.UpdateNotificationPreferencesResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, user.Username, resp.GetPreferences().Username)
+				require.True(t, resp.GetPreferences().TransferDebitEmails)
+				require.False(t, resp.GetPreferences().TransferCreditEmails)
+				require.Equal(t, "en", resp.GetPreferences().Locale)
+			},
+		},
+		{
+			name: "Locale",
+			req: &pb.UpdateNotificationPreferencesRequest{
+				Username: user.Username,
+				Locale:   &locale,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.UpsertNotificationPreferencesParams{
+					Username: user.Username,
+					Locale:   sql.NullString{String: locale, Valid: true},
+				}
+				updated := preferences
+				updated.Locale = locale
+				store.EXPECT().UpsertNotificationPreferences(gomock.Any(), gomock.Eq(arg)).Times(1).Return(updated, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, resp *pb.UpdateNotificationPreferencesResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, locale, resp.GetPreferences().Locale)
+			},
+		},
+		{
+			name: "InvalidLocale",
+			req: &pb.UpdateNotificationPreferencesRequest{
+				Username: user.Username,
+				Locale:   &invalidLocale,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpsertNotificationPreferences(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, resp *pb.UpdateNotificationPreferencesResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+		{
+			name: "BankerCanUpdate",
+			req: &pb.UpdateNotificationPreferencesRequest{
+				Username:             user.Username,
+				TransferCreditEmails: &turnedOff,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpsertNotificationPreferences(gomock.Any(), gomock.Any()).Times(1).Return(preferences, nil)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, "banker", util.BankerRole, time.Minute)
+			},
+			checkResponse: func(t *testing.T, resp *pb.UpdateNotificationPreferencesResponse, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "PermissionDenied",
+			req: &pb.UpdateNotificationPreferencesRequest{
+				Username:             user.Username,
+				TransferCreditEmails: &turnedOff,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpsertNotificationPreferences(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, otherUser.Username, otherUser.Role, time.Minute)
+			},
+			checkResponse: func(t *testing.T, resp *pb.UpdateNotificationPreferencesResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.PermissionDenied, st.Code())
+			},
+		},
+		{
+			name: "UserNotFound",
+			req: &pb.UpdateNotificationPreferencesRequest{
+				Username:             "missing_user",
+				TransferCreditEmails: &turnedOff,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpsertNotificationPreferences(gomock.Any(), gomock.Any()).Times(1).
+					Return(db.NotificationPreference{}, &pq.Error{Code: "23503"})
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return newContextWithBearerToken(t, tokenMaker, "banker", util.BankerRole, time.Minute)
+			},
+			checkResponse: func(t *testing.T, resp *pb.UpdateNotificationPreferencesResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.NotFound, st.Code())
+			},
+		},
+		{
+			name: "NoAuthorization",
+			req: &pb.UpdateNotificationPreferencesRequest{
+				Username: user.Username,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpsertNotificationPreferences(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
+				return context.Background()
+			},
+			checkResponse: func(t *testing.T, resp *pb.UpdateNotificationPreferencesResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+
+			store := mockdb.NewMockStore(storeCtrl)
+
+			tc.buildStubs(store)
+			server := newTestServer(t, store, nil)
+
+			ctx := tc.buildContext(t, server.tokenMaker)
+			resp, err := server.UpdateNotificationPreferences(ctx, tc.req)
+
+			tc.checkResponse(t, resp, err)
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	db "github.com/xmeizh/simplebank/db/postgresql"
 	"github.com/xmeizh/simplebank/pb"
+	"github.com/xmeizh/simplebank/rbac"
 	"github.com/xmeizh/simplebank/util"
 	"github.com/xmeizh/simplebank/val"
 	"google.golang.org/genproto/googleapis/rpc/errdetails"
@@ -15,12 +16,12 @@ import (
 )
 
 func (server *Server) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.UpdateUserResponse, error) {
-	authPayload, err := server.authorizeUser(ctx, []string{util.BankerRole, util.DepositorRole})
+	authPayload, permissions, err := authFromContext(ctx)
 	if err != nil {
 		return nil, unauthenticatedError(err)
 	}
 
-	if authPayload.Role != util.BankerRole && authPayload.Username != req.GetUsername() {
+	if !permissions.AllowsAny(rbac.UpdateUsers) && authPayload.Username != req.GetUsername() {
 		return nil, status.Errorf(codes.PermissionDenied, "cannot update other user's info")
 	}
 
@@ -16,7 +16,6 @@ import (
 	"github.com/xmeizh/simplebank/token"
 	"github.com/xmeizh/simplebank/util"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
@@ -67,16 +66,7 @@ func TestUpdateUserAPI(t *testing.T) {
 				store.EXPECT().UpdateUser(gomock.Any(), EqUpdateUserParams(arg, newPassword)).Times(1).Return(expectedUser, nil)
 			},
 			buildContext: func(t *testing.T, tokenMaker token.Maker) context.Context {
-				token, _, err := tokenMaker.CreateToken(user.Username, user.Role, time.Minute)
-				require.NoError(t, err)
-				bearerToken := fmt.Sprintf("%s %s", authorizationTypeBearer, token)
-				md := metadata.MD{
-					authorizationHeader: []string{
-						bearerToken,
-					},
-				}
-
-				return metadata.NewIncomingContext(context.Background(), md)
+				return newContextWithBearerToken(t, tokenMaker, user.Username, user.Role, time.Minute)
 			},
 			checkResponse: func(t *testing.T, resp *pb.UpdateUserResponse, err error) {
 				require.NoError(t, err)
@@ -0,0 +1,88 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/xmeizh/simplebank/lockout"
+	"github.com/xmeizh/simplebank/mfa"
+	"github.com/xmeizh/simplebank/pb"
+	"github.com/xmeizh/simplebank/val"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (server *Server) VerifyMFA(ctx context.Context, req *pb.VerifyMFARequest) (*pb.VerifyMFAResponse, error) {
+	violations := validateVerifyMFARequest(req)
+	if violations != nil {
+		return nil, invalidArgumentError(violations)
+	}
+
+	challengePayload, err := server.tokenMaker.VerifyToken(req.GetMfaChallengeToken())
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid MFA challenge token: %s", err)
+	}
+
+	clientIP := server.extractMetadata(ctx).ClientIP
+	err = server.loginGuard.Check(ctx, challengePayload.Username, clientIP)
+	if err != nil {
+		if errors.Is(err, lockout.ErrLoginThrottled) {
+			return nil, status.Errorf(codes.ResourceExhausted, "%s", err)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to check login attempts: %s", err)
+	}
+
+	user, err := mfa.VerifyChallenge(ctx, server.store, challengePayload.ID, req.GetCode())
+	if err != nil {
+		// wrong codes count against the user like wrong passwords, so they can't be guessed with one challenge after another
+		if errors.Is(err, mfa.ErrInvalidCode) || errors.Is(err, mfa.ErrTooManyAttempts) {
+			recordErr := server.loginGuard.RecordFailure(ctx, challengePayload.Username, clientIP)
+			if recordErr != nil {
+				return nil, status.Errorf(codes.Internal, "failed to record login attempt: %s", recordErr)
+			}
+		}
+
+		switch {
+		case errors.Is(err, mfa.ErrInvalidChallenge), errors.Is(err, mfa.ErrInvalidCode):
+			return nil, status.Errorf(codes.Unauthenticated, "%s", err)
+		case errors.Is(err, mfa.ErrTooManyAttempts):
+			return nil, status.Errorf(codes.ResourceExhausted, "%s, login again", err)
+		default:
+			return nil, status.Errorf(codes.Internal, "failed to verify MFA code: %s", err)
+		}
+	}
+
+	err = server.loginGuard.RecordSuccess(ctx, user.Username)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to record login attempt: %s", err)
+	}
+
+	session, err := server.createSession(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	rsp := &pb.VerifyMFAResponse{
+		SessionId:             session.ID.String(),
+		AccessToken:           session.AccessToken,
+		AccessTokenExpiresAt:  timestamppb.New(session.AccessPayload.ExpiredAt),
+		User:                  convertUser(user),
+		RefreshToken:          session.RefreshToken,
+		RefreshTokenExpiresAt: timestamppb.New(session.RefreshPayload.ExpiredAt),
+	}
+	return rsp, nil
+}
+
+func validateVerifyMFARequest(req *pb.VerifyMFARequest) (violations []*errdetails.BadRequest_FieldViolation) {
+	if req.GetMfaChallengeToken() == "" {
+		violations = append(violations, fieldViolation("mfa_challenge_token", errors.New("is required")))
+	}
+
+	if err := val.ValidateMFACode(req.GetCode()); err != nil {
+		violations = append(violations, fieldViolation("code", err))
+	}
+
+	return violations
+}
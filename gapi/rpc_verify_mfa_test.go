@@ -0,0 +1,225 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/xmeizh/simplebank/db/mock"
+	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/lockout"
+	"github.com/xmeizh/simplebank/mfa"
+	"github.com/xmeizh/simplebank/pb"
+	"github.com/xmeizh/simplebank/token"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestVerifyMFAAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	secret, err := mfa.GenerateSecret()
+	require.NoError(t, err)
+	user.TotpSecret = secret
+	user.IsMfaEnabled = true
+
+	code, err := mfa.GenerateCode(secret, time.Now())
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		code          string
+		guard         loginGuard
+		buildToken    func(t *testing.T, tokenMaker token.Maker) (string, uuid.UUID)
+		buildStubs    func(store *mockdb.MockStore, challengeID uuid.UUID)
+		checkResponse func(t *testing.T, resp *pb.VerifyMFAResponse, err error)
+	}{
+		{
+			name:       "OK",
+			code:       code,
+			buildToken: newChallengeToken(user),
+			buildStubs: func(store *mockdb.MockStore, challengeID uuid.UUID) {
+				challenge := randomMfaChallenge(challengeID, user.Username)
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Eq(challengeID)).Times(1).Return(challenge, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().UseTotpStep(gomock.Any(), gomock.Any()).Times(1).Return(int64(1), nil)
+				store.EXPECT().UseMfaChallenge(gomock.Any(), gomock.Eq(challengeID)).Times(1).Return(challenge, nil)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(1).
+					DoAndReturn(func(ctx context.Context, arg db.CreateSessionParams) (db.Session, error) {
+						return db.Session{ID: arg.ID, Username: arg.Username, FamilyID: arg.FamilyID}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, resp *pb.VerifyMFAResponse, err error) {
+				require.NoError(t, err)
+				require.NotEmpty(t, resp.GetSessionId())
+				require.NotEmpty(t, resp.GetAccessToken())
+				require.NotEmpty(t, resp.GetRefreshToken())
+				require.Equal(t, user.Username, resp.GetUser().GetUsername())
+			},
+		},
+		{
+			name:       "InvalidCode",
+			code:       "000000",
+			buildToken: newChallengeToken(user),
+			buildStubs: func(store *mockdb.MockStore, challengeID uuid.UUID) {
+				challenge := randomMfaChallenge(challengeID, user.Username)
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Eq(challengeID)).Times(1).Return(challenge, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				challenge.FailedAttempts++
+				store.EXPECT().IncrementMfaChallengeFailedAttempts(gomock.Any(), gomock.Eq(challengeID)).Times(1).Return(challenge, nil)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, resp *pb.VerifyMFAResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+			},
+		},
+		{
+			name:       "RecordFailureError",
+			code:       "000000",
+			guard:      loginGuard{recordErr: sql.ErrConnDone},
+			buildToken: newChallengeToken(user),
+			buildStubs: func(store *mockdb.MockStore, challengeID uuid.UUID) {
+				challenge := randomMfaChallenge(challengeID, user.Username)
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Eq(challengeID)).Times(1).Return(challenge, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				challenge.FailedAttempts++
+				store.EXPECT().IncrementMfaChallengeFailedAttempts(gomock.Any(), gomock.Eq(challengeID)).Times(1).Return(challenge, nil)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, resp *pb.VerifyMFAResponse, err error) {
+				// the failed attempt has to be counted by the lockout guard
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Internal, st.Code())
+			},
+		},
+		{
+			name:       "Throttled",
+			code:       code,
+			guard:      loginGuard{checkErr: lockout.ErrLoginThrottled},
+			buildToken: newChallengeToken(user),
+			buildStubs: func(store *mockdb.MockStore, challengeID uuid.UUID) {
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, resp *pb.VerifyMFAResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.ResourceExhausted, st.Code())
+			},
+		},
+		{
+			name:       "TooManyAttempts",
+			code:       code,
+			buildToken: newChallengeToken(user),
+			buildStubs: func(store *mockdb.MockStore, challengeID uuid.UUID) {
+				challenge := randomMfaChallenge(challengeID, user.Username)
+				challenge.FailedAttempts = mfa.MaxFailedAttempts
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Eq(challengeID)).Times(1).Return(challenge, nil)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, resp *pb.VerifyMFAResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.ResourceExhausted, st.Code())
+			},
+		},
+		{
+			name:       "ChallengeUsed",
+			code:       code,
+			buildToken: newChallengeToken(user),
+			buildStubs: func(store *mockdb.MockStore, challengeID uuid.UUID) {
+				challenge := randomMfaChallenge(challengeID, user.Username)
+				challenge.IsUsed = true
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Eq(challengeID)).Times(1).Return(challenge, nil)
+				store.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, resp *pb.VerifyMFAResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+			},
+		},
+		{
+			name: "InvalidChallengeToken",
+			code: code,
+			buildToken: func(t *testing.T, tokenMaker token.Maker) (string, uuid.UUID) {
+				return "invalid", uuid.Nil
+			},
+			buildStubs: func(store *mockdb.MockStore, challengeID uuid.UUID) {
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, resp *pb.VerifyMFAResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.Unauthenticated, st.Code())
+			},
+		},
+		{
+			name:       "InvalidArgument",
+			code:       "123",
+			buildToken: newChallengeToken(user),
+			buildStubs: func(store *mockdb.MockStore, challengeID uuid.UUID) {
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, resp *pb.VerifyMFAResponse, err error) {
+				require.Error(t, err)
+				st, ok := status.FromError(err)
+				require.True(t, ok)
+				require.Equal(t, codes.InvalidArgument, st.Code())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			storeCtrl := gomock.NewController(t)
+			defer storeCtrl.Finish()
+
+			store := mockdb.NewMockStore(storeCtrl)
+			server := newTestServer(t, store, nil)
+			server.loginGuard = tc.guard
+
+			challengeToken, challengeID := tc.buildToken(t, server.tokenMaker)
+			tc.buildStubs(store, challengeID)
+
+			resp, err := server.VerifyMFA(context.Background(), &pb.VerifyMFARequest{
+				MfaChallengeToken: challengeToken,
+				Code:              tc.code,
+			})
+
+			tc.checkResponse(t, resp, err)
+		})
+	}
+}
+
+// newChallengeToken returns a function creating the challenge token LoginUser would return to user
+func newChallengeToken(user db.User) func(t *testing.T, tokenMaker token.Maker) (string, uuid.UUID) {
+	return func(t *testing.T, tokenMaker token.Maker) (string, uuid.UUID) {
+		challengeToken, payload, err := tokenMaker.CreateToken(user.Username, user.Role, uuid.Nil, time.Minute)
+		require.NoError(t, err)
+		return challengeToken, payload.ID
+	}
+}
+
+func randomMfaChallenge(id uuid.UUID, username string) db.MfaChallenge {
+	return db.MfaChallenge{
+		ID:        id,
+		Username:  username,
+		ExpiresAt: time.Now().Add(time.Minute),
+		CreatedAt: time.Now(),
+	}
+}
@@ -1,10 +1,12 @@
 package gapi
 
 import (
-	"fmt"
-
 	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/fx"
+	"github.com/xmeizh/simplebank/lockout"
 	"github.com/xmeizh/simplebank/pb"
+	"github.com/xmeizh/simplebank/rbac"
+	"github.com/xmeizh/simplebank/revocation"
 	"github.com/xmeizh/simplebank/token"
 	"github.com/xmeizh/simplebank/util"
 	"github.com/xmeizh/simplebank/worker"
@@ -13,25 +15,37 @@ import (
 // Server serves HTTP requests for our banking service
 type Server struct {
 	pb.UnimplementedSimpleBankServer
-	config          util.Config
-	store           db.Store
-	tokenMaker      token.Maker
-	taskDistributor worker.TaskDistributor
+	config            util.Config
+	store             db.Store
+	tokenMaker        token.Maker
+	taskDistributor   worker.TaskDistributor
+	rateProvider      fx.RateProvider
+	revocationChecker revocation.Checker
+	loginGuard        lockout.Guard
+	authorizer        rbac.Authorizer
 }
 
 // NewServer creates a new gRPC server
-func NewServer(config util.Config, store db.Store, taskDistributor worker.TaskDistributor) (*Server, error) {
-	tokenMaker, err := token.NewPasetoMaker(config.TokenSymmetricKey)
-	if err != nil {
-		return nil, fmt.Errorf("cannot create token maker: %w", err)
-	}
-
+func NewServer(
+	config util.Config,
+	store db.Store,
+	tokenMaker token.Maker,
+	taskDistributor worker.TaskDistributor,
+	rateProvider fx.RateProvider,
+	revocationChecker revocation.Checker,
+	loginGuard lockout.Guard,
+	authorizer rbac.Authorizer,
+) *Server {
 	server := &Server{
-		config:          config,
-		store:           store,
-		tokenMaker:      tokenMaker,
-		taskDistributor: taskDistributor,
+		config:            config,
+		store:             store,
+		tokenMaker:        tokenMaker,
+		taskDistributor:   taskDistributor,
+		rateProvider:      rateProvider,
+		revocationChecker: revocationChecker,
+		loginGuard:        loginGuard,
+		authorizer:        authorizer,
 	}
 
-	return server, nil
+	return server
 }
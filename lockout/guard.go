@@ -0,0 +1,176 @@
+package lockout
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/hibiken/asynq"
+	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/worker"
+)
+
+// freeAttempts is the number of failed attempts allowed before logins are delayed
+const freeAttempts = 3
+
+var ErrLoginThrottled = errors.New("too many failed login attempts")
+
+// Guard protects logins against password guessing
+type Guard interface {
+	// Check returns ErrLoginThrottled if the username or client IP is locked
+	// or has to wait before the next attempt
+	Check(ctx context.Context, username string, clientIP string) error
+	// RecordFailure counts a failed attempt against the username and client IP and locks them once they reach their limit
+	RecordFailure(ctx context.Context, username string, clientIP string) error
+	// RecordSuccess clears the failed attempts of the username
+	RecordSuccess(ctx context.Context, username string) error
+}
+
+type Config struct {
+	// MaxUserAttempts is the number of failed attempts after which a username is locked
+	MaxUserAttempts int32
+	// MaxIPAttempts is the number of failed attempts after which a client IP is locked
+	MaxIPAttempts int32
+	// BaseDelay is the wait after the first delayed attempt, it doubles with every further failure
+	BaseDelay time.Duration
+	// LockoutDuration is how long a lock lasts and how long failed attempts are remembered
+	LockoutDuration time.Duration
+}
+
+// StoreGuard keeps failed attempts in the database, so they are shared by all server instances.
+// When a username gets locked its user is sent an email to unlock it, through the outbox in the same transaction.
+type StoreGuard struct {
+	store  db.Store
+	config Config
+}
+
+// NewStoreGuard creates a new StoreGuard
+func NewStoreGuard(store db.Store, config Config) *StoreGuard {
+	return &StoreGuard{
+		store:  store,
+		config: config,
+	}
+}
+
+func (guard *StoreGuard) Check(ctx context.Context, username string, clientIP string) error {
+	for _, arg := range guard.keys(username, clientIP) {
+		failure, err := guard.store.GetLoginFailure(ctx, arg)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+			return fmt.Errorf("failed to get login failures: %w", err)
+		}
+
+		if wait := guard.wait(failure, time.Now()); wait > 0 {
+			return fmt.Errorf("%w, retry in %s", ErrLoginThrottled, wait.Round(time.Second))
+		}
+	}
+	return nil
+}
+
+func (guard *StoreGuard) RecordFailure(ctx context.Context, username string, clientIP string) error {
+	for _, key := range guard.keys(username, clientIP) {
+		maxAttempts := guard.config.MaxIPAttempts
+		if key.Scope == db.LoginFailureScopeUsername {
+			maxAttempts = guard.config.MaxUserAttempts
+		}
+
+		_, err := guard.store.RecordLoginFailureTx(ctx, db.RecordLoginFailureTxParams{
+			RecordLoginFailureParams: db.RecordLoginFailureParams{
+				Scope:       key.Scope,
+				Key:         key.Key,
+				ResetBefore: time.Now().Add(-guard.config.LockoutDuration),
+			},
+			MaxAttempts: maxAttempts,
+			LockedUntil: time.Now().Add(guard.config.LockoutDuration),
+			AfterLock: func(q db.Querier, failure db.LoginFailure) error {
+				if key.Scope != db.LoginFailureScopeUsername {
+					return nil
+				}
+				return sendUnlockAccountEmail(ctx, q, username)
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to record login failure: %w", err)
+		}
+	}
+	return nil
+}
+
+// sendUnlockAccountEmail writes the unlock account email of the user to the outbox
+func sendUnlockAccountEmail(ctx context.Context, q db.Querier, username string) error {
+	_, err := q.GetUser(ctx, username)
+	if err != nil {
+		// usernames that don't exist are locked too, so the lockout doesn't reveal which ones do
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	payload := &worker.SendUnlockAccountEmailPayload{
+		Username: username,
+	}
+	opts := []asynq.Option{
+		asynq.MaxRetry(10),
+		asynq.Queue(worker.QueueCritical),
+	}
+	err = worker.NewOutboxTaskDistributor(q).DistributeTaskSendUnlockAccountEmail(ctx, payload, opts...)
+	if err != nil {
+		return fmt.Errorf("failed to send unlock account email: %w", err)
+	}
+	return nil
+}
+
+func (guard *StoreGuard) RecordSuccess(ctx context.Context, username string) error {
+	// failures of the client IP are kept, otherwise logging into one account would reset the guesses against others
+	err := guard.store.DeleteLoginFailure(ctx, db.DeleteLoginFailureParams{
+		Scope: db.LoginFailureScopeUsername,
+		Key:   username,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to clear login failures: %w", err)
+	}
+	return nil
+}
+
+// keys returns the keys failed attempts are tracked under, the client IP is skipped if it's unknown
+func (guard *StoreGuard) keys(username string, clientIP string) []db.GetLoginFailureParams {
+	keys := []db.GetLoginFailureParams{
+		{Scope: db.LoginFailureScopeUsername, Key: username},
+	}
+
+	// the peer address includes the port, which changes with every connection
+	if host, _, err := net.SplitHostPort(clientIP); err == nil {
+		clientIP = host
+	}
+	if clientIP != "" {
+		keys = append(keys, db.GetLoginFailureParams{Scope: db.LoginFailureScopeClientIP, Key: clientIP})
+	}
+	return keys
+}
+
+// wait returns how long to wait until the next attempt is allowed
+func (guard *StoreGuard) wait(failure db.LoginFailure, now time.Time) time.Duration {
+	if failure.LockedUntil.Valid && now.Before(failure.LockedUntil.Time) {
+		return failure.LockedUntil.Time.Sub(now)
+	}
+
+	if now.Sub(failure.LastFailedAt) > guard.config.LockoutDuration || failure.FailedAttempts < freeAttempts {
+		return 0
+	}
+
+	delay := guard.config.BaseDelay
+	for i := int32(freeAttempts); i < failure.FailedAttempts && delay < guard.config.LockoutDuration; i++ {
+		delay *= 2
+	}
+	if delay > guard.config.LockoutDuration {
+		delay = guard.config.LockoutDuration
+	}
+
+	return failure.LastFailedAt.Add(delay).Sub(now)
+}
@@ -0,0 +1,294 @@
+package lockout
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/xmeizh/simplebank/db/mock"
+	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/util"
+	"github.com/xmeizh/simplebank/worker"
+)
+
+var testConfig = Config{
+	MaxUserAttempts: 5,
+	MaxIPAttempts:   20,
+	BaseDelay:       time.Second,
+	LockoutDuration: 15 * time.Minute,
+}
+
+func TestCheck(t *testing.T) {
+	username := util.RandomOwner()
+	userKey := db.GetLoginFailureParams{Scope: db.LoginFailureScopeUsername, Key: username}
+	ipKey := db.GetLoginFailureParams{Scope: db.LoginFailureScopeClientIP, Key: "10.0.0.1"}
+
+	testCases := []struct {
+		name       string
+		clientIP   string
+		buildStubs func(store *mockdb.MockStore)
+		checkError func(t *testing.T, err error)
+	}{
+		{
+			name:     "NoFailures",
+			clientIP: "10.0.0.1:51234",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetLoginFailure(gomock.Any(), gomock.Eq(userKey)).Times(1).Return(db.LoginFailure{}, sql.ErrNoRows)
+				store.EXPECT().GetLoginFailure(gomock.Any(), gomock.Eq(ipKey)).Times(1).Return(db.LoginFailure{}, sql.ErrNoRows)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:     "FreeAttempts",
+			clientIP: "",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetLoginFailure(gomock.Any(), gomock.Eq(userKey)).Times(1).
+					Return(db.LoginFailure{FailedAttempts: freeAttempts - 1, LastFailedAt: time.Now()}, nil)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:     "Delayed",
+			clientIP: "",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetLoginFailure(gomock.Any(), gomock.Eq(userKey)).Times(1).
+					Return(db.LoginFailure{FailedAttempts: freeAttempts + 1, LastFailedAt: time.Now()}, nil)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrLoginThrottled)
+			},
+		},
+		{
+			name:     "DelayPassed",
+			clientIP: "",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetLoginFailure(gomock.Any(), gomock.Eq(userKey)).Times(1).
+					Return(db.LoginFailure{FailedAttempts: freeAttempts + 1, LastFailedAt: time.Now().Add(-time.Minute)}, nil)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:     "IPLocked",
+			clientIP: "10.0.0.1",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetLoginFailure(gomock.Any(), gomock.Eq(userKey)).Times(1).Return(db.LoginFailure{}, sql.ErrNoRows)
+				store.EXPECT().GetLoginFailure(gomock.Any(), gomock.Eq(ipKey)).Times(1).
+					Return(db.LoginFailure{
+						FailedAttempts: testConfig.MaxIPAttempts,
+						LastFailedAt:   time.Now().Add(-time.Hour),
+						LockedUntil:    sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true},
+					}, nil)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrLoginThrottled)
+			},
+		},
+		{
+			name:     "LockExpired",
+			clientIP: "",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetLoginFailure(gomock.Any(), gomock.Eq(userKey)).Times(1).
+					Return(db.LoginFailure{
+						FailedAttempts: testConfig.MaxUserAttempts,
+						LastFailedAt:   time.Now().Add(-time.Hour),
+						LockedUntil:    sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true},
+					}, nil)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:     "InternalError",
+			clientIP: "",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetLoginFailure(gomock.Any(), gomock.Any()).Times(1).Return(db.LoginFailure{}, sql.ErrConnDone)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, sql.ErrConnDone)
+				require.NotErrorIs(t, err, ErrLoginThrottled)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			guard := NewStoreGuard(store, testConfig)
+			err := guard.Check(context.Background(), username, tc.clientIP)
+			tc.checkError(t, err)
+		})
+	}
+}
+
+func TestRecordFailure(t *testing.T) {
+	username := util.RandomOwner()
+
+	testCases := []struct {
+		name       string
+		buildStubs func(store *mockdb.MockStore)
+		checkError func(t *testing.T, err error)
+	}{
+		{
+			name: "BelowLimit",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().RecordLoginFailureTx(gomock.Any(), gomock.Any()).Times(2).
+					DoAndReturn(func(ctx context.Context, arg db.RecordLoginFailureTxParams) (db.RecordLoginFailureTxResult, error) {
+						if arg.Scope == db.LoginFailureScopeClientIP {
+							require.Equal(t, "10.0.0.1", arg.Key)
+							require.Equal(t, testConfig.MaxIPAttempts, arg.MaxAttempts)
+						} else {
+							require.Equal(t, username, arg.Key)
+							require.Equal(t, testConfig.MaxUserAttempts, arg.MaxAttempts)
+						}
+						require.WithinDuration(t, time.Now().Add(-testConfig.LockoutDuration), arg.ResetBefore, time.Second)
+						require.WithinDuration(t, time.Now().Add(testConfig.LockoutDuration), arg.LockedUntil, time.Second)
+						return db.RecordLoginFailureTxResult{}, nil
+					})
+				store.EXPECT().CreateOutboxMessage(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "LockUser",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().RecordLoginFailureTx(gomock.Any(), gomock.Any()).Times(2).
+					DoAndReturn(func(ctx context.Context, arg db.RecordLoginFailureTxParams) (db.RecordLoginFailureTxResult, error) {
+						failure := db.LoginFailure{Scope: arg.Scope, Key: arg.Key, FailedAttempts: arg.MaxAttempts}
+						if arg.Scope == db.LoginFailureScopeUsername {
+							err := arg.AfterLock(store, failure)
+							return db.RecordLoginFailureTxResult{Failure: failure, Locked: true}, err
+						}
+						return db.RecordLoginFailureTxResult{}, nil
+					})
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(username)).Times(1).Return(db.User{Username: username}, nil)
+				taskPayload, err := json.Marshal(&worker.SendUnlockAccountEmailPayload{Username: username})
+				require.NoError(t, err)
+				outboxArg := db.CreateOutboxMessageParams{
+					TaskType: worker.TaskSendUnlockAccountEmail,
+					Payload:  taskPayload,
+					Queue:    sql.NullString{String: worker.QueueCritical, Valid: true},
+					MaxRetry: sql.NullInt32{Int32: 10, Valid: true},
+				}
+				store.EXPECT().CreateOutboxMessage(gomock.Any(), gomock.Eq(outboxArg)).Times(1).Return(db.Outbox{}, nil)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "LockUnknownUser",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().RecordLoginFailureTx(gomock.Any(), gomock.Any()).Times(2).
+					DoAndReturn(func(ctx context.Context, arg db.RecordLoginFailureTxParams) (db.RecordLoginFailureTxResult, error) {
+						failure := db.LoginFailure{Scope: arg.Scope, Key: arg.Key, FailedAttempts: arg.MaxAttempts}
+						if arg.Scope == db.LoginFailureScopeUsername {
+							err := arg.AfterLock(store, failure)
+							return db.RecordLoginFailureTxResult{Failure: failure, Locked: true}, err
+						}
+						return db.RecordLoginFailureTxResult{}, nil
+					})
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(username)).Times(1).Return(db.User{}, sql.ErrNoRows)
+				store.EXPECT().CreateOutboxMessage(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "LockIP",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().RecordLoginFailureTx(gomock.Any(), gomock.Any()).Times(2).
+					DoAndReturn(func(ctx context.Context, arg db.RecordLoginFailureTxParams) (db.RecordLoginFailureTxResult, error) {
+						failure := db.LoginFailure{Scope: arg.Scope, Key: arg.Key, FailedAttempts: arg.MaxAttempts}
+						if arg.Scope == db.LoginFailureScopeClientIP {
+							err := arg.AfterLock(store, failure)
+							return db.RecordLoginFailureTxResult{Failure: failure, Locked: true}, err
+						}
+						return db.RecordLoginFailureTxResult{}, nil
+					})
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateOutboxMessage(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "InternalError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().RecordLoginFailureTx(gomock.Any(), gomock.Any()).Times(1).Return(db.RecordLoginFailureTxResult{}, sql.ErrConnDone)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, sql.ErrConnDone)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			guard := NewStoreGuard(store, testConfig)
+			err := guard.RecordFailure(context.Background(), username, "10.0.0.1:51234")
+			tc.checkError(t, err)
+		})
+	}
+}
+
+func TestRecordSuccess(t *testing.T) {
+	username := util.RandomOwner()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	arg := db.DeleteLoginFailureParams{
+		Scope: db.LoginFailureScopeUsername,
+		Key:   username,
+	}
+	store.EXPECT().DeleteLoginFailure(gomock.Any(), gomock.Eq(arg)).Times(1).Return(nil)
+
+	guard := NewStoreGuard(store, testConfig)
+	err := guard.RecordSuccess(context.Background(), username)
+	require.NoError(t, err)
+}
+
+func TestWait(t *testing.T) {
+	guard := NewStoreGuard(nil, testConfig)
+	now := time.Now()
+
+	wait := guard.wait(db.LoginFailure{FailedAttempts: freeAttempts, LastFailedAt: now}, now)
+	require.Equal(t, testConfig.BaseDelay, wait)
+
+	wait = guard.wait(db.LoginFailure{FailedAttempts: freeAttempts + 2, LastFailedAt: now}, now)
+	require.Equal(t, 4*testConfig.BaseDelay, wait)
+
+	wait = guard.wait(db.LoginFailure{FailedAttempts: 100, LastFailedAt: now}, now)
+	require.Equal(t, testConfig.LockoutDuration, wait)
+}
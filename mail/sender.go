@@ -12,10 +12,17 @@ const (
 	smtpServerAddress = "smtp.gmail.com:587"
 )
 
+// Body is the content of an email. When both parts are set the email is sent as
+// multipart/alternative, so clients that can't render HTML show the plain text.
+type Body struct {
+	Text string
+	HTML string
+}
+
 type EmailSender interface {
 	SendEmail(
 		subject string,
-		content string,
+		body Body,
 		to []string,
 		cc []string,
 		bcc []string,
@@ -37,14 +44,15 @@ func NewGmailSender(name string, fromEmailAddress string, fromEmailPassword stri
 	}
 }
 
-func (sender *GmailSender) SendEmail(subject string, content string, to []string, cc []string, bcc []string, attachFiles []string) error {
+func (sender *GmailSender) SendEmail(subject string, body Body, to []string, cc []string, bcc []string, attachFiles []string) error {
 	e := email.Email{
 		To:      to,
 		From:    fmt.Sprintf("%s <%s>", sender.name, sender.fromEmailAddress),
 		Cc:      cc,
 		Bcc:     bcc,
 		Subject: subject,
-		HTML:    []byte(content),
+		Text:    []byte(body.Text),
+		HTML:    []byte(body.HTML),
 	}
 
 	for _, f := range attachFiles {
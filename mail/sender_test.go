@@ -17,14 +17,17 @@ func TestSendEmailWithGmail(t *testing.T) {
 	sender := NewGmailSender(config.EmailSenderName, config.EmailSenderAddress, config.EmailSenderPassword)
 
 	subject := "Simple Bank Test Email"
-	content := `
+	body := Body{
+		Text: "Welcome to Simple Bank Service\n\nThis is a test message from Simple Bank\n",
+		HTML: `
 	<h1>Welcome to Simple Bank Service</h1>
 	<p>This is a test message from Simple Bank</p>
-	`
+	`,
+	}
 
 	to := []string{"dummy@gmail.com"}
 	attachments := []string{"../README.md"}
 
-	err = sender.SendEmail(subject, content, to, nil, nil, attachments)
+	err = sender.SendEmail(subject, body, to, nil, nil, attachments)
 	require.NoError(t, err)
 }
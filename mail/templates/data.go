@@ -0,0 +1,38 @@
+package templates
+
+import "time"
+
+// VerificationEmailData is the data of VerificationEmail
+type VerificationEmailData struct {
+	FullName  string
+	VerifyURL string
+}
+
+// PasswordResetEmailData is the data of PasswordResetEmail
+type PasswordResetEmailData struct {
+	FullName string
+	ResetURL string
+}
+
+// UnlockAccountEmailData is the data of UnlockAccountEmail
+type UnlockAccountEmailData struct {
+	FullName  string
+	UnlockURL string
+}
+
+// AccountStatementEmailData is the data of AccountStatementEmail
+type AccountStatementEmailData struct {
+	FullName  string
+	AccountID int64
+	Currency  string
+	Month     time.Time
+}
+
+// TransferEmailData is the data of TransferDebitEmail and TransferCreditEmail
+type TransferEmailData struct {
+	FullName string
+	// Amount is formatted with its currency, e.g. "12.34 USD"
+	Amount         string
+	AccountID      int64
+	OtherAccountID int64
+}
@@ -0,0 +1,25 @@
+package templates
+
+import (
+	"fmt"
+	"time"
+)
+
+// monthNames translates the months of the locales that don't write them in English
+var monthNames = map[string][12]string{
+	"fr": {"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+}
+
+// monthFormatter returns the formatMonth template func of the locale, which writes a month like "January 2006"
+func monthFormatter(locale string) func(time.Time) string {
+	names, ok := monthNames[locale]
+	if !ok {
+		return func(month time.Time) string {
+			return month.Format("January 2006")
+		}
+	}
+
+	return func(month time.Time) string {
+		return fmt.Sprintf("%s %d", names[month.Month()-1], month.Year())
+	}
+}
@@ -0,0 +1,173 @@
+package templates
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/xmeizh/simplebank/mail"
+)
+
+// The emails are rendered from the files embedded below, with one directory per locale.
+// In a locale directory, <name>.txt defines the "subject" and the plain text "content" of an email
+// and <name>.html its HTML "content", which are wrapped in the "layout" of layout.txt and layout.html.
+//
+//go:embed files
+var files embed.FS
+
+// DefaultLocale is used for the locales without templates, and for the emails a locale doesn't translate
+const DefaultLocale = "en"
+
+// Names of the emails
+const (
+	VerificationEmail     = "verification_email"
+	PasswordResetEmail    = "password_reset_email"
+	UnlockAccountEmail    = "unlock_account_email"
+	AccountStatementEmail = "account_statement_email"
+	TransferDebitEmail    = "transfer_debit_email"
+	TransferCreditEmail   = "transfer_credit_email"
+)
+
+// Email is a rendered email
+type Email struct {
+	Subject string
+	Body    mail.Body
+}
+
+type emailTemplates struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// Renderer renders the emails of every locale
+type Renderer struct {
+	// templates maps locales to the templates of their emails by name
+	templates map[string]map[string]emailTemplates
+}
+
+// New parses the embedded templates. Links to the public site in the templates start with baseURL.
+func New(baseURL string) (*Renderer, error) {
+	renderer := &Renderer{
+		templates: make(map[string]map[string]emailTemplates),
+	}
+
+	dirs, err := fs.ReadDir(files, "files")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read templates: %w", err)
+	}
+
+	for _, dir := range dirs {
+		locale := dir.Name()
+		emails, err := parseLocale(locale, baseURL)
+		if err != nil {
+			return nil, err
+		}
+		renderer.templates[locale] = emails
+	}
+
+	if _, ok := renderer.templates[DefaultLocale]; !ok {
+		return nil, fmt.Errorf("missing templates of default locale %s", DefaultLocale)
+	}
+	return renderer, nil
+}
+
+func parseLocale(locale string, baseURL string) (map[string]emailTemplates, error) {
+	dir := path.Join("files", locale)
+	funcs := map[string]any{
+		"baseURL":     func() string { return baseURL },
+		"locale":      func() string { return locale },
+		"formatMonth": monthFormatter(locale),
+	}
+
+	textFiles, err := fs.Glob(files, path.Join(dir, "*.txt"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s templates: %w", locale, err)
+	}
+
+	emails := make(map[string]emailTemplates)
+	for _, textFile := range textFiles {
+		name := strings.TrimSuffix(path.Base(textFile), ".txt")
+		if name == "layout" {
+			continue
+		}
+
+		text, err := texttemplate.New(name).Funcs(funcs).
+			ParseFS(files, path.Join(dir, "layout.txt"), textFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s text template %s: %w", locale, name, err)
+		}
+
+		html, err := htmltemplate.New(name).Funcs(funcs).
+			ParseFS(files, path.Join(dir, "layout.html"), path.Join(dir, name+".html"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s HTML template %s: %w", locale, name, err)
+		}
+
+		emails[name] = emailTemplates{
+			text: text,
+			html: html,
+		}
+	}
+	return emails, nil
+}
+
+// Locales returns the locales with templates
+func (renderer *Renderer) Locales() []string {
+	locales := make([]string, 0, len(renderer.templates))
+	for locale := range renderer.templates {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Render renders the email in the given locale, like "fr" or "fr-CA".
+// A region falls back to its language, and a locale without templates to DefaultLocale.
+func (renderer *Renderer) Render(name string, locale string, data any) (Email, error) {
+	templates, ok := renderer.templates[renderer.resolveLocale(locale)][name]
+	if !ok {
+		templates, ok = renderer.templates[DefaultLocale][name]
+		if !ok {
+			return Email{}, fmt.Errorf("unknown email %s", name)
+		}
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := templates.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Email{}, fmt.Errorf("failed to render subject of %s: %w", name, err)
+	}
+	if err := templates.text.ExecuteTemplate(&text, "layout", data); err != nil {
+		return Email{}, fmt.Errorf("failed to render text of %s: %w", name, err)
+	}
+	if err := templates.html.ExecuteTemplate(&html, "layout", data); err != nil {
+		return Email{}, fmt.Errorf("failed to render HTML of %s: %w", name, err)
+	}
+
+	email := Email{
+		Subject: strings.TrimSpace(subject.String()),
+		Body: mail.Body{
+			Text: text.String(),
+			HTML: html.String(),
+		},
+	}
+	return email, nil
+}
+
+func (renderer *Renderer) resolveLocale(locale string) string {
+	locale = strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+	if _, ok := renderer.templates[locale]; ok {
+		return locale
+	}
+
+	language, _, _ := strings.Cut(locale, "-")
+	if _, ok := renderer.templates[language]; ok {
+		return language
+	}
+	return DefaultLocale
+}
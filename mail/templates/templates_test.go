@@ -0,0 +1,160 @@
+package templates
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xmeizh/simplebank/util"
+)
+
+const testBaseURL = "https://bank.example.com"
+
+func TestRender(t *testing.T) {
+	renderer, err := New(testBaseURL)
+	require.NoError(t, err)
+	require.Equal(t, []string{"en", "fr"}, renderer.Locales())
+
+	fullName := util.RandomOwner()
+	testCases := []struct {
+		name     string
+		data     any
+		contains []string
+	}{
+		{
+			name: VerificationEmail,
+			data: VerificationEmailData{
+				FullName:  fullName,
+				VerifyURL: testBaseURL + "/v1/verify_email?email_id=1&secret_code=abc",
+			},
+			contains: []string{"/v1/verify_email?email_id=1"},
+		},
+		{
+			name: PasswordResetEmail,
+			data: PasswordResetEmailData{
+				FullName: fullName,
+				ResetURL: "http://localhost:3000/reset_password?token=abc",
+			},
+			contains: []string{"/reset_password?token=abc"},
+		},
+		{
+			name: UnlockAccountEmail,
+			data: UnlockAccountEmailData{
+				FullName:  fullName,
+				UnlockURL: testBaseURL + "/v1/unlock_account?token=abc",
+			},
+			contains: []string{"/v1/unlock_account?token=abc"},
+		},
+		{
+			name: AccountStatementEmail,
+			data: AccountStatementEmailData{
+				FullName:  fullName,
+				AccountID: 42,
+				Currency:  util.EUR,
+				Month:     time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+			},
+			contains: []string{"42", util.EUR, "2024"},
+		},
+		{
+			name: TransferDebitEmail,
+			data: TransferEmailData{
+				FullName:       fullName,
+				Amount:         "12.50 EUR",
+				AccountID:      42,
+				OtherAccountID: 43,
+			},
+			contains: []string{"12.50 EUR", "42", "43"},
+		},
+		{
+			name: TransferCreditEmail,
+			data: TransferEmailData{
+				FullName:       fullName,
+				Amount:         "12.50 EUR",
+				AccountID:      43,
+				OtherAccountID: 42,
+			},
+			contains: []string{"12.50 EUR", "42", "43"},
+		},
+	}
+
+	for _, locale := range renderer.Locales() {
+		for i := range testCases {
+			tc := testCases[i]
+
+			t.Run(locale+"/"+tc.name, func(t *testing.T) {
+				email, err := renderer.Render(tc.name, locale, tc.data)
+				require.NoError(t, err)
+				require.NotEmpty(t, email.Subject)
+				require.NotContains(t, email.Subject, "\n")
+
+				for _, content := range []string{email.Body.Text, email.Body.HTML} {
+					require.Contains(t, content, fullName)
+					require.Contains(t, content, testBaseURL)
+					for _, s := range tc.contains {
+						require.Contains(t, content, s)
+					}
+				}
+				require.Contains(t, email.Body.HTML, `<html lang="`+locale+`">`)
+				require.NotContains(t, email.Body.Text, "<p>")
+			})
+		}
+	}
+}
+
+func TestRenderLocales(t *testing.T) {
+	renderer, err := New(testBaseURL)
+	require.NoError(t, err)
+
+	month := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	data := AccountStatementEmailData{
+		FullName:  util.RandomOwner(),
+		AccountID: 1,
+		Currency:  util.USD,
+		Month:     month,
+	}
+
+	testCases := []struct {
+		locale  string
+		subject string
+	}{
+		{locale: "en", subject: "Your Simple Bank statement for March 2024"},
+		{locale: "fr", subject: "Votre relevé Simple Bank de mars 2024"},
+		{locale: "fr-CA", subject: "Votre relevé Simple Bank de mars 2024"},
+		{locale: "fr_ca", subject: "Votre relevé Simple Bank de mars 2024"},
+		{locale: "de", subject: "Your Simple Bank statement for March 2024"},
+		{locale: "", subject: "Your Simple Bank statement for March 2024"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.locale, func(t *testing.T) {
+			email, err := renderer.Render(AccountStatementEmail, tc.locale, data)
+			require.NoError(t, err)
+			require.Equal(t, tc.subject, email.Subject)
+		})
+	}
+}
+
+func TestRenderEscapesHTML(t *testing.T) {
+	renderer, err := New(testBaseURL)
+	require.NoError(t, err)
+
+	data := VerificationEmailData{
+		FullName:  "<script>alert(1)</script>",
+		VerifyURL: testBaseURL + "/v1/verify_email",
+	}
+
+	email, err := renderer.Render(VerificationEmail, DefaultLocale, data)
+	require.NoError(t, err)
+	require.NotContains(t, email.Body.HTML, "<script>")
+	require.Contains(t, email.Body.Text, "<script>")
+}
+
+func TestRenderUnknownEmail(t *testing.T) {
+	renderer, err := New(testBaseURL)
+	require.NoError(t, err)
+
+	_, err = renderer.Render("unknown_email", DefaultLocale, nil)
+	require.Error(t, err)
+}
@@ -25,12 +25,19 @@ import (
 	"github.com/xmeizh/simplebank/api"
 	db "github.com/xmeizh/simplebank/db/postgresql"
 	_ "github.com/xmeizh/simplebank/doc/statik"
+	"github.com/xmeizh/simplebank/fx"
 	"github.com/xmeizh/simplebank/gapi"
+	"github.com/xmeizh/simplebank/lockout"
 	"github.com/xmeizh/simplebank/mail"
+	"github.com/xmeizh/simplebank/mail/templates"
 	"github.com/xmeizh/simplebank/pb"
+	"github.com/xmeizh/simplebank/rbac"
+	"github.com/xmeizh/simplebank/revocation"
+	"github.com/xmeizh/simplebank/token"
 	"github.com/xmeizh/simplebank/util"
 	"github.com/xmeizh/simplebank/worker"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/reflection"
 	"google.golang.org/protobuf/encoding/protojson"
 )
@@ -60,6 +67,7 @@ func main() {
 	runDBMigration(config.MigrationURL, config.DBSource)
 
 	store := db.NewStore(conn)
+	loadCurrencies(store)
 
 	// start redis worker
 	redisOpt := asynq.RedisClientOpt{
@@ -68,13 +76,41 @@ func main() {
 
 	taskDistributor := worker.NewRedisTaskDistributor(redisOpt)
 
+	rateProvider := fx.NewCachedRateProvider(fx.NewFileRateProvider(config.FXRatesFile), config.FXRateCacheDuration)
+	tokenMaker, err := token.NewMaker(token.Config{
+		Format:           config.TokenFormat,
+		Algorithm:        config.TokenAlgorithm,
+		Issuer:           config.TokenIssuer,
+		Audience:         config.TokenAudience,
+		SymmetricKey:     config.TokenSymmetricKey,
+		KeyID:            config.TokenKeyID,
+		PrivateKeyFile:   config.TokenPrivateKeyFile,
+		VerificationKeys: config.TokenVerificationKeys,
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("cannot create token maker")
+	}
+
+	revocationChecker := revocation.NewStoreChecker(store, config.RevocationCacheSize, config.RevocationCacheDuration)
+	loginGuard := lockout.NewStoreGuard(store, lockout.Config{
+		MaxUserAttempts: config.LoginMaxUserAttempts,
+		MaxIPAttempts:   config.LoginMaxIPAttempts,
+		BaseDelay:       config.LoginBaseDelay,
+		LockoutDuration: config.LoginLockoutDuration,
+	})
+	authorizer := rbac.NewStoreAuthorizer(store)
+
 	ctx, stop := signal.NotifyContext(context.Background(), interruptSignals...)
 	defer stop()
 	waitGroup, ctx := errgroup.WithContext(ctx)
 
 	runTaskProcessor(ctx, waitGroup, config, redisOpt, store)
-	runGrpcServer(ctx, waitGroup, config, store, taskDistributor)
-	runGatewayServer(ctx, waitGroup, config, store, taskDistributor)
+	runOutboxRelay(ctx, waitGroup, config, store, taskDistributor)
+	runWebhookDispatcher(ctx, waitGroup, config, store, taskDistributor)
+	runScheduler(ctx, waitGroup, config, store, taskDistributor)
+	runStatementScheduler(ctx, waitGroup, store, taskDistributor)
+	runGrpcServer(ctx, waitGroup, config, store, tokenMaker, taskDistributor, rateProvider, revocationChecker, loginGuard, authorizer)
+	runGatewayServer(ctx, waitGroup, config, store, tokenMaker, taskDistributor, rateProvider, revocationChecker, loginGuard, authorizer)
 
 	err = waitGroup.Wait()
 	if err != nil {
@@ -93,6 +129,26 @@ func runDBMigration(migrationURL string, dbSource string) {
 	log.Info().Msg("db migrated successfully")
 }
 
+// loadCurrencies fills the currency registry used by validators and response formatting
+func loadCurrencies(store db.Store) {
+	currencies, err := store.ListCurrencies(context.Background())
+	if err != nil {
+		gapi.LogFatal("cannot load currencies", err)
+	}
+
+	registry := make([]util.Currency, len(currencies))
+	for i, currency := range currencies {
+		registry[i] = util.Currency{
+			Code:       currency.Code,
+			MinorUnits: currency.MinorUnits,
+			Enabled:    currency.Enabled,
+		}
+	}
+
+	util.LoadCurrencies(registry)
+	log.Info().Msgf("loaded %d currencies", len(registry))
+}
+
 func runTaskProcessor(
 	ctx context.Context,
 	waitGroup *errgroup.Group,
@@ -101,9 +157,14 @@ func runTaskProcessor(
 	store db.Store,
 ) {
 	mailSender := mail.NewGmailSender(config.EmailSenderName, config.EmailSenderAddress, config.EmailSenderPassword)
-	taskProcessor := worker.NewRedisTaskProcessor(redisOpt, store, mailSender)
+	renderer, err := templates.New(config.PublicBaseURL)
+	if err != nil {
+		gapi.LogFatal("cannot parse email templates", err)
+	}
+
+	taskProcessor := worker.NewRedisTaskProcessor(config, redisOpt, store, mailSender, renderer)
 	log.Info().Msg("start task processor")
-	err := taskProcessor.Start()
+	err = taskProcessor.Start()
 	if err != nil {
 		gapi.LogFatal("failed to start task processor", err)
 	}
@@ -117,13 +178,84 @@ func runTaskProcessor(
 	})
 }
 
-func runGinServer(config util.Config, store db.Store) {
-	server, err := api.NewServer(config, store)
-	if err != nil {
-		gapi.LogFatal("cannot create server", err)
-	}
+func runOutboxRelay(
+	ctx context.Context,
+	waitGroup *errgroup.Group,
+	config util.Config,
+	store db.Store,
+	taskDistributor worker.TaskDistributor,
+) {
+	relay := worker.NewOutboxRelay(store, taskDistributor, config.OutboxRelayInterval)
+
+	waitGroup.Go(func() error {
+		log.Info().Msg("start outbox relay")
+		err := relay.Start(ctx)
+		log.Info().Msg("outbox relay is stopped")
+		return err
+	})
+}
+
+func runWebhookDispatcher(
+	ctx context.Context,
+	waitGroup *errgroup.Group,
+	config util.Config,
+	store db.Store,
+	taskDistributor worker.TaskDistributor,
+) {
+	dispatcher := worker.NewWebhookDispatcher(store, taskDistributor, config.WebhookDispatchInterval)
+
+	waitGroup.Go(func() error {
+		log.Info().Msg("start webhook dispatcher")
+		err := dispatcher.Start(ctx)
+		log.Info().Msg("webhook dispatcher is stopped")
+		return err
+	})
+}
+
+func runScheduler(
+	ctx context.Context,
+	waitGroup *errgroup.Group,
+	config util.Config,
+	store db.Store,
+	taskDistributor worker.TaskDistributor,
+) {
+	scheduler := worker.NewScheduledTransferScheduler(store, taskDistributor, config.SchedulerInterval)
 
-	err = server.Start(config.HTTPServerAddress)
+	waitGroup.Go(func() error {
+		log.Info().Msg("start scheduled transfer scheduler")
+		err := scheduler.Start(ctx)
+		log.Info().Msg("scheduled transfer scheduler is stopped")
+		return err
+	})
+}
+
+func runStatementScheduler(
+	ctx context.Context,
+	waitGroup *errgroup.Group,
+	store db.Store,
+	taskDistributor worker.TaskDistributor,
+) {
+	scheduler := worker.NewMonthlyStatementScheduler(store, taskDistributor)
+
+	waitGroup.Go(func() error {
+		log.Info().Msg("start monthly statement scheduler")
+		err := scheduler.Start(ctx)
+		log.Info().Msg("monthly statement scheduler is stopped")
+		return err
+	})
+}
+
+func runGinServer(
+	config util.Config,
+	store db.Store,
+	tokenMaker token.Maker,
+	rateProvider fx.RateProvider,
+	revocationChecker revocation.Checker,
+	loginGuard lockout.Guard,
+) {
+	server := api.NewServer(config, store, tokenMaker, rateProvider, revocationChecker, loginGuard)
+
+	err := server.Start(config.HTTPServerAddress)
 	if err != nil {
 		gapi.LogFatal("cannot start server", err)
 	}
@@ -134,15 +266,19 @@ func runGrpcServer(
 	waitGroup *errgroup.Group,
 	config util.Config,
 	store db.Store,
+	tokenMaker token.Maker,
 	taskDistributor worker.TaskDistributor,
+	rateProvider fx.RateProvider,
+	revocationChecker revocation.Checker,
+	loginGuard lockout.Guard,
+	authorizer rbac.Authorizer,
 ) {
-	server, err := gapi.NewServer(config, store, taskDistributor)
-	if err != nil {
-		gapi.LogFatal("cannot create server:", err)
-	}
+	server := gapi.NewServer(config, store, tokenMaker, taskDistributor, rateProvider, revocationChecker, loginGuard, authorizer)
 
-	grpcLogger := grpc.UnaryInterceptor(gapi.GrpcLogger)
-	grpcServer := grpc.NewServer(grpcLogger)
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(gapi.GrpcLogger, server.UnaryAuthInterceptor),
+		grpc.StreamInterceptor(server.StreamAuthInterceptor),
+	)
 	pb.RegisterSimpleBankServer(grpcServer, server)
 	reflection.Register(grpcServer)
 
@@ -175,12 +311,14 @@ func runGatewayServer(
 	waitGroup *errgroup.Group,
 	config util.Config,
 	store db.Store,
+	tokenMaker token.Maker,
 	taskDistributor worker.TaskDistributor,
+	rateProvider fx.RateProvider,
+	revocationChecker revocation.Checker,
+	loginGuard lockout.Guard,
+	authorizer rbac.Authorizer,
 ) {
-	server, err := gapi.NewServer(config, store, taskDistributor)
-	if err != nil {
-		gapi.LogFatal("cannot create server:", err)
-	}
+	server := gapi.NewServer(config, store, tokenMaker, taskDistributor, rateProvider, revocationChecker, loginGuard, authorizer)
 
 	jsonOption := runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
 		MarshalOptions: protojson.MarshalOptions{
@@ -191,15 +329,18 @@ func runGatewayServer(
 		},
 	})
 
+	// Requests are proxied to the gRPC server rather than handled in-process,
+	// so they pass through its auth interceptors and streaming RPCs work as well.
 	gwmux := runtime.NewServeMux(jsonOption)
-
-	err = pb.RegisterSimpleBankHandlerServer(ctx, gwmux, server)
+	dialOpts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	err := pb.RegisterSimpleBankHandlerFromEndpoint(ctx, gwmux, config.GRPCServerAddress, dialOpts)
 	if err != nil {
-		gapi.LogFatal("cannot register handler server", err)
+		gapi.LogFatal("cannot register handler from endpoint", err)
 	}
 
 	mux := http.NewServeMux()
 	mux.Handle("/", gwmux)
+	mux.Handle(gapi.JWKSPath, server.JWKSHandler())
 
 	statikFS, err := fs.New()
 	if err != nil {
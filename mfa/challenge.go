@@ -0,0 +1,140 @@
+package mfa
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/token"
+	"github.com/xmeizh/simplebank/util"
+)
+
+// MaxFailedAttempts is the number of wrong codes after which a challenge is rejected,
+// the user has to log in with their password again to get a new one
+const MaxFailedAttempts = 5
+
+var (
+	ErrInvalidChallenge = errors.New("invalid MFA challenge")
+	ErrTooManyAttempts  = errors.New("too many failed MFA attempts")
+	ErrInvalidCode      = errors.New("invalid MFA code")
+)
+
+// CreateChallenge creates the challenge a user with MFA enabled has to answer after their password was checked.
+// The challenge token is a token of the user that isn't bound to a session, so it can't be used as an access token.
+func CreateChallenge(ctx context.Context, store db.Querier, tokenMaker token.Maker, user db.User, duration time.Duration) (string, *token.Payload, error) {
+	challengeToken, payload, err := tokenMaker.CreateToken(user.Username, user.Role, uuid.Nil, duration)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create challenge token: %w", err)
+	}
+
+	_, err = store.CreateMfaChallenge(ctx, db.CreateMfaChallengeParams{
+		ID:        payload.ID,
+		Username:  user.Username,
+		ExpiresAt: payload.ExpiredAt,
+	})
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create challenge: %w", err)
+	}
+
+	return challengeToken, payload, nil
+}
+
+// VerifyChallenge checks code against the TOTP secret and the unused recovery codes of the user the challenge was created for.
+// A recovery code can only be used once. The challenge is used up once a code is accepted.
+func VerifyChallenge(ctx context.Context, store db.Querier, challengeID uuid.UUID, code string) (db.User, error) {
+	challenge, err := store.GetMfaChallenge(ctx, challengeID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.User{}, fmt.Errorf("%w: challenge not found", ErrInvalidChallenge)
+		}
+		return db.User{}, fmt.Errorf("failed to get challenge: %w", err)
+	}
+
+	if challenge.IsUsed {
+		return db.User{}, fmt.Errorf("%w: challenge already used", ErrInvalidChallenge)
+	}
+
+	if time.Now().After(challenge.ExpiresAt) {
+		return db.User{}, fmt.Errorf("%w: challenge expired", ErrInvalidChallenge)
+	}
+
+	if challenge.FailedAttempts >= MaxFailedAttempts {
+		return db.User{}, ErrTooManyAttempts
+	}
+
+	user, err := store.GetUser(ctx, challenge.Username)
+	if err != nil {
+		return db.User{}, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if !user.IsMfaEnabled {
+		return db.User{}, fmt.Errorf("%w: MFA is not enabled", ErrInvalidChallenge)
+	}
+
+	ok, err := UseCode(ctx, store, user, code, time.Now())
+	if err != nil {
+		return db.User{}, err
+	}
+	if !ok {
+		ok, err = useRecoveryCode(ctx, store, user.Username, code)
+		if err != nil {
+			return db.User{}, err
+		}
+	}
+
+	if !ok {
+		challenge, err = store.IncrementMfaChallengeFailedAttempts(ctx, challengeID)
+		if err != nil {
+			return db.User{}, fmt.Errorf("failed to record failed attempt: %w", err)
+		}
+		if challenge.FailedAttempts >= MaxFailedAttempts {
+			return db.User{}, ErrTooManyAttempts
+		}
+		return db.User{}, ErrInvalidCode
+	}
+
+	_, err = store.UseMfaChallenge(ctx, challengeID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.User{}, fmt.Errorf("%w: challenge already used", ErrInvalidChallenge)
+		}
+		return db.User{}, fmt.Errorf("failed to use challenge: %w", err)
+	}
+
+	return user, nil
+}
+
+// useRecoveryCode marks the unused recovery code matching code as used
+func useRecoveryCode(ctx context.Context, store db.Querier, username string, code string) (bool, error) {
+	code = NormalizeRecoveryCode(code)
+	if len(code) != recoveryCodeLength {
+		return false, nil
+	}
+
+	recoveryCodes, err := store.ListUnusedRecoveryCodes(ctx, username)
+	if err != nil {
+		return false, fmt.Errorf("failed to list recovery codes: %w", err)
+	}
+
+	for _, recoveryCode := range recoveryCodes {
+		if util.CheckPassword(code, recoveryCode.HashedCode) != nil {
+			continue
+		}
+
+		_, err = store.UseRecoveryCode(ctx, recoveryCode.ID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				// used by a concurrent request
+				return false, nil
+			}
+			return false, fmt.Errorf("failed to use recovery code: %w", err)
+		}
+		return true, nil
+	}
+
+	return false, nil
+}
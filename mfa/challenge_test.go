@@ -0,0 +1,296 @@
+package mfa
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/xmeizh/simplebank/db/mock"
+	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/token"
+	"github.com/xmeizh/simplebank/util"
+)
+
+func TestCreateChallenge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	tokenMaker, err := token.NewPasetoMaker(util.RandomString(32))
+	require.NoError(t, err)
+
+	user := db.User{Username: util.RandomOwner(), Role: util.DepositorRole}
+
+	var arg db.CreateMfaChallengeParams
+	store.EXPECT().CreateMfaChallenge(gomock.Any(), gomock.Any()).Times(1).
+		DoAndReturn(func(ctx context.Context, params db.CreateMfaChallengeParams) (db.MfaChallenge, error) {
+			arg = params
+			return db.MfaChallenge{ID: params.ID, Username: params.Username, ExpiresAt: params.ExpiresAt}, nil
+		})
+
+	challengeToken, payload, err := CreateChallenge(context.Background(), store, tokenMaker, user, time.Minute)
+	require.NoError(t, err)
+
+	verified, err := tokenMaker.VerifyToken(challengeToken)
+	require.NoError(t, err)
+	require.Equal(t, payload.ID, verified.ID)
+	require.Equal(t, uuid.Nil, verified.SessionID)
+
+	require.Equal(t, payload.ID, arg.ID)
+	require.Equal(t, user.Username, arg.Username)
+	require.Equal(t, payload.ExpiredAt, arg.ExpiresAt)
+}
+
+func TestVerifyChallenge(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+
+	user := db.User{
+		Username:     util.RandomOwner(),
+		Role:         util.DepositorRole,
+		TotpSecret:   secret,
+		IsMfaEnabled: true,
+	}
+
+	now := time.Now()
+	code, err := GenerateCode(secret, now)
+	require.NoError(t, err)
+	useStep := db.UseTotpStepParams{
+		Step:     now.Unix() / int64(period.Seconds()),
+		Username: user.Username,
+	}
+
+	recoveryCode := "abcde-23456"
+	hashedRecoveryCode, err := util.HashPassword(NormalizeRecoveryCode(recoveryCode))
+	require.NoError(t, err)
+	recoveryCodes := []db.RecoveryCode{
+		{ID: 1, Username: user.Username, HashedCode: hashedRecoveryCode},
+	}
+
+	challenge := db.MfaChallenge{
+		ID:        uuid.New(),
+		Username:  user.Username,
+		ExpiresAt: time.Now().Add(time.Minute),
+	}
+
+	testCases := []struct {
+		name       string
+		code       string
+		buildStubs func(store *mockdb.MockStore)
+		checkError func(t *testing.T, err error)
+	}{
+		{
+			name: "TOTPCode",
+			code: code,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Eq(challenge.ID)).Times(1).Return(challenge, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().UseTotpStep(gomock.Any(), gomock.Eq(useStep)).Times(1).Return(int64(1), nil)
+				store.EXPECT().ListUnusedRecoveryCodes(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().UseMfaChallenge(gomock.Any(), gomock.Eq(challenge.ID)).Times(1).Return(challenge, nil)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "TOTPCodeReplayed",
+			code: code,
+			buildStubs: func(store *mockdb.MockStore) {
+				replayed := user
+				replayed.TotpLastStep = useStep.Step
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Eq(challenge.ID)).Times(1).Return(challenge, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(replayed, nil)
+				store.EXPECT().UseTotpStep(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().IncrementMfaChallengeFailedAttempts(gomock.Any(), gomock.Eq(challenge.ID)).Times(1).
+					Return(db.MfaChallenge{FailedAttempts: 1}, nil)
+				store.EXPECT().UseMfaChallenge(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrInvalidCode)
+			},
+		},
+		{
+			name: "TOTPCodeUsedConcurrently",
+			code: code,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Eq(challenge.ID)).Times(1).Return(challenge, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().UseTotpStep(gomock.Any(), gomock.Eq(useStep)).Times(1).Return(int64(0), nil)
+				store.EXPECT().IncrementMfaChallengeFailedAttempts(gomock.Any(), gomock.Eq(challenge.ID)).Times(1).
+					Return(db.MfaChallenge{FailedAttempts: 1}, nil)
+				store.EXPECT().UseMfaChallenge(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrInvalidCode)
+			},
+		},
+		{
+			name: "RecoveryCode",
+			code: "ABCDE 23456",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Eq(challenge.ID)).Times(1).Return(challenge, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().ListUnusedRecoveryCodes(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(recoveryCodes, nil)
+				store.EXPECT().UseRecoveryCode(gomock.Any(), gomock.Eq(int64(1))).Times(1).Return(recoveryCodes[0], nil)
+				store.EXPECT().UseMfaChallenge(gomock.Any(), gomock.Eq(challenge.ID)).Times(1).Return(challenge, nil)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "RecoveryCodeUsedConcurrently",
+			code: recoveryCode,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Eq(challenge.ID)).Times(1).Return(challenge, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().ListUnusedRecoveryCodes(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(recoveryCodes, nil)
+				store.EXPECT().UseRecoveryCode(gomock.Any(), gomock.Eq(int64(1))).Times(1).Return(db.RecoveryCode{}, sql.ErrNoRows)
+				store.EXPECT().IncrementMfaChallengeFailedAttempts(gomock.Any(), gomock.Eq(challenge.ID)).Times(1).
+					Return(db.MfaChallenge{FailedAttempts: 1}, nil)
+				store.EXPECT().UseMfaChallenge(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrInvalidCode)
+			},
+		},
+		{
+			name: "InvalidCode",
+			code: "000000",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Eq(challenge.ID)).Times(1).Return(challenge, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				// a 6 digit code can't be a recovery code
+				store.EXPECT().ListUnusedRecoveryCodes(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().IncrementMfaChallengeFailedAttempts(gomock.Any(), gomock.Eq(challenge.ID)).Times(1).
+					Return(db.MfaChallenge{FailedAttempts: 1}, nil)
+				store.EXPECT().UseMfaChallenge(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrInvalidCode)
+			},
+		},
+		{
+			name: "LastAttempt",
+			code: "000000",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Eq(challenge.ID)).Times(1).Return(challenge, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().IncrementMfaChallengeFailedAttempts(gomock.Any(), gomock.Eq(challenge.ID)).Times(1).
+					Return(db.MfaChallenge{FailedAttempts: MaxFailedAttempts}, nil)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrTooManyAttempts)
+			},
+		},
+		{
+			name: "TooManyAttempts",
+			code: code,
+			buildStubs: func(store *mockdb.MockStore) {
+				exhausted := challenge
+				exhausted.FailedAttempts = MaxFailedAttempts
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Eq(challenge.ID)).Times(1).Return(exhausted, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrTooManyAttempts)
+			},
+		},
+		{
+			name: "ChallengeNotFound",
+			code: code,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Eq(challenge.ID)).Times(1).Return(db.MfaChallenge{}, sql.ErrNoRows)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrInvalidChallenge)
+			},
+		},
+		{
+			name: "ChallengeUsed",
+			code: code,
+			buildStubs: func(store *mockdb.MockStore) {
+				used := challenge
+				used.IsUsed = true
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Eq(challenge.ID)).Times(1).Return(used, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrInvalidChallenge)
+			},
+		},
+		{
+			name: "ChallengeExpired",
+			code: code,
+			buildStubs: func(store *mockdb.MockStore) {
+				expired := challenge
+				expired.ExpiresAt = time.Now().Add(-time.Second)
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Eq(challenge.ID)).Times(1).Return(expired, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrInvalidChallenge)
+			},
+		},
+		{
+			name: "ChallengeUsedConcurrently",
+			code: code,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Eq(challenge.ID)).Times(1).Return(challenge, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().UseTotpStep(gomock.Any(), gomock.Eq(useStep)).Times(1).Return(int64(1), nil)
+				store.EXPECT().UseMfaChallenge(gomock.Any(), gomock.Eq(challenge.ID)).Times(1).Return(db.MfaChallenge{}, sql.ErrNoRows)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrInvalidChallenge)
+			},
+		},
+		{
+			name: "MFADisabled",
+			code: code,
+			buildStubs: func(store *mockdb.MockStore) {
+				disabled := user
+				disabled.IsMfaEnabled = false
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Eq(challenge.ID)).Times(1).Return(challenge, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(disabled, nil)
+				store.EXPECT().UseMfaChallenge(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrInvalidChallenge)
+			},
+		},
+		{
+			name: "InternalError",
+			code: code,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetMfaChallenge(gomock.Any(), gomock.Eq(challenge.ID)).Times(1).Return(db.MfaChallenge{}, sql.ErrConnDone)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, sql.ErrConnDone)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			verified, err := VerifyChallenge(context.Background(), store, challenge.ID, tc.code)
+			tc.checkError(t, err)
+			if err == nil {
+				require.Equal(t, user.Username, verified.Username)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package mfa
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// RecoveryCodeCount is the number of recovery codes generated when MFA is enabled
+const RecoveryCodeCount = 10
+
+// the alphabet leaves out characters that are easily confused, like 0 and o or 1 and l
+const (
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+	recoveryCodeLength   = 10
+)
+
+// GenerateRecoveryCodes generates n random recovery codes formatted as xxxxx-xxxxx
+func GenerateRecoveryCodes(n int) ([]string, error) {
+	max := big.NewInt(int64(len(recoveryCodeAlphabet)))
+
+	codes := make([]string, n)
+	for i := range codes {
+		var sb strings.Builder
+		for j := 0; j < recoveryCodeLength; j++ {
+			if j == recoveryCodeLength/2 {
+				sb.WriteByte('-')
+			}
+			k, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				return nil, fmt.Errorf("failed to generate recovery code: %w", err)
+			}
+			sb.WriteByte(recoveryCodeAlphabet[k.Int64()])
+		}
+		codes[i] = sb.String()
+	}
+	return codes, nil
+}
+
+// NormalizeRecoveryCode drops the separator, spaces and case, so a code is hashed
+// and compared the same way however the user typed it
+func NormalizeRecoveryCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(code))
+}
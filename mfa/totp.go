@@ -0,0 +1,122 @@
+package mfa
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	db "github.com/xmeizh/simplebank/db/postgresql"
+)
+
+// TOTP parameters from RFC 6238, they are the defaults every authenticator app supports
+const (
+	secretSize = 20
+	digits     = 6
+	period     = 30 * time.Second
+	// skew is the number of periods a code is still accepted before or after the current one
+	skew = 1
+)
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret generates a random base32 encoded TOTP secret
+func GenerateSecret() (string, error) {
+	secret := make([]byte, secretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+	return encoding.EncodeToString(secret), nil
+}
+
+// KeyURI returns the otpauth URI authenticator apps scan to add the secret of accountName
+func KeyURI(issuer string, accountName string, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(digits))
+	query.Set("period", fmt.Sprint(int(period.Seconds())))
+
+	uri := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + issuer + ":" + accountName,
+		RawQuery: query.Encode(),
+	}
+	return uri.String()
+}
+
+// GenerateCode returns the TOTP code of the secret at time t
+func GenerateCode(secret string, t time.Time) (string, error) {
+	key, err := encoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", fmt.Errorf("invalid secret: %w", err)
+	}
+	return hotp(key, uint64(t.Unix()/int64(period.Seconds()))), nil
+}
+
+// ValidateCode reports whether code is the TOTP code of the secret at time t,
+// allowing for the clock of the authenticator to be one period off.
+// It also returns the time step the code belongs to.
+func ValidateCode(secret string, code string, t time.Time) (int64, bool) {
+	key, err := encoding.DecodeString(strings.ToUpper(secret))
+	if err != nil || len(code) != digits {
+		return 0, false
+	}
+
+	counter := t.Unix() / int64(period.Seconds())
+	for i := int64(-skew); i <= skew; i++ {
+		expected := hotp(key, uint64(counter+i))
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return counter + i, true
+		}
+	}
+	return 0, false
+}
+
+// UseCode accepts a TOTP code of the user at time t only once.
+// Codes of the time step of the last accepted code or an earlier one are rejected,
+// so a code can't be replayed while it's still valid.
+func UseCode(ctx context.Context, store db.Querier, user db.User, code string, t time.Time) (bool, error) {
+	step, ok := ValidateCode(user.TotpSecret, code, t)
+	if !ok || step <= user.TotpLastStep {
+		return false, nil
+	}
+
+	rows, err := store.UseTotpStep(ctx, db.UseTotpStepParams{
+		Step:     step,
+		Username: user.Username,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to use TOTP code: %w", err)
+	}
+	// no row is updated if a concurrent request accepted a code of this step or a later one
+	return rows == 1, nil
+}
+
+// hotp computes the HOTP value of RFC 4226 for the counter
+func hotp(key []byte, counter uint64) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%mod)
+}
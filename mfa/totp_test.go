@@ -0,0 +1,85 @@
+package mfa
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret is the base32 encoding of the SHA1 key of the RFC 6238 test vectors
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestGenerateCode(t *testing.T) {
+	// the last 6 digits of the 8 digit codes of RFC 6238 appendix B
+	testCases := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, tc := range testCases {
+		code, err := GenerateCode(rfcSecret, time.Unix(tc.unix, 0))
+		require.NoError(t, err)
+		require.Equal(t, tc.code, code)
+	}
+}
+
+func TestValidateCode(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+
+	now := time.Now()
+	code, err := GenerateCode(secret, now)
+	require.NoError(t, err)
+
+	step := now.Unix() / int64(period.Seconds())
+	for _, at := range []time.Time{now, now.Add(period), now.Add(-period)} {
+		codeStep, ok := ValidateCode(secret, code, at)
+		require.True(t, ok)
+		require.Equal(t, step, codeStep)
+	}
+
+	_, ok := ValidateCode(secret, code, now.Add(3*period))
+	require.False(t, ok)
+	_, ok = ValidateCode(secret, code, now.Add(-3*period))
+	require.False(t, ok)
+	_, ok = ValidateCode(secret, "", now)
+	require.False(t, ok)
+	_, ok = ValidateCode("not base32!", code, now)
+	require.False(t, ok)
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret1, err := GenerateSecret()
+	require.NoError(t, err)
+	secret2, err := GenerateSecret()
+	require.NoError(t, err)
+	require.NotEqual(t, secret1, secret2)
+
+	key, err := encoding.DecodeString(secret1)
+	require.NoError(t, err)
+	require.Len(t, key, secretSize)
+}
+
+func TestKeyURI(t *testing.T) {
+	uri, err := url.Parse(KeyURI("SimpleBank", "alice", rfcSecret))
+	require.NoError(t, err)
+
+	require.Equal(t, "otpauth", uri.Scheme)
+	require.Equal(t, "totp", uri.Host)
+	require.Equal(t, "/SimpleBank:alice", uri.Path)
+
+	query := uri.Query()
+	require.Equal(t, rfcSecret, query.Get("secret"))
+	require.Equal(t, "SimpleBank", query.Get("issuer"))
+	require.Equal(t, "6", query.Get("digits"))
+	require.Equal(t, "30", query.Get("period"))
+}
@@ -0,0 +1,226 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.1
+// 	protoc        v5.26.1
+// source: rpc_subscribe_account_events.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type SubscribeAccountEventsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// offset of the last event received, the stream starts from the first event if empty
+	AfterOffset string `protobuf:"bytes,1,opt,name=after_offset,json=afterOffset,proto3" json:"after_offset,omitempty"`
+	// only stream the events of this account, all events of the logged in user if 0
+	AccountId int64 `protobuf:"varint,2,opt,name=account_id,json=accountId,proto3" json:"account_id,omitempty"`
+}
+
+func (x *SubscribeAccountEventsRequest) Reset() {
+	*x = SubscribeAccountEventsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rpc_subscribe_account_events_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SubscribeAccountEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeAccountEventsRequest) ProtoMessage() {}
+
+func (x *SubscribeAccountEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_subscribe_account_events_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeAccountEventsRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeAccountEventsRequest) Descriptor() ([]byte, []int) {
+	return file_rpc_subscribe_account_events_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SubscribeAccountEventsRequest) GetAfterOffset() string {
+	if x != nil {
+		return x.AfterOffset
+	}
+	return ""
+}
+
+func (x *SubscribeAccountEventsRequest) GetAccountId() int64 {
+	if x != nil {
+		return x.AccountId
+	}
+	return 0
+}
+
+type SubscribeAccountEventsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Event *AccountEvent `protobuf:"bytes,1,opt,name=event,proto3" json:"event,omitempty"`
+}
+
+func (x *SubscribeAccountEventsResponse) Reset() {
+	*x = SubscribeAccountEventsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_rpc_subscribe_account_events_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SubscribeAccountEventsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeAccountEventsResponse) ProtoMessage() {}
+
+func (x *SubscribeAccountEventsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_rpc_subscribe_account_events_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeAccountEventsResponse.ProtoReflect.Descriptor instead.
+func (*SubscribeAccountEventsResponse) Descriptor() ([]byte, []int) {
+	return file_rpc_subscribe_account_events_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SubscribeAccountEventsResponse) GetEvent() *AccountEvent {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+var File_rpc_subscribe_account_events_proto protoreflect.FileDescriptor
+
+var file_rpc_subscribe_account_events_proto_rawDesc = []byte{
+	0x0a, 0x22, 0x72, 0x70, 0x63, 0x5f, 0x73, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x5f,
+	0x61, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x5f, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x12, 0x02, 0x70, 0x62, 0x1a, 0x0b, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x61, 0x0a, 0x1d, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69,
+	0x62, 0x65, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x21, 0x0a, 0x0c, 0x61, 0x66, 0x74, 0x65, 0x72, 0x5f,
+	0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x61, 0x66,
+	0x74, 0x65, 0x72, 0x4f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x61, 0x63, 0x63,
+	0x6f, 0x75, 0x6e, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x61,
+	0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x49, 0x64, 0x22, 0x48, 0x0a, 0x1e, 0x53, 0x75, 0x62, 0x73,
+	0x63, 0x72, 0x69, 0x62, 0x65, 0x41, 0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x45, 0x76, 0x65, 0x6e,
+	0x74, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x26, 0x0a, 0x05, 0x65, 0x76,
+	0x65, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x70, 0x62, 0x2e, 0x41,
+	0x63, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x52, 0x05, 0x65, 0x76, 0x65,
+	0x6e, 0x74, 0x42, 0x21, 0x5a, 0x1f, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d,
+	0x2f, 0x78, 0x6d, 0x65, 0x69, 0x7a, 0x68, 0x2f, 0x73, 0x69, 0x6d, 0x70, 0x6c, 0x65, 0x62, 0x61,
+	0x6e, 0x6b, 0x2f, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_rpc_subscribe_account_events_proto_rawDescOnce sync.Once
+	file_rpc_subscribe_account_events_proto_rawDescData = file_rpc_subscribe_account_events_proto_rawDesc
+)
+
+func file_rpc_subscribe_account_events_proto_rawDescGZIP() []byte {
+	file_rpc_subscribe_account_events_proto_rawDescOnce.Do(func() {
+		file_rpc_subscribe_account_events_proto_rawDescData = protoimpl.X.CompressGZIP(file_rpc_subscribe_account_events_proto_rawDescData)
+	})
+	return file_rpc_subscribe_account_events_proto_rawDescData
+}
+
+var file_rpc_subscribe_account_events_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_rpc_subscribe_account_events_proto_goTypes = []interface{}{
+	(*SubscribeAccountEventsRequest)(nil),  // 0: pb.SubscribeAccountEventsRequest
+	(*SubscribeAccountEventsResponse)(nil), // 1: pb.SubscribeAccountEventsResponse
+	(*AccountEvent)(nil),                   // 2: pb.AccountEvent
+}
+var file_rpc_subscribe_account_events_proto_depIdxs = []int32{
+	2, // 0: pb.SubscribeAccountEventsResponse.event:type_name -> pb.AccountEvent
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_rpc_subscribe_account_events_proto_init() }
+func file_rpc_subscribe_account_events_proto_init() {
+	if File_rpc_subscribe_account_events_proto != nil {
+		return
+	}
+	file_event_proto_init()
+	if !protoimpl.UnsafeEnabled {
+		file_rpc_subscribe_account_events_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SubscribeAccountEventsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_rpc_subscribe_account_events_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SubscribeAccountEventsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_rpc_subscribe_account_events_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_rpc_subscribe_account_events_proto_goTypes,
+		DependencyIndexes: file_rpc_subscribe_account_events_proto_depIdxs,
+		MessageInfos:      file_rpc_subscribe_account_events_proto_msgTypes,
+	}.Build()
+	File_rpc_subscribe_account_events_proto = out.File
+	file_rpc_subscribe_account_events_proto_rawDesc = nil
+	file_rpc_subscribe_account_events_proto_goTypes = nil
+	file_rpc_subscribe_account_events_proto_depIdxs = nil
+}
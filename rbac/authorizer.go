@@ -0,0 +1,36 @@
+package rbac
+
+import (
+	"context"
+	"fmt"
+
+	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/token"
+)
+
+// Authorizer decides what users are allowed to do
+type Authorizer interface {
+	// Permissions returns the permissions granted to the user the token was issued to
+	Permissions(ctx context.Context, payload *token.Payload) (Permissions, error)
+}
+
+// StoreAuthorizer reads the permissions of the roles granted to a user from the database on every call,
+// so granting or revoking a role takes effect without waiting for new tokens
+type StoreAuthorizer struct {
+	store db.Querier
+}
+
+// NewStoreAuthorizer creates a new StoreAuthorizer
+func NewStoreAuthorizer(store db.Querier) *StoreAuthorizer {
+	return &StoreAuthorizer{
+		store: store,
+	}
+}
+
+func (authorizer *StoreAuthorizer) Permissions(ctx context.Context, payload *token.Payload) (Permissions, error) {
+	permissions, err := authorizer.store.ListUserPermissions(ctx, payload.Username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list user permissions: %w", err)
+	}
+	return NewPermissions(permissions...), nil
+}
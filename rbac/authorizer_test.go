@@ -0,0 +1,39 @@
+package rbac
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/xmeizh/simplebank/db/mock"
+	"github.com/xmeizh/simplebank/token"
+	"github.com/xmeizh/simplebank/util"
+)
+
+func TestStoreAuthorizer(t *testing.T) {
+	payload := &token.Payload{Username: util.RandomOwner(), Role: util.DepositorRole}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	gomock.InOrder(
+		store.EXPECT().ListUserPermissions(gomock.Any(), gomock.Eq(payload.Username)).Times(1).
+			Return([]string{"accounts:read:any", "transfers:create:own"}, nil),
+		store.EXPECT().ListUserPermissions(gomock.Any(), gomock.Eq(payload.Username)).Times(1).
+			Return(nil, sql.ErrConnDone),
+	)
+
+	authorizer := NewStoreAuthorizer(store)
+
+	permissions, err := authorizer.Permissions(context.Background(), payload)
+	require.NoError(t, err)
+	require.True(t, permissions.AllowsAny(ReadAccounts))
+	require.True(t, permissions.Allows(CreateTransfers))
+	require.False(t, permissions.AllowsAny(CreateTransfers))
+
+	_, err = authorizer.Permissions(context.Background(), payload)
+	require.ErrorIs(t, err, sql.ErrConnDone)
+}
@@ -0,0 +1,58 @@
+package rbac
+
+// Action is something a user can do to a kind of resource, like reading accounts.
+// Roles are granted an action either on the resources of the user or on those of any user,
+// which is stored as a permission like accounts:read:own or accounts:read:any.
+type Action string
+
+const (
+	UpdateUsers              Action = "users:update"
+	ManageMFA                Action = "mfa:manage"
+	ReadSessions             Action = "sessions:read"
+	RevokeSessions           Action = "sessions:revoke"
+	CreateAccounts           Action = "accounts:create"
+	ReadAccounts             Action = "accounts:read"
+	DeleteAccounts           Action = "accounts:delete"
+	CreateTransfers          Action = "transfers:create"
+	ReadTransfers            Action = "transfers:read"
+	CreateScheduledTransfers Action = "scheduled_transfers:create"
+	ReadScheduledTransfers   Action = "scheduled_transfers:read"
+	CancelScheduledTransfers Action = "scheduled_transfers:cancel"
+	ManageRoles              Action = "roles:manage"
+	ManageWebhooks           Action = "webhooks:manage"
+)
+
+const (
+	// ScopeOwn grants an action on the resources of the user
+	ScopeOwn = "own"
+	// ScopeAny grants an action on the resources of every user
+	ScopeAny = "any"
+)
+
+// Permission returns the permission granting the action in scope
+func (action Action) Permission(scope string) string {
+	return string(action) + ":" + scope
+}
+
+// Permissions is the set of permissions granted to a user by their roles
+type Permissions map[string]bool
+
+// NewPermissions creates a set of permissions
+func NewPermissions(permissions ...string) Permissions {
+	set := make(Permissions, len(permissions))
+	for _, permission := range permissions {
+		set[permission] = true
+	}
+	return set
+}
+
+// Allows reports whether the action is granted in any scope,
+// callers still have to check the resource belongs to the user unless AllowsAny is true
+func (permissions Permissions) Allows(action Action) bool {
+	return permissions[action.Permission(ScopeOwn)] || permissions[action.Permission(ScopeAny)]
+}
+
+// AllowsAny reports whether the action is granted on the resources of every user
+func (permissions Permissions) AllowsAny(action Action) bool {
+	return permissions[action.Permission(ScopeAny)]
+}
@@ -0,0 +1,25 @@
+package rbac
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPermissions(t *testing.T) {
+	require.Equal(t, "accounts:read:own", ReadAccounts.Permission(ScopeOwn))
+	require.Equal(t, "accounts:read:any", ReadAccounts.Permission(ScopeAny))
+
+	permissions := NewPermissions("accounts:read:own", "sessions:read:any")
+
+	require.True(t, permissions.Allows(ReadAccounts))
+	require.False(t, permissions.AllowsAny(ReadAccounts))
+
+	require.True(t, permissions.Allows(ReadSessions))
+	require.True(t, permissions.AllowsAny(ReadSessions))
+
+	require.False(t, permissions.Allows(ManageRoles))
+	require.False(t, permissions.AllowsAny(ManageRoles))
+
+	require.False(t, NewPermissions().Allows(ReadAccounts))
+}
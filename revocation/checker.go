@@ -0,0 +1,67 @@
+package revocation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	db "github.com/xmeizh/simplebank/db/postgresql"
+)
+
+var ErrTokenRevoked = errors.New("token has been revoked")
+
+// Checker decides whether tokens issued for a session are still accepted
+type Checker interface {
+	// CheckToken returns ErrTokenRevoked if the session has been blocked
+	// or the user changed their password after the token was issued
+	CheckToken(ctx context.Context, sessionID uuid.UUID, issuedAt time.Time) error
+}
+
+// StoreChecker looks sessions up in the database and keeps their state in an LRU cache for a short time,
+// so a revocation takes effect at the latest once the cached state expires
+type StoreChecker struct {
+	store db.Querier
+	cache *lruCache[uuid.UUID, db.GetSessionStateRow]
+}
+
+// NewStoreChecker creates a new StoreChecker caching the state of up to size sessions for duration
+func NewStoreChecker(store db.Querier, size int, duration time.Duration) *StoreChecker {
+	return &StoreChecker{
+		store: store,
+		cache: newLRUCache[uuid.UUID, db.GetSessionStateRow](size, duration),
+	}
+}
+
+func (checker *StoreChecker) CheckToken(ctx context.Context, sessionID uuid.UUID, issuedAt time.Time) error {
+	if sessionID == uuid.Nil {
+		return fmt.Errorf("%w: token doesn't belong to a session", ErrTokenRevoked)
+	}
+
+	state, ok := checker.cache.get(sessionID)
+	if !ok {
+		var err error
+		state, err = checker.store.GetSessionState(ctx, sessionID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("%w: session not found", ErrTokenRevoked)
+			}
+			return fmt.Errorf("failed to get session state: %w", err)
+		}
+		checker.cache.add(sessionID, state)
+	}
+
+	if state.IsBlocked {
+		return fmt.Errorf("%w: session is blocked", ErrTokenRevoked)
+	}
+
+	// tokens only carry the second they were issued at,
+	// so a token issued in the second the password changed is still accepted
+	if issuedAt.Before(state.PasswordChangedAt.Truncate(time.Second)) {
+		return fmt.Errorf("%w: password changed after the token was issued", ErrTokenRevoked)
+	}
+
+	return nil
+}
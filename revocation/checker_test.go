@@ -0,0 +1,132 @@
+package revocation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/xmeizh/simplebank/db/mock"
+	db "github.com/xmeizh/simplebank/db/postgresql"
+)
+
+func TestStoreChecker(t *testing.T) {
+	sessionID := uuid.New()
+	passwordChangedAt := time.Now().Add(-time.Hour)
+
+	testCases := []struct {
+		name       string
+		sessionID  uuid.UUID
+		issuedAt   time.Time
+		buildStubs func(store *mockdb.MockStore)
+		checkError func(t *testing.T, err error)
+	}{
+		{
+			name:      "OK",
+			sessionID: sessionID,
+			issuedAt:  time.Now(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetSessionState(gomock.Any(), gomock.Eq(sessionID)).Times(1).
+					Return(db.GetSessionStateRow{PasswordChangedAt: passwordChangedAt}, nil)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:      "BlockedSession",
+			sessionID: sessionID,
+			issuedAt:  time.Now(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetSessionState(gomock.Any(), gomock.Eq(sessionID)).Times(1).
+					Return(db.GetSessionStateRow{IsBlocked: true, PasswordChangedAt: passwordChangedAt}, nil)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrTokenRevoked)
+			},
+		},
+		{
+			name:      "IssuedBeforePasswordChange",
+			sessionID: sessionID,
+			issuedAt:  passwordChangedAt.Add(-time.Minute),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetSessionState(gomock.Any(), gomock.Eq(sessionID)).Times(1).
+					Return(db.GetSessionStateRow{PasswordChangedAt: passwordChangedAt}, nil)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrTokenRevoked)
+			},
+		},
+		{
+			name:      "SessionNotFound",
+			sessionID: sessionID,
+			issuedAt:  time.Now(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetSessionState(gomock.Any(), gomock.Eq(sessionID)).Times(1).
+					Return(db.GetSessionStateRow{}, sql.ErrNoRows)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrTokenRevoked)
+			},
+		},
+		{
+			name:      "NoSession",
+			sessionID: uuid.Nil,
+			issuedAt:  time.Now(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetSessionState(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrTokenRevoked)
+			},
+		},
+		{
+			name:      "InternalError",
+			sessionID: sessionID,
+			issuedAt:  time.Now(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetSessionState(gomock.Any(), gomock.Eq(sessionID)).Times(1).
+					Return(db.GetSessionStateRow{}, sql.ErrConnDone)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, sql.ErrConnDone)
+				require.False(t, errors.Is(err, ErrTokenRevoked))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			checker := NewStoreChecker(store, 10, time.Minute)
+			err := checker.CheckToken(context.Background(), tc.sessionID, tc.issuedAt)
+			tc.checkError(t, err)
+		})
+	}
+}
+
+func TestStoreCheckerCachesSessionState(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	sessionID := uuid.New()
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetSessionState(gomock.Any(), gomock.Eq(sessionID)).Times(1).Return(db.GetSessionStateRow{}, nil)
+
+	checker := NewStoreChecker(store, 10, time.Minute)
+	for i := 0; i < 3; i++ {
+		err := checker.CheckToken(context.Background(), sessionID, time.Now())
+		require.NoError(t, err)
+	}
+}
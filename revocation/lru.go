@@ -0,0 +1,76 @@
+package revocation
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+type lruEntry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiredAt time.Time
+}
+
+// lruCache keeps at most size values, each for a limited time.
+// When it is full the least recently used value is evicted.
+type lruCache[K comparable, V any] struct {
+	size     int
+	duration time.Duration
+	mutex    sync.Mutex
+	order    *list.List
+	entries  map[K]*list.Element
+}
+
+func newLRUCache[K comparable, V any](size int, duration time.Duration) *lruCache[K, V] {
+	return &lruCache[K, V]{
+		size:     size,
+		duration: duration,
+		order:    list.New(),
+		entries:  make(map[K]*list.Element),
+	}
+}
+
+func (cache *lruCache[K, V]) get(key K) (value V, ok bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	element, ok := cache.entries[key]
+	if !ok {
+		return value, false
+	}
+
+	entry := element.Value.(*lruEntry[K, V])
+	if time.Now().After(entry.expiredAt) {
+		cache.order.Remove(element)
+		delete(cache.entries, key)
+		return value, false
+	}
+
+	cache.order.MoveToFront(element)
+	return entry.value, true
+}
+
+func (cache *lruCache[K, V]) add(key K, value V) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	entry := &lruEntry[K, V]{
+		key:       key,
+		value:     value,
+		expiredAt: time.Now().Add(cache.duration),
+	}
+
+	if element, ok := cache.entries[key]; ok {
+		element.Value = entry
+		cache.order.MoveToFront(element)
+		return
+	}
+
+	cache.entries[key] = cache.order.PushFront(entry)
+	if cache.order.Len() > cache.size {
+		oldest := cache.order.Back()
+		cache.order.Remove(oldest)
+		delete(cache.entries, oldest.Value.(*lruEntry[K, V]).key)
+	}
+}
@@ -0,0 +1,55 @@
+package revocation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRUCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newLRUCache[string, int](2, time.Minute)
+
+	cache.add("a", 1)
+	cache.add("b", 2)
+
+	// reading a makes b the least recently used
+	value, ok := cache.get("a")
+	require.True(t, ok)
+	require.Equal(t, 1, value)
+
+	cache.add("c", 3)
+
+	_, ok = cache.get("b")
+	require.False(t, ok)
+
+	value, ok = cache.get("a")
+	require.True(t, ok)
+	require.Equal(t, 1, value)
+
+	value, ok = cache.get("c")
+	require.True(t, ok)
+	require.Equal(t, 3, value)
+}
+
+func TestLRUCacheExpired(t *testing.T) {
+	cache := newLRUCache[string, int](2, -time.Minute)
+
+	cache.add("a", 1)
+
+	_, ok := cache.get("a")
+	require.False(t, ok)
+	require.Zero(t, cache.order.Len())
+}
+
+func TestLRUCacheReplace(t *testing.T) {
+	cache := newLRUCache[string, int](2, time.Minute)
+
+	cache.add("a", 1)
+	cache.add("a", 2)
+
+	value, ok := cache.get("a")
+	require.True(t, ok)
+	require.Equal(t, 2, value)
+	require.Equal(t, 1, cache.order.Len())
+}
@@ -0,0 +1,35 @@
+package statement
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+
+	"github.com/xmeizh/simplebank/util"
+)
+
+// WriteCSV writes the statement as CSV, one row per entry
+// between an opening balance row and a closing balance row
+func WriteCSV(w io.Writer, statement Statement) error {
+	currency := statement.Account.Currency
+	records := [][]string{
+		{"date", "entry_id", "description", "amount", "balance"},
+		{formatTime(statement.FromTime), "", "Opening balance", "", util.FormatAmount(statement.OpeningBalance, currency)},
+	}
+
+	for _, entry := range statement.Entries {
+		records = append(records, []string{
+			formatTime(entry.CreatedAt),
+			fmt.Sprintf("%d", entry.ID),
+			description(entry.Amount),
+			util.FormatAmount(entry.Amount, currency),
+			util.FormatAmount(entry.RunningBalance, currency),
+		})
+	}
+
+	records = append(records, []string{
+		formatTime(statement.ToTime), "", "Closing balance", "", util.FormatAmount(statement.ClosingBalance, currency),
+	})
+
+	return csv.NewWriter(w).WriteAll(records)
+}
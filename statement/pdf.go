@@ -0,0 +1,144 @@
+package statement
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/xmeizh/simplebank/util"
+)
+
+// The PDF is a plain A4 document printed in a monospaced standard font,
+// so it needs neither embedded fonts nor a layout engine.
+const (
+	pdfPageWidth    = 595
+	pdfPageHeight   = 842
+	pdfMargin       = 50
+	pdfFontSize     = 9
+	pdfLineHeight   = 12
+	pdfLinesPerPage = (pdfPageHeight - 2*pdfMargin) / pdfLineHeight
+)
+
+// WritePDF writes the statement as a PDF document
+func WritePDF(w io.Writer, statement Statement) error {
+	pages := paginate(statementLines(statement), pdfLinesPerPage)
+
+	var pdf pdfWriter
+	pdf.buf.WriteString("%PDF-1.4\n")
+
+	// objects 1 to 3 are the catalog, the page tree and the font,
+	// each page then takes two objects: the page itself and its content stream
+	pageRefs := make([]string, len(pages))
+	for i := range pages {
+		pageRefs[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+
+	pdf.writeObject("<< /Type /Catalog /Pages 2 0 R >>")
+	pdf.writeObject(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(pageRefs, " "), len(pages)))
+	pdf.writeObject("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+
+	for i, lines := range pages {
+		pdf.writeObject(fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 5+2*i,
+		))
+
+		content := pageContent(lines)
+		pdf.writeObject(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+
+	pdf.writeTrailer()
+
+	_, err := w.Write(pdf.buf.Bytes())
+	return err
+}
+
+func statementLines(statement Statement) []string {
+	currency := statement.Account.Currency
+	row := func(date string, entry string, description string, amount string, balance string) string {
+		return fmt.Sprintf("%-23s %8s %-15s %16s %16s", date, entry, description, amount, balance)
+	}
+
+	lines := []string{
+		"Simple Bank - Account Statement",
+		"",
+		fmt.Sprintf("Account:  #%d (%s)", statement.Account.ID, currency),
+		fmt.Sprintf("Owner:    %s (%s)", statement.FullName, statement.Account.Owner),
+		fmt.Sprintf("Period:   %s - %s", formatTime(statement.FromTime), formatTime(statement.ToTime)),
+		"",
+		row("Date", "Entry", "Description", "Amount", "Balance"),
+		row(formatTime(statement.FromTime), "", "Opening balance", "", util.FormatAmount(statement.OpeningBalance, currency)),
+	}
+
+	for _, entry := range statement.Entries {
+		lines = append(lines, row(
+			formatTime(entry.CreatedAt),
+			fmt.Sprintf("%d", entry.ID),
+			description(entry.Amount),
+			util.FormatAmount(entry.Amount, currency),
+			util.FormatAmount(entry.RunningBalance, currency),
+		))
+	}
+
+	return append(lines,
+		row(formatTime(statement.ToTime), "", "Closing balance", "", util.FormatAmount(statement.ClosingBalance, currency)),
+	)
+}
+
+func paginate(lines []string, linesPerPage int) [][]string {
+	var pages [][]string
+	for len(lines) > linesPerPage {
+		pages = append(pages, lines[:linesPerPage])
+		lines = lines[linesPerPage:]
+	}
+	return append(pages, lines)
+}
+
+func pageContent(lines []string) string {
+	var content strings.Builder
+	fmt.Fprintf(&content, "BT\n/F1 %d Tf\n%d TL\n%d %d Td\n", pdfFontSize, pdfLineHeight, pdfMargin, pdfPageHeight-pdfMargin)
+	for _, line := range lines {
+		fmt.Fprintf(&content, "(%s) Tj T*\n", escapePDFText(line))
+	}
+	content.WriteString("ET")
+	return content.String()
+}
+
+// escapePDFText escapes a line for a PDF literal string.
+// Characters outside printable ASCII aren't available in the standard font encoding and are replaced.
+func escapePDFText(text string) string {
+	var escaped strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			escaped.WriteRune('\\')
+			escaped.WriteRune(r)
+		case r < ' ' || r > '~':
+			escaped.WriteRune('?')
+		default:
+			escaped.WriteRune(r)
+		}
+	}
+	return escaped.String()
+}
+
+// pdfWriter keeps track of the byte offset of every object for the cross-reference table
+type pdfWriter struct {
+	buf     bytes.Buffer
+	offsets []int
+}
+
+func (pdf *pdfWriter) writeObject(body string) {
+	pdf.offsets = append(pdf.offsets, pdf.buf.Len())
+	fmt.Fprintf(&pdf.buf, "%d 0 obj\n%s\nendobj\n", len(pdf.offsets), body)
+}
+
+func (pdf *pdfWriter) writeTrailer() {
+	xrefOffset := pdf.buf.Len()
+	fmt.Fprintf(&pdf.buf, "xref\n0 %d\n0000000000 65535 f \n", len(pdf.offsets)+1)
+	for _, offset := range pdf.offsets {
+		fmt.Fprintf(&pdf.buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&pdf.buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(pdf.offsets)+1, xrefOffset)
+}
@@ -0,0 +1,40 @@
+// Package statement renders account statements to files that can be sent to customers.
+package statement
+
+import (
+	"time"
+
+	db "github.com/xmeizh/simplebank/db/postgresql"
+)
+
+// dateFormat is used for every timestamp of a rendered statement
+const dateFormat = "2006-01-02 15:04:05 UTC"
+
+// Statement holds everything printed on an account statement
+type Statement struct {
+	Account        db.Account
+	FullName       string
+	FromTime       time.Time
+	ToTime         time.Time
+	OpeningBalance int64
+	ClosingBalance int64
+	Entries        []db.ListAccountStatementEntriesRow
+}
+
+// MonthPeriod returns the first instant of the month of t and of the month after it, in UTC
+func MonthPeriod(t time.Time) (fromTime time.Time, toTime time.Time) {
+	t = t.UTC()
+	fromTime = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return fromTime, fromTime.AddDate(0, 1, 0)
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(dateFormat)
+}
+
+func description(amount int64) string {
+	if amount < 0 {
+		return "Debit"
+	}
+	return "Credit"
+}
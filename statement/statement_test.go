@@ -0,0 +1,104 @@
+package statement
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	db "github.com/xmeizh/simplebank/db/postgresql"
+)
+
+func randomStatement(n int) Statement {
+	fromTime, toTime := MonthPeriod(time.Date(2024, time.February, 10, 8, 0, 0, 0, time.UTC))
+	statement := Statement{
+		Account: db.Account{
+			ID:       7,
+			Owner:    "alice",
+			Currency: "USD",
+		},
+		FullName:       "Alice (Jr.)",
+		FromTime:       fromTime,
+		ToTime:         toTime,
+		OpeningBalance: 10000,
+	}
+
+	balance := statement.OpeningBalance
+	for i := 0; i < n; i++ {
+		amount := int64(-150)
+		if i%2 == 1 {
+			amount = 100
+		}
+		balance += amount
+		statement.Entries = append(statement.Entries, db.ListAccountStatementEntriesRow{
+			ID:             int64(i + 1),
+			AccountID:      statement.Account.ID,
+			Amount:         amount,
+			CreatedAt:      fromTime.Add(time.Duration(i) * time.Hour),
+			RunningBalance: balance,
+		})
+	}
+	statement.ClosingBalance = balance
+	return statement
+}
+
+func TestMonthPeriod(t *testing.T) {
+	fromTime, toTime := MonthPeriod(time.Date(2023, time.December, 31, 23, 59, 0, 0, time.UTC))
+	require.Equal(t, time.Date(2023, time.December, 1, 0, 0, 0, 0, time.UTC), fromTime)
+	require.Equal(t, time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC), toTime)
+}
+
+func TestWriteCSV(t *testing.T) {
+	statement := randomStatement(2)
+
+	var buf bytes.Buffer
+	err := WriteCSV(&buf, statement)
+	require.NoError(t, err)
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Equal(t, [][]string{
+		{"date", "entry_id", "description", "amount", "balance"},
+		{"2024-02-01 00:00:00 UTC", "", "Opening balance", "", "100.00"},
+		{"2024-02-01 00:00:00 UTC", "1", "Debit", "-1.50", "98.50"},
+		{"2024-02-01 01:00:00 UTC", "2", "Credit", "1.00", "99.50"},
+		{"2024-03-01 00:00:00 UTC", "", "Closing balance", "", "99.50"},
+	}, records)
+}
+
+func TestWritePDF(t *testing.T) {
+	statement := randomStatement(2 * pdfLinesPerPage)
+
+	var buf bytes.Buffer
+	err := WritePDF(&buf, statement)
+	require.NoError(t, err)
+
+	pdf := buf.Bytes()
+	require.True(t, bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")))
+	require.True(t, bytes.HasSuffix(pdf, []byte("%%EOF\n")))
+	require.Contains(t, buf.String(), "/Count 3")
+	require.Contains(t, buf.String(), `(Owner:    Alice \(Jr.\) \(alice\)) Tj`)
+
+	// every cross-reference entry must point at the start of its object
+	xref := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(pdf, -1)
+	require.Len(t, xref, 3+2*3)
+	for i, match := range xref {
+		offset, err := strconv.Atoi(string(match[1]))
+		require.NoError(t, err)
+		require.True(t, bytes.HasPrefix(pdf[offset:], []byte(fmt.Sprintf("%d 0 obj\n", i+1))))
+	}
+
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(pdf)
+	require.NotNil(t, startxref)
+	offset, err := strconv.Atoi(string(startxref[1]))
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(pdf[offset:], []byte("xref\n")))
+}
+
+func TestEscapePDFText(t *testing.T) {
+	require.Equal(t, `a\(b\)c\\d?`, escapePDFText(`a(b)c\dé`))
+}
@@ -0,0 +1,158 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	FormatPaseto = "paseto"
+	FormatJWT    = "jwt"
+)
+
+const (
+	AlgorithmPasetoLocal  = "v2.local"
+	AlgorithmPasetoPublic = "v4.public"
+	AlgorithmHS256        = "HS256"
+	AlgorithmRS256        = "RS256"
+	AlgorithmEdDSA        = "EdDSA"
+)
+
+// Config selects the Maker created by NewMaker
+type Config struct {
+	Format    string
+	Algorithm string
+	// Issuer and Audience are only used by JWTs
+	Issuer   string
+	Audience string
+	// SymmetricKey is used by v2.local and HS256
+	SymmetricKey string
+	// KeyID and PrivateKeyFile are used by the public key algorithms, the key is a PKCS #8 PEM file
+	KeyID          string
+	PrivateKeyFile string
+	// VerificationKeys are the retired keys still accepted during a rotation,
+	// each one is "<key id>=<path to a PKIX PEM public key>"
+	VerificationKeys []string
+}
+
+// NewMaker creates the Maker selected by config
+func NewMaker(config Config) (Maker, error) {
+	switch {
+	case config.Format == FormatPaseto && config.Algorithm == AlgorithmPasetoLocal:
+		return NewPasetoMaker(config.SymmetricKey)
+	case config.Format == FormatPaseto && config.Algorithm == AlgorithmPasetoPublic:
+		privateKey, verificationKeys, err := loadKeys(config)
+		if err != nil {
+			return nil, err
+		}
+
+		ed25519PrivateKey, ok := privateKey.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("%s requires an Ed25519 private key", config.Algorithm)
+		}
+
+		ed25519VerificationKeys := make(map[string]ed25519.PublicKey, len(verificationKeys))
+		for id, key := range verificationKeys {
+			ed25519VerificationKeys[id], ok = key.(ed25519.PublicKey)
+			if !ok {
+				return nil, fmt.Errorf("%s requires Ed25519 verification keys, key %s is %T", config.Algorithm, id, key)
+			}
+		}
+
+		return NewPasetoPublicMaker(config.KeyID, ed25519PrivateKey, ed25519VerificationKeys)
+	case config.Format == FormatJWT && config.Algorithm == AlgorithmHS256:
+		return NewJWTMaker(config.SymmetricKey, config.Issuer, config.Audience)
+	case config.Format == FormatJWT && (config.Algorithm == AlgorithmRS256 || config.Algorithm == AlgorithmEdDSA):
+		privateKey, verificationKeys, err := loadKeys(config)
+		if err != nil {
+			return nil, err
+		}
+
+		method, err := jwtSigningMethod(privateKey.Public())
+		if err != nil {
+			return nil, err
+		}
+		if method.Alg() != config.Algorithm {
+			return nil, fmt.Errorf("%s private key doesn't match algorithm %s", method.Alg(), config.Algorithm)
+		}
+
+		return NewJWTPublicMaker(config.KeyID, privateKey, verificationKeys, config.Issuer, config.Audience)
+	default:
+		return nil, fmt.Errorf("unsupported token format %q with algorithm %q", config.Format, config.Algorithm)
+	}
+}
+
+func loadKeys(config Config) (crypto.Signer, map[string]crypto.PublicKey, error) {
+	privateKey, err := loadPrivateKey(config.PrivateKeyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	verificationKeys := make(map[string]crypto.PublicKey, len(config.VerificationKeys))
+	for _, entry := range config.VerificationKeys {
+		id, file, ok := strings.Cut(entry, "=")
+		if !ok || id == "" || file == "" {
+			return nil, nil, fmt.Errorf("invalid verification key %q: must be <key id>=<file>", entry)
+		}
+
+		verificationKeys[id], err = loadPublicKey(file)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return privateKey, verificationKeys, nil
+}
+
+func loadPrivateKey(file string) (crypto.Signer, error) {
+	block, err := readPEM(file, "PRIVATE KEY")
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse private key %s: %w", file, err)
+	}
+
+	switch key := key.(type) {
+	case ed25519.PrivateKey:
+		return key, nil
+	case *rsa.PrivateKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T in %s", key, file)
+	}
+}
+
+func loadPublicKey(file string) (crypto.PublicKey, error) {
+	block, err := readPEM(file, "PUBLIC KEY")
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse public key %s: %w", file, err)
+	}
+	return key, nil
+}
+
+func readPEM(file string, blockType string) (*pem.Block, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read key file: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != blockType {
+		return nil, fmt.Errorf("%s doesn't contain a PEM encoded %s", file, blockType)
+	}
+	return block, nil
+}
@@ -0,0 +1,153 @@
+package token
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/xmeizh/simplebank/util"
+)
+
+func writePrivateKey(t *testing.T, key crypto.Signer) string {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return writePEM(t, "PRIVATE KEY", der)
+}
+
+func writePublicKey(t *testing.T, key crypto.PublicKey) string {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+	return writePEM(t, "PUBLIC KEY", der)
+}
+
+func writePEM(t *testing.T, blockType string, der []byte) string {
+	file := filepath.Join(t.TempDir(), "key.pem")
+	err := os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600)
+	require.NoError(t, err)
+	return file
+}
+
+func TestNewMaker(t *testing.T) {
+	edKey := newEd25519Key(t)
+	rsaKey := newRSAKey(t)
+	oldEdKey := newEd25519Key(t)
+
+	testCases := []struct {
+		name      string
+		config    Config
+		checkMade func(t *testing.T, maker Maker, err error)
+	}{
+		{
+			name:   "PasetoLocal",
+			config: Config{Format: FormatPaseto, Algorithm: AlgorithmPasetoLocal, SymmetricKey: util.RandomString(32)},
+			checkMade: func(t *testing.T, maker Maker, err error) {
+				require.NoError(t, err)
+				require.IsType(t, &PasetoMaker{}, maker)
+			},
+		},
+		{
+			name: "PasetoPublic",
+			config: Config{
+				Format:           FormatPaseto,
+				Algorithm:        AlgorithmPasetoPublic,
+				KeyID:            "key2",
+				PrivateKeyFile:   writePrivateKey(t, edKey),
+				VerificationKeys: []string{"key1=" + writePublicKey(t, oldEdKey.Public())},
+			},
+			checkMade: func(t *testing.T, maker Maker, err error) {
+				require.NoError(t, err)
+				require.IsType(t, &PasetoPublicMaker{}, maker)
+				require.Len(t, maker.(PublicKeyMaker).PublicKeys(), 2)
+			},
+		},
+		{
+			name:   "JWTHS256",
+			config: Config{Format: FormatJWT, Algorithm: AlgorithmHS256, SymmetricKey: util.RandomString(32), Issuer: "simplebank"},
+			checkMade: func(t *testing.T, maker Maker, err error) {
+				require.NoError(t, err)
+				require.IsType(t, &JWTMaker{}, maker)
+
+				_, payload, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, uuid.New(), time.Minute)
+				require.NoError(t, err)
+				require.Equal(t, "simplebank", payload.Issuer)
+			},
+		},
+		{
+			name:   "JWTRS256",
+			config: Config{Format: FormatJWT, Algorithm: AlgorithmRS256, KeyID: "key1", PrivateKeyFile: writePrivateKey(t, rsaKey)},
+			checkMade: func(t *testing.T, maker Maker, err error) {
+				require.NoError(t, err)
+				require.Equal(t, AlgorithmRS256, maker.(PublicKeyMaker).PublicKeys()[0].Algorithm)
+			},
+		},
+		{
+			name:   "JWTEdDSA",
+			config: Config{Format: FormatJWT, Algorithm: AlgorithmEdDSA, KeyID: "key1", PrivateKeyFile: writePrivateKey(t, edKey)},
+			checkMade: func(t *testing.T, maker Maker, err error) {
+				require.NoError(t, err)
+				require.Equal(t, AlgorithmEdDSA, maker.(PublicKeyMaker).PublicKeys()[0].Algorithm)
+			},
+		},
+		{
+			name:   "MismatchedAlgorithm",
+			config: Config{Format: FormatJWT, Algorithm: AlgorithmEdDSA, KeyID: "key1", PrivateKeyFile: writePrivateKey(t, rsaKey)},
+			checkMade: func(t *testing.T, maker Maker, err error) {
+				require.Error(t, err)
+				require.Nil(t, maker)
+			},
+		},
+		{
+			name:   "PasetoPublicRSAKey",
+			config: Config{Format: FormatPaseto, Algorithm: AlgorithmPasetoPublic, KeyID: "key1", PrivateKeyFile: writePrivateKey(t, rsaKey)},
+			checkMade: func(t *testing.T, maker Maker, err error) {
+				require.Error(t, err)
+				require.Nil(t, maker)
+			},
+		},
+		{
+			name: "InvalidVerificationKey",
+			config: Config{
+				Format:           FormatJWT,
+				Algorithm:        AlgorithmEdDSA,
+				KeyID:            "key2",
+				PrivateKeyFile:   writePrivateKey(t, edKey),
+				VerificationKeys: []string{writePublicKey(t, oldEdKey.Public())},
+			},
+			checkMade: func(t *testing.T, maker Maker, err error) {
+				require.Error(t, err)
+				require.Nil(t, maker)
+			},
+		},
+		{
+			name:   "MissingKeyFile",
+			config: Config{Format: FormatJWT, Algorithm: AlgorithmRS256, KeyID: "key1", PrivateKeyFile: filepath.Join(t.TempDir(), "missing.pem")},
+			checkMade: func(t *testing.T, maker Maker, err error) {
+				require.Error(t, err)
+				require.Nil(t, maker)
+			},
+		},
+		{
+			name:   "UnsupportedFormat",
+			config: Config{Format: "macaroon", Algorithm: AlgorithmHS256, SymmetricKey: util.RandomString(32)},
+			checkMade: func(t *testing.T, maker Maker, err error) {
+				require.Error(t, err)
+				require.Nil(t, maker)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			maker, err := NewMaker(tc.config)
+			tc.checkMade(t, maker, err)
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewJSONWebKeySet(t *testing.T) {
+	edKey := newEd25519Key(t)
+	rsaKey := newRSAKey(t)
+
+	set, err := NewJSONWebKeySet([]PublicKey{
+		{ID: "key1", Algorithm: "EdDSA", Key: edKey.Public()},
+		{ID: "key2", Algorithm: "RS256", Key: rsaKey.Public()},
+	})
+	require.NoError(t, err)
+	require.Len(t, set.Keys, 2)
+
+	require.Equal(t, "OKP", set.Keys[0].KeyType)
+	require.Equal(t, "key1", set.Keys[0].KeyID)
+	require.Equal(t, "Ed25519", set.Keys[0].Curve)
+	x, err := base64.RawURLEncoding.DecodeString(set.Keys[0].X)
+	require.NoError(t, err)
+	require.Equal(t, []byte(edKey.Public().(ed25519.PublicKey)), x)
+
+	require.Equal(t, "RSA", set.Keys[1].KeyType)
+	require.Equal(t, "key2", set.Keys[1].KeyID)
+	require.Equal(t, "RS256", set.Keys[1].Algorithm)
+	require.Equal(t, "AQAB", set.Keys[1].E)
+	require.NotEmpty(t, set.Keys[1].N)
+}
@@ -0,0 +1,77 @@
+package token
+
+import (
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// jwtClaims are the claims of the tokens created by the JWT makers.
+// They hold a Payload, with its times as the NumericDate other JWT libraries read iat, nbf and exp as.
+type jwtClaims struct {
+	ID        uuid.UUID        `json:"id"`
+	Username  string           `json:"sub"`
+	Role      string           `json:"role"`
+	SessionID uuid.UUID        `json:"session_id"`
+	Issuer    string           `json:"iss,omitempty"`
+	Audience  jwt.ClaimStrings `json:"aud,omitempty"`
+	IssuedAt  *jwt.NumericDate `json:"iat"`
+	NotBefore *jwt.NumericDate `json:"nbf"`
+	ExpiresAt *jwt.NumericDate `json:"exp"`
+}
+
+func newJWTClaims(payload *Payload) *jwtClaims {
+	return &jwtClaims{
+		ID:        payload.ID,
+		Username:  payload.Username,
+		Role:      payload.Role,
+		SessionID: payload.SessionID,
+		Issuer:    payload.Issuer,
+		Audience:  payload.Audience,
+		IssuedAt:  jwt.NewNumericDate(payload.IssueAt),
+		NotBefore: jwt.NewNumericDate(payload.NotBefore),
+		ExpiresAt: jwt.NewNumericDate(payload.ExpiredAt),
+	}
+}
+
+// payload returns the payload of claims that passed the validation of the parser,
+// which requires exp, and parseJWT also requires iat
+func (claims *jwtClaims) payload() *Payload {
+	payload := &Payload{
+		ID:        claims.ID,
+		Username:  claims.Username,
+		Role:      claims.Role,
+		SessionID: claims.SessionID,
+		Issuer:    claims.Issuer,
+		Audience:  claims.Audience,
+		IssueAt:   claims.IssuedAt.Time,
+		ExpiredAt: claims.ExpiresAt.Time,
+	}
+	if claims.NotBefore != nil {
+		payload.NotBefore = claims.NotBefore.Time
+	}
+	return payload
+}
+
+func (claims *jwtClaims) GetAudience() (jwt.ClaimStrings, error) {
+	return claims.Audience, nil
+}
+
+func (claims *jwtClaims) GetExpirationTime() (*jwt.NumericDate, error) {
+	return claims.ExpiresAt, nil
+}
+
+func (claims *jwtClaims) GetIssuedAt() (*jwt.NumericDate, error) {
+	return claims.IssuedAt, nil
+}
+
+func (claims *jwtClaims) GetIssuer() (string, error) {
+	return claims.Issuer, nil
+}
+
+func (claims *jwtClaims) GetNotBefore() (*jwt.NumericDate, error) {
+	return claims.NotBefore, nil
+}
+
+func (claims *jwtClaims) GetSubject() (string, error) {
+	return claims.Username, nil
+}
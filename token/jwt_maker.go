@@ -6,29 +6,34 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 const minSecretKeySize = 32
 
 type JWTMaker struct {
 	secretKey string
+	issuer    string
+	audience  string
 }
 
-func NewJWTMaker(secretKey string) (Maker, error) {
+// NewJWTMaker creates a JWTMaker signing tokens with HS256.
+// When issuer or audience are set, tokens carry them in iss and aud and tokens without them are rejected.
+func NewJWTMaker(secretKey string, issuer string, audience string) (Maker, error) {
 	if len(secretKey) < minSecretKeySize {
 		return nil, fmt.Errorf("invalid key size: must be at least %d characters", minSecretKeySize)
 	}
 
-	return &JWTMaker{secretKey}, nil
+	return &JWTMaker{secretKey, issuer, audience}, nil
 }
 
-func (maker *JWTMaker) CreateToken(username string, role string, duration time.Duration) (string, *Payload, error) {
-	payload, err := NewPayload(username, role, duration)
+func (maker *JWTMaker) CreateToken(username string, role string, sessionID uuid.UUID, duration time.Duration) (string, *Payload, error) {
+	payload, err := newJWTPayload(username, role, sessionID, duration, maker.issuer, maker.audience)
 	if err != nil {
 		return "", payload, err
 	}
 
-	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
+	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, newJWTClaims(payload))
 	token, err := jwtToken.SignedString([]byte(maker.secretKey))
 	return token, payload, err
 }
@@ -43,7 +48,36 @@ func (maker *JWTMaker) VerifyToken(token string) (*Payload, error) {
 
 		return []byte(maker.secretKey), nil
 	}
-	jwtToken, err := jwt.ParseWithClaims(token, &Payload{}, keyFunc)
+	return parseJWT(token, keyFunc, maker.issuer, maker.audience)
+}
+
+func newJWTPayload(username string, role string, sessionID uuid.UUID, duration time.Duration, issuer string, audience string) (*Payload, error) {
+	payload, err := NewPayload(username, role, sessionID, duration)
+	if err != nil {
+		return nil, err
+	}
+
+	payload.Issuer = issuer
+	if audience != "" {
+		payload.Audience = jwt.ClaimStrings{audience}
+	}
+	return payload, nil
+}
+
+// parseJWT verifies the signature and the registered claims of a token created by a JWT maker
+func parseJWT(token string, keyFunc jwt.Keyfunc, issuer string, audience string) (*Payload, error) {
+	options := []jwt.ParserOption{
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	}
+	if issuer != "" {
+		options = append(options, jwt.WithIssuer(issuer))
+	}
+	if audience != "" {
+		options = append(options, jwt.WithAudience(audience))
+	}
+
+	jwtToken, err := jwt.ParseWithClaims(token, &jwtClaims{}, keyFunc, options...)
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
 			return nil, ErrTokenExpired
@@ -51,10 +85,11 @@ func (maker *JWTMaker) VerifyToken(token string) (*Payload, error) {
 		return nil, ErrInvalidToken
 	}
 
-	payload, ok := jwtToken.Claims.(*Payload)
-	if !ok {
+	claims, ok := jwtToken.Claims.(*jwtClaims)
+	// iat is required too, the revocation of a token is decided by when it was issued
+	if !ok || claims.Username == "" || claims.IssuedAt == nil {
 		return nil, ErrInvalidToken
 	}
 
-	return payload, nil
+	return claims.payload(), nil
 }
@@ -5,22 +5,24 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 	"github.com/xmeizh/simplebank/util"
 )
 
 func TestJWTMaker(t *testing.T) {
-	maker, err := NewJWTMaker(util.RandomString(32))
+	maker, err := NewJWTMaker(util.RandomString(32), "simplebank", "simplebank-api")
 	require.NoError(t, err)
 
 	username := util.RandomOwner()
+	sessionID := uuid.New()
 	duration := time.Minute
 
 	issueAt := time.Now()
 	expiredAt := issueAt.Add(duration)
 
 	role := util.DepositorRole
-	token, payload, err := maker.CreateToken(username, role, duration)
+	token, payload, err := maker.CreateToken(username, role, sessionID, duration)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
 	require.NotEmpty(t, payload)
@@ -32,15 +34,18 @@ func TestJWTMaker(t *testing.T) {
 	require.NotZero(t, payload.ID)
 	require.Equal(t, username, payload.Username)
 	require.Equal(t, role, payload.Role)
+	require.Equal(t, sessionID, payload.SessionID)
+	require.Equal(t, "simplebank", payload.Issuer)
+	require.Equal(t, jwt.ClaimStrings{"simplebank-api"}, payload.Audience)
 	require.WithinDuration(t, issueAt, payload.IssueAt, time.Second)
 	require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
 }
 
 func TestExpiredJWTToken(t *testing.T) {
-	maker, err := NewJWTMaker(util.RandomString(32))
+	maker, err := NewJWTMaker(util.RandomString(32), "", "")
 	require.NoError(t, err)
 
-	token, payload, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, -time.Minute)
+	token, payload, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, uuid.New(), -time.Minute)
 	require.NoError(t, err)
 	require.NotEmpty(t, token)
 	require.NotEmpty(t, payload)
@@ -52,14 +57,14 @@ func TestExpiredJWTToken(t *testing.T) {
 }
 
 func TestInvalidJWTTokenAlgNone(t *testing.T) {
-	payload, err := NewPayload(util.RandomOwner(), util.DepositorRole, time.Minute)
+	payload, err := NewPayload(util.RandomOwner(), util.DepositorRole, uuid.New(), time.Minute)
 	require.NoError(t, err)
 
-	jwtToken := jwt.NewWithClaims(jwt.SigningMethodNone, payload)
+	jwtToken := jwt.NewWithClaims(jwt.SigningMethodNone, newJWTClaims(payload))
 	token, err := jwtToken.SignedString(jwt.UnsafeAllowNoneSignatureType)
 	require.NoError(t, err)
 
-	maker, err := NewJWTMaker(util.RandomString(32))
+	maker, err := NewJWTMaker(util.RandomString(32), "", "")
 	require.NoError(t, err)
 
 	payload, err = maker.VerifyToken(token)
@@ -67,3 +72,64 @@ func TestInvalidJWTTokenAlgNone(t *testing.T) {
 	require.EqualError(t, err, ErrInvalidToken.Error())
 	require.Nil(t, payload)
 }
+
+func TestJWTMakerRegisteredClaims(t *testing.T) {
+	secretKey := util.RandomString(32)
+	maker, err := NewJWTMaker(secretKey, "simplebank", "simplebank-api")
+	require.NoError(t, err)
+
+	otherIssuer, err := NewJWTMaker(secretKey, "other", "simplebank-api")
+	require.NoError(t, err)
+	otherAudience, err := NewJWTMaker(secretKey, "simplebank", "other")
+	require.NoError(t, err)
+	noClaims, err := NewJWTMaker(secretKey, "", "")
+	require.NoError(t, err)
+
+	for _, otherMaker := range []Maker{otherIssuer, otherAudience, noClaims} {
+		token, _, err := otherMaker.CreateToken(util.RandomOwner(), util.DepositorRole, uuid.New(), time.Minute)
+		require.NoError(t, err)
+
+		payload, err := maker.VerifyToken(token)
+		require.EqualError(t, err, ErrInvalidToken.Error())
+		require.Nil(t, payload)
+	}
+
+	// the subject is the username and must be set
+	token, _, err := maker.CreateToken("", util.DepositorRole, uuid.New(), time.Minute)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(token)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestJWTMakerStockParser(t *testing.T) {
+	secretKey := util.RandomString(32)
+	maker, err := NewJWTMaker(secretKey, "", "")
+	require.NoError(t, err)
+
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		return []byte(secretKey), nil
+	}
+
+	// a parser knowing nothing about Payload reads the registered claims
+	token, payload, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, uuid.New(), time.Minute)
+	require.NoError(t, err)
+
+	jwtToken, err := jwt.Parse(token, keyFunc, jwt.WithExpirationRequired(), jwt.WithIssuedAt())
+	require.NoError(t, err)
+	expiresAt, err := jwtToken.Claims.GetExpirationTime()
+	require.NoError(t, err)
+	require.NotNil(t, expiresAt)
+	require.True(t, payload.ExpiredAt.Equal(expiresAt.Time))
+	subject, err := jwtToken.Claims.GetSubject()
+	require.NoError(t, err)
+	require.Equal(t, payload.Username, subject)
+
+	// and rejects the token once it expired
+	token, _, err = maker.CreateToken(util.RandomOwner(), util.DepositorRole, uuid.New(), -time.Minute)
+	require.NoError(t, err)
+
+	_, err = jwt.Parse(token, keyFunc)
+	require.ErrorIs(t, err, jwt.ErrTokenExpired)
+}
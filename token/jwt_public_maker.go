@@ -0,0 +1,129 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const minRSAKeySize = 2048
+
+// JWTPublicMaker creates JWTs signed with an RSA (RS256) or Ed25519 (EdDSA) private key
+type JWTPublicMaker struct {
+	keyID      string
+	method     jwt.SigningMethod
+	privateKey crypto.Signer
+	publicKeys map[string]crypto.PublicKey
+	issuer     string
+	audience   string
+}
+
+// NewJWTPublicMaker creates a JWTPublicMaker signing tokens with privateKey under keyID.
+// Tokens signed by one of verificationKeys are accepted too, so the signing key can be rotated.
+// issuer and audience are handled like in NewJWTMaker.
+func NewJWTPublicMaker(
+	keyID string,
+	privateKey crypto.Signer,
+	verificationKeys map[string]crypto.PublicKey,
+	issuer string,
+	audience string,
+) (Maker, error) {
+	if keyID == "" {
+		return nil, fmt.Errorf("key id must not be empty")
+	}
+
+	method, err := jwtSigningMethod(privateKey.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	publicKeys := make(map[string]crypto.PublicKey, len(verificationKeys)+1)
+	for id, key := range verificationKeys {
+		if _, err := jwtSigningMethod(key); err != nil {
+			return nil, fmt.Errorf("invalid verification key %s: %w", id, err)
+		}
+		publicKeys[id] = key
+	}
+	publicKeys[keyID] = privateKey.Public()
+
+	maker := &JWTPublicMaker{
+		keyID:      keyID,
+		method:     method,
+		privateKey: privateKey,
+		publicKeys: publicKeys,
+		issuer:     issuer,
+		audience:   audience,
+	}
+	return maker, nil
+}
+
+func (maker *JWTPublicMaker) CreateToken(username string, role string, sessionID uuid.UUID, duration time.Duration) (string, *Payload, error) {
+	payload, err := newJWTPayload(username, role, sessionID, duration, maker.issuer, maker.audience)
+	if err != nil {
+		return "", payload, err
+	}
+
+	jwtToken := jwt.NewWithClaims(maker.method, newJWTClaims(payload))
+	jwtToken.Header["kid"] = maker.keyID
+	token, err := jwtToken.SignedString(maker.privateKey)
+	return token, payload, err
+}
+
+// VerifyToken checks if the token is valid or not
+func (maker *JWTPublicMaker) VerifyToken(token string) (*Payload, error) {
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		keyID, ok := token.Header["kid"].(string)
+		if !ok {
+			return nil, ErrInvalidToken
+		}
+
+		key, ok := maker.publicKeys[keyID]
+		if !ok {
+			return nil, ErrInvalidToken
+		}
+
+		// the key decides the algorithm, a token can't pick another one
+		method, err := jwtSigningMethod(key)
+		if err != nil || token.Method.Alg() != method.Alg() {
+			return nil, ErrInvalidToken
+		}
+
+		return key, nil
+	}
+	return parseJWT(token, keyFunc, maker.issuer, maker.audience)
+}
+
+func (maker *JWTPublicMaker) PublicKeys() []PublicKey {
+	keys := make([]PublicKey, 0, len(maker.publicKeys))
+	for id, key := range maker.publicKeys {
+		method, _ := jwtSigningMethod(key)
+		keys = append(keys, PublicKey{
+			ID:        id,
+			Algorithm: method.Alg(),
+			Key:       key,
+		})
+	}
+	return sortPublicKeys(keys)
+}
+
+func jwtSigningMethod(key crypto.PublicKey) (jwt.SigningMethod, error) {
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if key.Size()*8 < minRSAKeySize {
+			return nil, fmt.Errorf("invalid key size: RSA keys must have at least %d bits", minRSAKeySize)
+		}
+		return jwt.SigningMethodRS256, nil
+	case ed25519.PublicKey:
+		if len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid key size: Ed25519 keys must be exactly %d bytes", ed25519.PublicKeySize)
+		}
+		return jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", key)
+	}
+}
@@ -0,0 +1,171 @@
+package token
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/xmeizh/simplebank/util"
+)
+
+func newRSAKey(t *testing.T) *rsa.PrivateKey {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return privateKey
+}
+
+func TestJWTPublicMaker(t *testing.T) {
+	signers := map[string]crypto.Signer{
+		"RS256": newRSAKey(t),
+		"EdDSA": newEd25519Key(t),
+	}
+
+	for alg, signer := range signers {
+		t.Run(alg, func(t *testing.T) {
+			maker, err := NewJWTPublicMaker("key1", signer, nil, "", "")
+			require.NoError(t, err)
+
+			username := util.RandomOwner()
+			role := util.DepositorRole
+			sessionID := uuid.New()
+			duration := time.Minute
+
+			issueAt := time.Now()
+			expiredAt := issueAt.Add(duration)
+
+			token, payload, err := maker.CreateToken(username, role, sessionID, duration)
+			require.NoError(t, err)
+			require.NotEmpty(t, token)
+			require.NotEmpty(t, payload)
+
+			jwtToken, _, err := jwt.NewParser().ParseUnverified(token, &jwtClaims{})
+			require.NoError(t, err)
+			require.Equal(t, alg, jwtToken.Method.Alg())
+			require.Equal(t, "key1", jwtToken.Header["kid"])
+
+			payload, err = maker.VerifyToken(token)
+			require.NoError(t, err)
+			require.NotEmpty(t, payload)
+
+			require.NotZero(t, payload.ID)
+			require.Equal(t, username, payload.Username)
+			require.Equal(t, role, payload.Role)
+			require.Equal(t, sessionID, payload.SessionID)
+			require.WithinDuration(t, issueAt, payload.IssueAt, time.Second)
+			require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
+		})
+	}
+}
+
+func TestJWTPublicTokenRegisteredTimeClaims(t *testing.T) {
+	maker, err := NewJWTPublicMaker("key1", newEd25519Key(t), nil, "", "")
+	require.NoError(t, err)
+
+	token, payload, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, uuid.New(), time.Minute)
+	require.NoError(t, err)
+
+	// outside verifiers read the registered claims, so they must be NumericDate
+	parts := strings.Split(token, ".")
+	require.Len(t, parts, 3)
+	rawClaims, err := base64.RawURLEncoding.DecodeString(parts[1])
+	require.NoError(t, err)
+
+	var claims map[string]any
+	require.NoError(t, json.Unmarshal(rawClaims, &claims))
+	require.Equal(t, float64(payload.ExpiredAt.Unix()), claims["exp"])
+	require.Equal(t, float64(payload.IssueAt.Unix()), claims["iat"])
+	require.Equal(t, float64(payload.IssueAt.Unix()), claims["nbf"])
+	require.Equal(t, time.Minute, payload.ExpiredAt.Sub(payload.IssueAt))
+}
+
+func TestExpiredJWTPublicToken(t *testing.T) {
+	maker, err := NewJWTPublicMaker("key1", newEd25519Key(t), nil, "", "")
+	require.NoError(t, err)
+
+	token, payload, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, uuid.New(), -time.Minute)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
+
+	payload, err = maker.VerifyToken(token)
+	require.EqualError(t, err, ErrTokenExpired.Error())
+	require.Nil(t, payload)
+}
+
+func TestJWTPublicMakerKeyRotation(t *testing.T) {
+	oldKey := newRSAKey(t)
+	oldMaker, err := NewJWTPublicMaker("key1", oldKey, nil, "", "")
+	require.NoError(t, err)
+
+	token, _, err := oldMaker.CreateToken(util.RandomOwner(), util.DepositorRole, uuid.New(), time.Minute)
+	require.NoError(t, err)
+
+	// the new maker signs with an Ed25519 key but still accepts tokens signed with the RSA key
+	newMaker, err := NewJWTPublicMaker("key2", newEd25519Key(t), map[string]crypto.PublicKey{
+		"key1": oldKey.Public(),
+	}, "", "")
+	require.NoError(t, err)
+
+	payload, err := newMaker.VerifyToken(token)
+	require.NoError(t, err)
+	require.NotEmpty(t, payload)
+
+	keys := newMaker.(PublicKeyMaker).PublicKeys()
+	require.Len(t, keys, 2)
+	require.Equal(t, "key1", keys[0].ID)
+	require.Equal(t, "RS256", keys[0].Algorithm)
+	require.Equal(t, "key2", keys[1].ID)
+	require.Equal(t, "EdDSA", keys[1].Algorithm)
+}
+
+func TestInvalidJWTPublicToken(t *testing.T) {
+	privateKey := newRSAKey(t)
+	maker, err := NewJWTPublicMaker("key1", privateKey, nil, "", "")
+	require.NoError(t, err)
+
+	payload, err := NewPayload(util.RandomOwner(), util.DepositorRole, uuid.New(), time.Minute)
+	require.NoError(t, err)
+
+	sign := func(method jwt.SigningMethod, keyID string, key interface{}) string {
+		jwtToken := jwt.NewWithClaims(method, newJWTClaims(payload))
+		if keyID != "" {
+			jwtToken.Header["kid"] = keyID
+		}
+		token, err := jwtToken.SignedString(key)
+		require.NoError(t, err)
+		return token
+	}
+
+	invalidTokens := map[string]string{
+		"AlgNone":            sign(jwt.SigningMethodNone, "key1", jwt.UnsafeAllowNoneSignatureType),
+		"NoKeyID":            sign(jwt.SigningMethodRS256, "", privateKey),
+		"UnknownKeyID":       sign(jwt.SigningMethodRS256, "key2", privateKey),
+		"SignedWithOtherKey": sign(jwt.SigningMethodRS256, "key1", newRSAKey(t)),
+		"OtherAlgorithm":     sign(jwt.SigningMethodRS512, "key1", privateKey),
+	}
+
+	for name, invalidToken := range invalidTokens {
+		t.Run(name, func(t *testing.T) {
+			payload, err := maker.VerifyToken(invalidToken)
+			require.EqualError(t, err, ErrInvalidToken.Error())
+			require.Nil(t, payload)
+		})
+	}
+}
+
+func TestJWTPublicMakerWeakRSAKey(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+
+	maker, err := NewJWTPublicMaker("key1", privateKey, nil, "", "")
+	require.Error(t, err)
+	require.Nil(t, maker)
+}
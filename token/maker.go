@@ -3,6 +3,8 @@ package token
 import (
 	"errors"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 var ErrInvalidToken = errors.New("token is invalid")
@@ -10,7 +12,9 @@ var ErrTokenExpired = errors.New("token is expired")
 
 // Maker is an interface for managing tokens
 type Maker interface {
-	CreateToken(username string, role string, duration time.Duration) (string, *Payload, error)
+	// CreateToken creates a new token for a specific username, role and session.
+	// Refresh tokens start a session and are created with uuid.Nil.
+	CreateToken(username string, role string, sessionID uuid.UUID, duration time.Duration) (string, *Payload, error)
 
 	// VerifyToken checks if the token is valid or not
 	VerifyToken(token string) (*Payload, error)
@@ -0,0 +1,152 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const pasetoPublicHeader = "v4.public."
+
+// pasetoFooter is sent in the clear but covered by the signature
+type pasetoFooter struct {
+	KeyID string `json:"kid"`
+}
+
+// PasetoPublicMaker creates v4.public PASETO tokens signed with Ed25519
+type PasetoPublicMaker struct {
+	keyID      string
+	privateKey ed25519.PrivateKey
+	publicKeys map[string]ed25519.PublicKey
+}
+
+// NewPasetoPublicMaker creates a PasetoPublicMaker signing tokens with privateKey under keyID.
+// Tokens signed by one of verificationKeys are accepted too, so the signing key can be rotated.
+func NewPasetoPublicMaker(keyID string, privateKey ed25519.PrivateKey, verificationKeys map[string]ed25519.PublicKey) (Maker, error) {
+	if keyID == "" {
+		return nil, fmt.Errorf("key id must not be empty")
+	}
+	if len(privateKey) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid key size: must be exactly %d bytes", ed25519.PrivateKeySize)
+	}
+
+	publicKeys := make(map[string]ed25519.PublicKey, len(verificationKeys)+1)
+	for id, key := range verificationKeys {
+		if len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid size of verification key %s: must be exactly %d bytes", id, ed25519.PublicKeySize)
+		}
+		publicKeys[id] = key
+	}
+	publicKeys[keyID] = privateKey.Public().(ed25519.PublicKey)
+
+	maker := &PasetoPublicMaker{
+		keyID:      keyID,
+		privateKey: privateKey,
+		publicKeys: publicKeys,
+	}
+	return maker, nil
+}
+
+func (maker *PasetoPublicMaker) CreateToken(username string, role string, sessionID uuid.UUID, duration time.Duration) (string, *Payload, error) {
+	payload, err := NewPayload(username, role, sessionID, duration)
+	if err != nil {
+		return "", payload, err
+	}
+
+	message, err := json.Marshal(payload)
+	if err != nil {
+		return "", payload, err
+	}
+
+	footer, err := json.Marshal(pasetoFooter{KeyID: maker.keyID})
+	if err != nil {
+		return "", payload, err
+	}
+
+	signature := ed25519.Sign(maker.privateKey, preAuthEncode([]byte(pasetoPublicHeader), message, footer, nil))
+	token := pasetoPublicHeader +
+		base64.RawURLEncoding.EncodeToString(append(message, signature...)) + "." +
+		base64.RawURLEncoding.EncodeToString(footer)
+	return token, payload, nil
+}
+
+// VerifyToken checks if the token is valid or not
+func (maker *PasetoPublicMaker) VerifyToken(token string) (*Payload, error) {
+	if !strings.HasPrefix(token, pasetoPublicHeader) {
+		return nil, ErrInvalidToken
+	}
+
+	parts := strings.Split(strings.TrimPrefix(token, pasetoPublicHeader), ".")
+	if len(parts) != 2 {
+		return nil, ErrInvalidToken
+	}
+
+	body, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil || len(body) < ed25519.SignatureSize {
+		return nil, ErrInvalidToken
+	}
+
+	footer, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	var decodedFooter pasetoFooter
+	err = json.Unmarshal(footer, &decodedFooter)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	publicKey, ok := maker.publicKeys[decodedFooter.KeyID]
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	message := body[:len(body)-ed25519.SignatureSize]
+	signature := body[len(body)-ed25519.SignatureSize:]
+	if !ed25519.Verify(publicKey, preAuthEncode([]byte(pasetoPublicHeader), message, footer, nil), signature) {
+		return nil, ErrInvalidToken
+	}
+
+	payload := &Payload{}
+	err = json.Unmarshal(message, payload)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	err = payload.Valid()
+	if err != nil {
+		return nil, ErrTokenExpired
+	}
+
+	return payload, nil
+}
+
+func (maker *PasetoPublicMaker) PublicKeys() []PublicKey {
+	keys := make([]PublicKey, 0, len(maker.publicKeys))
+	for id, key := range maker.publicKeys {
+		keys = append(keys, PublicKey{
+			ID:        id,
+			Algorithm: strings.TrimSuffix(pasetoPublicHeader, "."),
+			Key:       key,
+		})
+	}
+	return sortPublicKeys(keys)
+}
+
+// preAuthEncode is the PAE function of the PASETO specification,
+// it encodes the pieces so that no two different lists have the same encoding
+func preAuthEncode(pieces ...[]byte) []byte {
+	output := binary.LittleEndian.AppendUint64(nil, uint64(len(pieces))&^(1<<63))
+	for _, piece := range pieces {
+		output = binary.LittleEndian.AppendUint64(output, uint64(len(piece))&^(1<<63))
+		output = append(output, piece...)
+	}
+	return output
+}
@@ -0,0 +1,179 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/xmeizh/simplebank/util"
+)
+
+func newEd25519Key(t *testing.T) ed25519.PrivateKey {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return privateKey
+}
+
+func TestPasetoPublicMaker(t *testing.T) {
+	maker, err := NewPasetoPublicMaker("key1", newEd25519Key(t), nil)
+	require.NoError(t, err)
+
+	username := util.RandomOwner()
+	role := util.DepositorRole
+	sessionID := uuid.New()
+	duration := time.Minute
+
+	issueAt := time.Now()
+	expiredAt := issueAt.Add(duration)
+
+	token, payload, err := maker.CreateToken(username, role, sessionID, duration)
+	require.NoError(t, err)
+	require.NotEmpty(t, payload)
+	require.True(t, strings.HasPrefix(token, "v4.public."))
+
+	payload, err = maker.VerifyToken(token)
+	require.NoError(t, err)
+	require.NotEmpty(t, payload)
+
+	require.NotZero(t, payload.ID)
+	require.Equal(t, username, payload.Username)
+	require.Equal(t, role, payload.Role)
+	require.Equal(t, sessionID, payload.SessionID)
+	require.WithinDuration(t, issueAt, payload.IssueAt, time.Second)
+	require.WithinDuration(t, expiredAt, payload.ExpiredAt, time.Second)
+}
+
+func TestPasetoPublicTokenRegisteredTimeClaims(t *testing.T) {
+	maker, err := NewPasetoPublicMaker("key1", newEd25519Key(t), nil)
+	require.NoError(t, err)
+
+	token, payload, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, uuid.New(), time.Minute)
+	require.NoError(t, err)
+
+	// outside verifiers read the registered claims, which PASETO defines as RFC 3339 times
+	parts := strings.Split(strings.TrimPrefix(token, pasetoPublicHeader), ".")
+	require.Len(t, parts, 2)
+	body, err := base64.RawURLEncoding.DecodeString(parts[0])
+	require.NoError(t, err)
+
+	var claims map[string]any
+	require.NoError(t, json.Unmarshal(body[:len(body)-ed25519.SignatureSize], &claims))
+	for name, want := range map[string]time.Time{
+		"exp": payload.ExpiredAt,
+		"iat": payload.IssueAt,
+		"nbf": payload.IssueAt,
+	} {
+		value, ok := claims[name].(string)
+		require.True(t, ok, name)
+		got, err := time.Parse(time.RFC3339, value)
+		require.NoError(t, err)
+		require.True(t, want.Equal(got), name)
+	}
+	require.Equal(t, time.Minute, payload.ExpiredAt.Sub(payload.IssueAt))
+}
+
+func TestExpiredPasetoPublicToken(t *testing.T) {
+	maker, err := NewPasetoPublicMaker("key1", newEd25519Key(t), nil)
+	require.NoError(t, err)
+
+	token, payload, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, uuid.New(), -time.Minute)
+	require.NoError(t, err)
+	require.NotEmpty(t, token)
+	require.NotEmpty(t, payload)
+
+	payload, err = maker.VerifyToken(token)
+	require.EqualError(t, err, ErrTokenExpired.Error())
+	require.Nil(t, payload)
+}
+
+func TestPasetoPublicMakerKeyRotation(t *testing.T) {
+	oldKey := newEd25519Key(t)
+	oldMaker, err := NewPasetoPublicMaker("key1", oldKey, nil)
+	require.NoError(t, err)
+
+	token, _, err := oldMaker.CreateToken(util.RandomOwner(), util.DepositorRole, uuid.New(), time.Minute)
+	require.NoError(t, err)
+
+	// the new maker signs with key2 but still accepts tokens signed with key1
+	newMaker, err := NewPasetoPublicMaker("key2", newEd25519Key(t), map[string]ed25519.PublicKey{
+		"key1": oldKey.Public().(ed25519.PublicKey),
+	})
+	require.NoError(t, err)
+
+	payload, err := newMaker.VerifyToken(token)
+	require.NoError(t, err)
+	require.NotEmpty(t, payload)
+
+	keys := newMaker.(PublicKeyMaker).PublicKeys()
+	require.Len(t, keys, 2)
+	require.Equal(t, "key1", keys[0].ID)
+	require.Equal(t, "key2", keys[1].ID)
+	require.Equal(t, "v4.public", keys[1].Algorithm)
+
+	// once key1 is retired its tokens are rejected
+	newerMaker, err := NewPasetoPublicMaker("key2", newEd25519Key(t), nil)
+	require.NoError(t, err)
+
+	payload, err = newerMaker.VerifyToken(token)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+	require.Nil(t, payload)
+}
+
+func TestInvalidPasetoPublicToken(t *testing.T) {
+	maker, err := NewPasetoPublicMaker("key1", newEd25519Key(t), nil)
+	require.NoError(t, err)
+
+	token, _, err := maker.CreateToken(util.RandomOwner(), util.DepositorRole, uuid.New(), time.Minute)
+	require.NoError(t, err)
+	parts := strings.Split(token, ".")
+	require.Len(t, parts, 4)
+
+	otherMaker, err := NewPasetoPublicMaker("key1", newEd25519Key(t), nil)
+	require.NoError(t, err)
+	otherToken, _, err := otherMaker.CreateToken(util.RandomOwner(), util.BankerRole, uuid.New(), time.Minute)
+	require.NoError(t, err)
+	otherParts := strings.Split(otherToken, ".")
+
+	localMaker, err := NewPasetoMaker(util.RandomString(32))
+	require.NoError(t, err)
+	localToken, _, err := localMaker.CreateToken(util.RandomOwner(), util.DepositorRole, uuid.New(), time.Minute)
+	require.NoError(t, err)
+
+	invalidTokens := map[string]string{
+		"SignedWithOtherKey": otherToken,
+		"NoFooter":           strings.Join(parts[:3], "."),
+		"SwappedBody":        strings.Join([]string{parts[0], parts[1], otherParts[2], parts[3]}, "."),
+		"UnknownKeyID":       strings.Join([]string{parts[0], parts[1], parts[2], "eyJraWQiOiJrZXkyIn0"}, "."),
+		"LocalToken":         localToken,
+	}
+
+	for name, invalidToken := range invalidTokens {
+		t.Run(name, func(t *testing.T) {
+			payload, err := maker.VerifyToken(invalidToken)
+			require.EqualError(t, err, ErrInvalidToken.Error())
+			require.Nil(t, payload)
+		})
+	}
+}
+
+// TestPasetoPublicTestVector checks the signature against test vector 4-S-1 of the PASETO specification
+func TestPasetoPublicTestVector(t *testing.T) {
+	privateKey, err := hex.DecodeString("b4cbfb43df4ce210727d953e4a713307fa19bb7d9f85041438d9e11b942a3774" +
+		"1eb9dbbbbc047c03fd70604e0071f0987e16b28b757225c11f00415d0e20b1a2")
+	require.NoError(t, err)
+
+	message := []byte(`{"data":"this is a signed message","exp":"2022-01-01T00:00:00+00:00"}`)
+	expected := "v4.public.eyJkYXRhIjoidGhpcyBpcyBhIHNpZ25lZCBtZXNzYWdlIiwiZXhwIjoiMjAyMi0wMS0wMVQwMDowMDowMCswMDowMCJ9" +
+		"bg_XBBzds8lTZShVlwwKSgeKpLT3yukTw6JUz3W4h_ExsQV-P0V54zemZDcAxFaSeef1QlXEFtkqxT1ciiQEDA"
+
+	signature := ed25519.Sign(privateKey, preAuthEncode([]byte(pasetoPublicHeader), message, nil, nil))
+	token := pasetoPublicHeader + base64.RawURLEncoding.EncodeToString(append(message, signature...))
+	require.Equal(t, expected, token)
+}
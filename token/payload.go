@@ -8,53 +8,41 @@ import (
 )
 
 type Payload struct {
-	ID        uuid.UUID `json:"id"`
-	Username  string    `json:"username"`
-	Role      string    `json:"role"`
-	IssueAt   time.Time `json:"issue_at"`
-	ExpiredAt time.Time `json:"expire_at"`
-}
-
-func NewPayload(username string, role string, duration time.Duration) (*Payload, error) {
+	ID       uuid.UUID `json:"id"`
+	Username string    `json:"sub"`
+	Role     string    `json:"role"`
+	// IssueAt, NotBefore and ExpiredAt are the registered iat, nbf and exp claims,
+	// which the PASETO makers write as RFC 3339 times and the JWT makers as NumericDate
+	IssueAt   time.Time `json:"iat"`
+	NotBefore time.Time `json:"nbf"`
+	ExpiredAt time.Time `json:"exp"`
+	// SessionID is the session an access token was issued for, it is empty for refresh tokens
+	SessionID uuid.UUID `json:"session_id"`
+	// Issuer and Audience are only set by the JWT makers
+	Issuer   string           `json:"iss,omitempty"`
+	Audience jwt.ClaimStrings `json:"aud,omitempty"`
+}
+
+func NewPayload(username string, role string, sessionID uuid.UUID, duration time.Duration) (*Payload, error) {
 	tokenID, err := uuid.NewRandom()
 	if err != nil {
 		return nil, err
 	}
 
+	// NumericDate only has second precision, so the times are truncated for every maker to agree
+	issueAt := time.Now().Truncate(time.Second)
 	payload := &Payload{
 		ID:        tokenID,
 		Username:  username,
 		Role:      role,
-		IssueAt:   time.Now(),
-		ExpiredAt: time.Now().Add(duration),
+		SessionID: sessionID,
+		IssueAt:   issueAt,
+		NotBefore: issueAt,
+		ExpiredAt: issueAt.Add(duration),
 	}
 	return payload, nil
 }
 
-func (payload *Payload) GetAudience() (jwt.ClaimStrings, error) {
-	return jwt.ClaimStrings{}, nil
-}
-
-func (payload *Payload) GetExpirationTime() (*jwt.NumericDate, error) {
-	return jwt.NewNumericDate(payload.ExpiredAt), nil
-}
-
-func (payload *Payload) GetIssuedAt() (*jwt.NumericDate, error) {
-	return jwt.NewNumericDate(payload.IssueAt), nil
-}
-
-func (payload *Payload) GetIssuer() (string, error) {
-	return payload.Username, nil
-}
-
-func (payload *Payload) GetNotBefore() (*jwt.NumericDate, error) {
-	return nil, nil
-}
-
-func (payload *Payload) GetSubject() (string, error) {
-	return "", nil
-}
-
 // Valid checks if the token payload is valid or not
 func (payload *Payload) Valid() error {
 	if time.Now().After(payload.ExpiredAt) {
@@ -0,0 +1,29 @@
+package token
+
+import (
+	"crypto"
+	"sort"
+)
+
+// PublicKey is a key that verifies the tokens of a PublicKeyMaker
+type PublicKey struct {
+	ID        string
+	Algorithm string
+	Key       crypto.PublicKey
+}
+
+// PublicKeyMaker is a Maker that signs tokens with a private key,
+// so services holding only its public keys can verify them
+type PublicKeyMaker interface {
+	Maker
+
+	// PublicKeys returns every key accepted by VerifyToken, including keys that no longer sign new tokens
+	PublicKeys() []PublicKey
+}
+
+func sortPublicKeys(keys []PublicKey) []PublicKey {
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].ID < keys[j].ID
+	})
+	return keys
+}
@@ -9,19 +9,43 @@ import (
 // Config stores all configuration of the application
 // The values are read by viper from a config file or environment variables.
 type Config struct {
-	Environment          string        `mapstructure:"ENVIRONMENT"`
-	DBDriver             string        `mapstructure:"DB_DRIVER"`
-	DBSource             string        `mapstructure:"DB_SOURCE"`
-	MigrationURL         string        `mapstructure:"MIGRATION_URL"`
-	HTTPServerAddress    string        `mapstructure:"HTTP_SERVER_ADDRESS"`
-	GRPCServerAddress    string        `mapstructure:"GRPC_SERVER_ADDRESS"`
-	TokenSymmetricKey    string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
-	AccessTokenDuration  time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
-	RefreshTokenDuration time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
-	RedisAddress         string        `mapstructure:"REDIS_ADDRESS"`
-	EmailSenderName      string        `mapstructure:"EMAIL_SENDER_NAME"`
-	EmailSenderAddress   string        `mapstructure:"EMAIL_SENDER_ADDRESS"`
-	EmailSenderPassword  string        `mapstructure:"EMAIL_SENDER_PASSWORD"`
+	Environment             string        `mapstructure:"ENVIRONMENT"`
+	DBDriver                string        `mapstructure:"DB_DRIVER"`
+	DBSource                string        `mapstructure:"DB_SOURCE"`
+	MigrationURL            string        `mapstructure:"MIGRATION_URL"`
+	HTTPServerAddress       string        `mapstructure:"HTTP_SERVER_ADDRESS"`
+	GRPCServerAddress       string        `mapstructure:"GRPC_SERVER_ADDRESS"`
+	TokenFormat             string        `mapstructure:"TOKEN_FORMAT"`
+	TokenAlgorithm          string        `mapstructure:"TOKEN_ALGORITHM"`
+	TokenIssuer             string        `mapstructure:"TOKEN_ISSUER"`
+	TokenAudience           string        `mapstructure:"TOKEN_AUDIENCE"`
+	TokenSymmetricKey       string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
+	TokenKeyID              string        `mapstructure:"TOKEN_KEY_ID"`
+	TokenPrivateKeyFile     string        `mapstructure:"TOKEN_PRIVATE_KEY_FILE"`
+	TokenVerificationKeys   []string      `mapstructure:"TOKEN_VERIFICATION_KEYS"`
+	AccessTokenDuration     time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	RefreshTokenDuration    time.Duration `mapstructure:"REFRESH_TOKEN_DURATION"`
+	MFAChallengeDuration    time.Duration `mapstructure:"MFA_CHALLENGE_DURATION"`
+	TOTPIssuer              string        `mapstructure:"TOTP_ISSUER"`
+	RedisAddress            string        `mapstructure:"REDIS_ADDRESS"`
+	EmailSenderName         string        `mapstructure:"EMAIL_SENDER_NAME"`
+	EmailSenderAddress      string        `mapstructure:"EMAIL_SENDER_ADDRESS"`
+	EmailSenderPassword     string        `mapstructure:"EMAIL_SENDER_PASSWORD"`
+	PublicBaseURL           string        `mapstructure:"PUBLIC_BASE_URL"`
+	PasswordResetURL        string        `mapstructure:"PASSWORD_RESET_URL"`
+	PasswordResetCooldown   time.Duration `mapstructure:"PASSWORD_RESET_COOLDOWN"`
+	FXRatesFile             string        `mapstructure:"FX_RATES_FILE"`
+	FXRateCacheDuration     time.Duration `mapstructure:"FX_RATE_CACHE_DURATION"`
+	SchedulerInterval       time.Duration `mapstructure:"SCHEDULER_INTERVAL"`
+	OutboxRelayInterval     time.Duration `mapstructure:"OUTBOX_RELAY_INTERVAL"`
+	EventPollInterval       time.Duration `mapstructure:"EVENT_POLL_INTERVAL"`
+	WebhookDispatchInterval time.Duration `mapstructure:"WEBHOOK_DISPATCH_INTERVAL"`
+	RevocationCacheSize     int           `mapstructure:"REVOCATION_CACHE_SIZE"`
+	RevocationCacheDuration time.Duration `mapstructure:"REVOCATION_CACHE_DURATION"`
+	LoginMaxUserAttempts    int32         `mapstructure:"LOGIN_MAX_USER_ATTEMPTS"`
+	LoginMaxIPAttempts      int32         `mapstructure:"LOGIN_MAX_IP_ATTEMPTS"`
+	LoginBaseDelay          time.Duration `mapstructure:"LOGIN_BASE_DELAY"`
+	LoginLockoutDuration    time.Duration `mapstructure:"LOGIN_LOCKOUT_DURATION"`
 }
 
 // LoadConfig reads configuration from file or environment variables.
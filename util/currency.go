@@ -1,15 +1,106 @@
 package util
 
+import (
+	"fmt"
+	"sync"
+)
+
+// ISO codes of the currencies enabled out of the box
 const (
 	USD = "USD"
 	EUR = "EUR"
 	CAD = "CAD"
 )
 
-func IsSupportedCurrency(currency string) bool {
-	switch currency {
-	case USD, EUR, CAD:
-		return true
+// Currency describes a currency of the registry.
+// Amounts are always stored in minor units, e.g. cents for USD.
+type Currency struct {
+	Code       string
+	MinorUnits int32
+	Enabled    bool
+}
+
+// defaultCurrencies are used until the registry is loaded from the database
+var defaultCurrencies = []Currency{
+	{Code: USD, MinorUnits: 2, Enabled: true},
+	{Code: EUR, MinorUnits: 2, Enabled: true},
+	{Code: CAD, MinorUnits: 2, Enabled: true},
+}
+
+// CurrencyRegistry holds the currencies known to the bank
+type CurrencyRegistry struct {
+	mutex      sync.RWMutex
+	currencies map[string]Currency
+}
+
+var currencyRegistry = NewCurrencyRegistry(defaultCurrencies)
+
+// NewCurrencyRegistry creates a new CurrencyRegistry
+func NewCurrencyRegistry(currencies []Currency) *CurrencyRegistry {
+	registry := &CurrencyRegistry{}
+	registry.Load(currencies)
+	return registry
+}
+
+// Load replaces all currencies of the registry
+func (registry *CurrencyRegistry) Load(currencies []Currency) {
+	byCode := make(map[string]Currency, len(currencies))
+	for _, currency := range currencies {
+		byCode[currency.Code] = currency
+	}
+
+	registry.mutex.Lock()
+	registry.currencies = byCode
+	registry.mutex.Unlock()
+}
+
+// Get returns the currency with the given code, whether it is enabled or not
+func (registry *CurrencyRegistry) Get(code string) (Currency, bool) {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	currency, ok := registry.currencies[code]
+	return currency, ok
+}
+
+// LoadCurrencies replaces the currencies of the global registry, it is called at startup
+func LoadCurrencies(currencies []Currency) {
+	currencyRegistry.Load(currencies)
+}
+
+// GetCurrency returns the currency with the given code from the registry
+func GetCurrency(code string) (Currency, bool) {
+	return currencyRegistry.Get(code)
+}
+
+// IsSupportedCurrency returns true if the currency is enabled in the registry
+func IsSupportedCurrency(code string) bool {
+	currency, ok := currencyRegistry.Get(code)
+	return ok && currency.Enabled
+}
+
+// FormatAmount formats an amount in minor units of the currency as a decimal string, e.g. 1234 USD as "12.34".
+// Amounts of unknown currencies are formatted as plain minor units.
+func FormatAmount(amount int64, code string) string {
+	currency, ok := currencyRegistry.Get(code)
+	if !ok {
+		return formatMinorUnits(amount, 0)
+	}
+	return formatMinorUnits(amount, currency.MinorUnits)
+}
+
+func formatMinorUnits(amount int64, minorUnits int32) string {
+	if minorUnits <= 0 {
+		return fmt.Sprintf("%d", amount)
 	}
-	return false
+
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	digits := fmt.Sprintf("%0*d", minorUnits+1, amount)
+	split := len(digits) - int(minorUnits)
+	return sign + digits[:split] + "." + digits[split:]
 }
@@ -0,0 +1,68 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCurrencyRegistry(t *testing.T) {
+	registry := NewCurrencyRegistry([]Currency{
+		{Code: USD, MinorUnits: 2, Enabled: true},
+		{Code: "JPY", MinorUnits: 0, Enabled: false},
+	})
+
+	currency, ok := registry.Get(USD)
+	require.True(t, ok)
+	require.Equal(t, int32(2), currency.MinorUnits)
+
+	// disabled currencies are still known to the registry
+	currency, ok = registry.Get("JPY")
+	require.True(t, ok)
+	require.False(t, currency.Enabled)
+
+	_, ok = registry.Get(EUR)
+	require.False(t, ok)
+
+	registry.Load([]Currency{
+		{Code: "JPY", MinorUnits: 0, Enabled: true},
+	})
+
+	currency, ok = registry.Get("JPY")
+	require.True(t, ok)
+	require.Equal(t, int32(0), currency.MinorUnits)
+
+	_, ok = registry.Get(USD)
+	require.False(t, ok)
+}
+
+func TestIsSupportedCurrency(t *testing.T) {
+	require.True(t, IsSupportedCurrency(USD))
+	require.False(t, IsSupportedCurrency("JPY"))
+	require.False(t, IsSupportedCurrency("usd"))
+}
+
+func TestFormatAmount(t *testing.T) {
+	require.Equal(t, "12.34", FormatAmount(1234, USD))
+	require.Equal(t, "1234", FormatAmount(1234, "XXX"))
+}
+
+func TestFormatMinorUnits(t *testing.T) {
+	testCases := []struct {
+		amount     int64
+		minorUnits int32
+		expected   string
+	}{
+		{1234, 2, "12.34"},
+		{5, 2, "0.05"},
+		{0, 2, "0.00"},
+		{-1234, 2, "-12.34"},
+		{1234, 0, "1234"},
+		{1234, 3, "1.234"},
+		{-5, 3, "-0.005"},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, formatMinorUnits(tc.amount, tc.minorUnits))
+	}
+}
@@ -0,0 +1,46 @@
+package util
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// ErrInvalidEventOffset is returned when an event offset is malformed
+var ErrInvalidEventOffset = errors.New("invalid event offset")
+
+// EventOffset is the position of an event in an event stream.
+// Events are streamed in the order of the transactions that recorded them, then by id.
+type EventOffset struct {
+	TransactionID int64 `json:"transaction_id"`
+	ID            int64 `json:"id"`
+}
+
+// EncodeEventOffset returns an opaque offset to resume an event stream after the given position
+func EncodeEventOffset(offset EventOffset) string {
+	data, _ := json.Marshal(offset)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeEventOffset returns the position to resume an event stream after,
+// or the zero position to stream from the first event for an empty offset
+func DecodeEventOffset(encoded string) (EventOffset, error) {
+	var offset EventOffset
+	if encoded == "" {
+		return offset, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return offset, ErrInvalidEventOffset
+	}
+
+	if err := json.Unmarshal(data, &offset); err != nil {
+		return EventOffset{}, ErrInvalidEventOffset
+	}
+
+	if offset.TransactionID <= 0 || offset.ID <= 0 {
+		return EventOffset{}, ErrInvalidEventOffset
+	}
+	return offset, nil
+}
@@ -0,0 +1,35 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventOffset(t *testing.T) {
+	offset := EventOffset{
+		TransactionID: RandomInt(1, 1000),
+		ID:            RandomInt(1, 1000),
+	}
+
+	encoded := EncodeEventOffset(offset)
+	require.NotEmpty(t, encoded)
+
+	decoded, err := DecodeEventOffset(encoded)
+	require.NoError(t, err)
+	require.Equal(t, offset, decoded)
+}
+
+func TestEmptyEventOffset(t *testing.T) {
+	decoded, err := DecodeEventOffset("")
+	require.NoError(t, err)
+	require.Zero(t, decoded)
+}
+
+func TestInvalidEventOffset(t *testing.T) {
+	_, err := DecodeEventOffset("not an offset")
+	require.ErrorIs(t, err, ErrInvalidEventOffset)
+
+	_, err = DecodeEventOffset(EncodeEventOffset(EventOffset{TransactionID: 10}))
+	require.ErrorIs(t, err, ErrInvalidEventOffset)
+}
@@ -0,0 +1,16 @@
+package util
+
+import "net"
+
+// IsPublicIP reports whether the IP is routable on the internet,
+// rejecting loopback, private, link-local, multicast and unspecified addresses
+func IsPublicIP(ip net.IP) bool {
+	return ip != nil &&
+		!ip.IsLoopback() &&
+		!ip.IsPrivate() &&
+		!ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() &&
+		!ip.IsMulticast() &&
+		!ip.IsUnspecified()
+}
@@ -0,0 +1,69 @@
+package util
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrInvalidPageToken is returned when a page token is malformed or was issued for a different list request
+var ErrInvalidPageToken = errors.New("invalid page token")
+
+// PageCursor is the position of the last row of a page.
+// Lists are ordered by creation time then id, since ids can commit out of order
+// and a row committed late with a lower id than the cursor would be skipped.
+type PageCursor struct {
+	CreatedAt time.Time
+	ID        int64
+}
+
+// pageToken is the cursor behind an opaque page token
+type pageToken struct {
+	LastCreatedAt time.Time `json:"last_created_at"`
+	LastID        int64     `json:"last_id"`
+	Scope         string    `json:"scope"`
+}
+
+// EncodePageToken returns an opaque token to resume a list after the row at the cursor.
+// The scope identifies the list request, e.g. by its filters, so the token can't be used for another list.
+func EncodePageToken(scope string, cursor PageCursor) string {
+	data, _ := json.Marshal(pageToken{
+		LastCreatedAt: cursor.CreatedAt,
+		LastID:        cursor.ID,
+		Scope:         hashPageTokenScope(scope),
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodePageToken returns the cursor of the last row of the previous page, or a zero cursor for an empty token
+func DecodePageToken(token string, scope string) (PageCursor, error) {
+	if token == "" {
+		return PageCursor{}, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return PageCursor{}, ErrInvalidPageToken
+	}
+
+	var cursor pageToken
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return PageCursor{}, ErrInvalidPageToken
+	}
+
+	if cursor.LastID <= 0 || cursor.LastCreatedAt.IsZero() || cursor.Scope != hashPageTokenScope(scope) {
+		return PageCursor{}, ErrInvalidPageToken
+	}
+	return PageCursor{
+		CreatedAt: cursor.LastCreatedAt,
+		ID:        cursor.LastID,
+	}, nil
+}
+
+func hashPageTokenScope(scope string) string {
+	sum := sha256.Sum256([]byte(scope))
+	return hex.EncodeToString(sum[:8])
+}
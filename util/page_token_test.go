@@ -0,0 +1,47 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPageToken(t *testing.T) {
+	scope := "accounts:" + RandomOwner()
+	cursor := PageCursor{
+		CreatedAt: time.Now().Round(time.Microsecond),
+		ID:        RandomInt(1, 1000),
+	}
+
+	token := EncodePageToken(scope, cursor)
+	require.NotEmpty(t, token)
+
+	gotCursor, err := DecodePageToken(token, scope)
+	require.NoError(t, err)
+	require.Equal(t, cursor.ID, gotCursor.ID)
+	require.True(t, cursor.CreatedAt.Equal(gotCursor.CreatedAt))
+}
+
+func TestEmptyPageToken(t *testing.T) {
+	gotCursor, err := DecodePageToken("", "accounts:"+RandomOwner())
+	require.NoError(t, err)
+	require.Zero(t, gotCursor)
+}
+
+func TestInvalidPageToken(t *testing.T) {
+	scope := "accounts:" + RandomOwner()
+	cursor := PageCursor{CreatedAt: time.Now(), ID: 10}
+
+	_, err := DecodePageToken(EncodePageToken("accounts:"+RandomOwner(), cursor), scope)
+	require.ErrorIs(t, err, ErrInvalidPageToken)
+
+	_, err = DecodePageToken("not a token", scope)
+	require.ErrorIs(t, err, ErrInvalidPageToken)
+
+	_, err = DecodePageToken(EncodePageToken(scope, PageCursor{CreatedAt: time.Now()}), scope)
+	require.ErrorIs(t, err, ErrInvalidPageToken)
+
+	_, err = DecodePageToken(EncodePageToken(scope, PageCursor{ID: 10}), scope)
+	require.ErrorIs(t, err, ErrInvalidPageToken)
+}
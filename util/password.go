@@ -1,6 +1,10 @@
 package util
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 
 	"golang.org/x/crypto/bcrypt"
@@ -17,3 +21,32 @@ func HashPassword(password string) (string, error) {
 func CheckPassword(password string, hashedPassword string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 }
+
+// noUserHashedPassword is a bcrypt hash with the default cost that no user has
+const noUserHashedPassword = "$2a$10$WyzzXh6deowQoTYf85npDeVQOrYwQB9CN5gHr9QYUiUxG/mA/c.Ce"
+
+// CheckPasswordOfNoUser spends as long as CheckPassword and always fails.
+// It is called when a login names an unknown user, so the response time does not reveal which usernames exist.
+func CheckPasswordOfNoUser(password string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(noUserHashedPassword), []byte(password))
+	if err == nil {
+		err = bcrypt.ErrMismatchedHashAndPassword
+	}
+	return err
+}
+
+// GenerateSecretCode generates a random URL safe code for links sent by email
+func GenerateSecretCode() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate secret code: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashSecretCode hashes a code generated by GenerateSecretCode.
+// The code is random enough that a fast hash is fine, and unlike bcrypt it can be looked up.
+func HashSecretCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
@@ -26,3 +26,27 @@ func TestPassword(t *testing.T) {
 	require.NotEmpty(t, hashedPassword2)
 	require.NotEqual(t, hashedPassword1, hashedPassword2)
 }
+
+func TestPasswordOfNoUser(t *testing.T) {
+	err := CheckPasswordOfNoUser(RandomString(6))
+	require.EqualError(t, err, bcrypt.ErrMismatchedHashAndPassword.Error())
+
+	cost, err := bcrypt.Cost([]byte(noUserHashedPassword))
+	require.NoError(t, err)
+	require.Equal(t, bcrypt.DefaultCost, cost)
+}
+
+func TestSecretCode(t *testing.T) {
+	code1, err := GenerateSecretCode()
+	require.NoError(t, err)
+	require.Len(t, code1, 43)
+
+	code2, err := GenerateSecretCode()
+	require.NoError(t, err)
+	require.NotEqual(t, code1, code2)
+
+	hashedCode := HashSecretCode(code1)
+	require.Len(t, hashedCode, 64)
+	require.Equal(t, hashedCode, HashSecretCode(code1))
+	require.NotEqual(t, hashedCode, HashSecretCode(code2))
+}
@@ -2,13 +2,32 @@ package val
 
 import (
 	"fmt"
+	"net"
 	"net/mail"
+	"net/url"
 	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xmeizh/simplebank/util"
+)
+
+// maxStatementPeriod is the longest period an account statement can cover
+const maxStatementPeriod = 366 * 24 * time.Hour
+
+// scheduled transfers repeat at most every minute and at least once a year
+const (
+	minScheduleInterval = 60
+	maxScheduleInterval = 366 * 24 * 60 * 60
 )
 
 var (
 	isValidUsername = regexp.MustCompile(`^[a-z0-9_]+$`).MatchString
 	isValidFullName = regexp.MustCompile(`^[a-zA-Z\s]+$`).MatchString
+	isValidTOTPCode = regexp.MustCompile(`^[0-9]{6}$`).MatchString
+	isValidRole     = regexp.MustCompile(`^[a-z_]+$`).MatchString
+	isValidLocale   = regexp.MustCompile(`^[a-z]{2}(-[A-Z]{2})?$`).MatchString
 )
 
 func ValidateString(value string, minLength int, maxLength int) error {
@@ -31,6 +50,17 @@ func ValidateUsername(value string) error {
 	return nil
 }
 
+func ValidateRole(value string) error {
+	if err := ValidateString(value, 3, 50); err != nil {
+		return err
+	}
+
+	if !isValidRole(value) {
+		return fmt.Errorf("must contain only lower letters or underscore")
+	}
+	return nil
+}
+
 func ValidatePassword(value string) error {
 	return ValidateString(value, 6, 100)
 }
@@ -64,6 +94,154 @@ func ValidateEmailId(value int64) error {
 	return nil
 }
 
+func ValidatePasswordResetID(value int64) error {
+	if value <= 0 {
+		return fmt.Errorf("must be a positive integer")
+	}
+	return nil
+}
+
+func ValidateAccountUnlockID(value int64) error {
+	if value <= 0 {
+		return fmt.Errorf("must be a positive integer")
+	}
+	return nil
+}
+
 func ValidateSecretCode(value string) error {
 	return ValidateString(value, 32, 128)
 }
+
+func ValidateAccountID(value int64) error {
+	if value <= 0 {
+		return fmt.Errorf("must be a positive integer")
+	}
+	return nil
+}
+
+func ValidateScheduledTransferID(value int64) error {
+	if value <= 0 {
+		return fmt.Errorf("must be a positive integer")
+	}
+	return nil
+}
+
+func ValidateWebhookDeliveryID(value int64) error {
+	if value <= 0 {
+		return fmt.Errorf("must be a positive integer")
+	}
+	return nil
+}
+
+func ValidateWebhookURL(value string) error {
+	if err := ValidateString(value, 10, 2048); err != nil {
+		return err
+	}
+
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("must be an absolute http or https URL")
+	}
+
+	// hosts resolving to internal addresses are also refused when a delivery connects
+	host := u.Hostname()
+	if ip := net.ParseIP(host); strings.EqualFold(host, "localhost") || (ip != nil && !util.IsPublicIP(ip)) {
+		return fmt.Errorf("must be a public address")
+	}
+	return nil
+}
+
+func ValidateSessionID(value string) error {
+	if _, err := uuid.Parse(value); err != nil {
+		return fmt.Errorf("must be a valid UUID")
+	}
+	return nil
+}
+
+func ValidateTOTPCode(value string) error {
+	if !isValidTOTPCode(value) {
+		return fmt.Errorf("must contain 6 digits")
+	}
+	return nil
+}
+
+// ValidateMFACode accepts a TOTP code or a recovery code, typed with or without its separator
+func ValidateMFACode(value string) error {
+	return ValidateString(value, 6, 20)
+}
+
+func ValidateCurrency(value string) error {
+	if !util.IsSupportedCurrency(value) {
+		return fmt.Errorf("is not a supported currency")
+	}
+	return nil
+}
+
+func ValidateLocale(value string) error {
+	if !isValidLocale(value) {
+		return fmt.Errorf("must be a language code with an optional region, like en or fr-CA")
+	}
+	return nil
+}
+
+func ValidatePageID(value int32) error {
+	if value < 1 {
+		return fmt.Errorf("must be at least 1")
+	}
+	return nil
+}
+
+func ValidatePageSize(value int32) error {
+	if value < 5 || value > 10 {
+		return fmt.Errorf("must be between 5 and 10")
+	}
+	return nil
+}
+
+func ValidateOptionalPageSize(value int32) error {
+	if value < 0 {
+		return fmt.Errorf("must not be negative")
+	}
+	return nil
+}
+
+func ValidateAmount(value int64) error {
+	if value <= 0 {
+		return fmt.Errorf("must be a positive integer")
+	}
+	return nil
+}
+
+func ValidateScheduleInterval(value int64) error {
+	if value != 0 && (value < minScheduleInterval || value > maxScheduleInterval) {
+		return fmt.Errorf("must be 0 or from %d-%d seconds", minScheduleInterval, maxScheduleInterval)
+	}
+	return nil
+}
+
+func ValidateIdempotencyKey(value string) error {
+	return ValidateString(value, 1, 100)
+}
+
+func ValidateStatementPeriod(fromTime time.Time, toTime time.Time) error {
+	if !toTime.After(fromTime) {
+		return fmt.Errorf("must end after it starts")
+	}
+
+	if toTime.Sub(fromTime) > maxStatementPeriod {
+		return fmt.Errorf("must not be longer than %d days", maxStatementPeriod/(24*time.Hour))
+	}
+	return nil
+}
+
+func ValidateStatementMonth(year int32, month int32) error {
+	if month < 1 || month > 12 {
+		return fmt.Errorf("must be between 1 and 12")
+	}
+
+	now := time.Now().UTC()
+	if year < 2000 || time.Date(int(year), time.Month(month), 1, 0, 0, 0, 0, time.UTC).After(now) {
+		return fmt.Errorf("must not be in the future")
+	}
+	return nil
+}
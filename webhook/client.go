@@ -0,0 +1,51 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/xmeizh/simplebank/util"
+)
+
+// ErrForbiddenAddress is returned when an endpoint resolves to an address that isn't public
+var ErrForbiddenAddress = errors.New("endpoint address is not public")
+
+// NewClient returns the client deliveries are sent with.
+// It only connects to public addresses, which is checked at connect time so a DNS record
+// changed after the endpoint was registered can't point it at the internal network,
+// and it doesn't follow redirects, which could lead there too.
+func NewClient(timeout time.Duration) *http.Client {
+	return newClient(timeout, util.IsPublicIP)
+}
+
+func newClient(timeout time.Duration, isAllowedIP func(net.IP) bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network string, address string, conn syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isAllowedIP(ip) {
+				return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
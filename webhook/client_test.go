@@ -0,0 +1,46 @@
+package webhook
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientRejectsLoopback(t *testing.T) {
+	received := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received++
+	}))
+	defer server.Close()
+
+	_, err := NewClient(time.Second).Post(server.URL, "application/json", nil)
+	require.ErrorIs(t, err, ErrForbiddenAddress)
+	require.Zero(t, received)
+}
+
+func TestClientDoesNotFollowRedirects(t *testing.T) {
+	received := 0
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received++
+	}))
+	defer internal.Close()
+
+	redirecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, internal.URL, http.StatusFound)
+	}))
+	defer redirecting.Close()
+
+	// the test servers listen on loopback, which this client connects to,
+	// so only not following the redirect keeps the request from reaching the internal one
+	client := newClient(time.Second, func(ip net.IP) bool { return true })
+
+	res, err := client.Post(redirecting.URL, "application/json", nil)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	require.Equal(t, http.StatusFound, res.StatusCode)
+	require.Zero(t, received)
+}
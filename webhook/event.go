@@ -0,0 +1,15 @@
+package webhook
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Event is the JSON body POSTed to the endpoints
+type Event struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	AccountID int64           `json:"account_id,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
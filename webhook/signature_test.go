@@ -0,0 +1,97 @@
+package webhook
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xmeizh/simplebank/util"
+)
+
+func TestSignature(t *testing.T) {
+	secret := util.RandomString(32)
+	body := []byte(`{"id":1,"type":"account_opened"}`)
+	timestamp := time.Now().Unix()
+
+	signature := Sign(secret, timestamp, body)
+	require.Equal(t, signature, Sign(secret, timestamp, body))
+
+	err := Verify(secret, strconv.FormatInt(timestamp, 10), signature, body, time.Minute)
+	require.NoError(t, err)
+}
+
+func TestSignatureInvalid(t *testing.T) {
+	secret := util.RandomString(32)
+	body := []byte(`{"id":1,"type":"account_opened"}`)
+	timestamp := time.Now().Unix()
+	signature := Sign(secret, timestamp, body)
+	timestampHeader := strconv.FormatInt(timestamp, 10)
+
+	testCases := []struct {
+		name      string
+		secret    string
+		timestamp string
+		signature string
+		body      []byte
+	}{
+		{
+			name:      "WrongSecret",
+			secret:    util.RandomString(32),
+			timestamp: timestampHeader,
+			signature: signature,
+			body:      body,
+		},
+		{
+			name:      "TamperedBody",
+			secret:    secret,
+			timestamp: timestampHeader,
+			signature: signature,
+			body:      []byte(`{"id":2,"type":"account_opened"}`),
+		},
+		{
+			name:      "TamperedTimestamp",
+			secret:    secret,
+			timestamp: strconv.FormatInt(timestamp+1, 10),
+			signature: signature,
+			body:      body,
+		},
+		{
+			name:      "MalformedTimestamp",
+			secret:    secret,
+			timestamp: "yesterday",
+			signature: signature,
+			body:      body,
+		},
+		{
+			name:      "MissingPrefix",
+			secret:    secret,
+			timestamp: timestampHeader,
+			signature: signature[len(signaturePrefix):],
+			body:      body,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			err := Verify(tc.secret, tc.timestamp, tc.signature, tc.body, time.Minute)
+			require.ErrorIs(t, err, ErrInvalidSignature)
+		})
+	}
+}
+
+func TestSignatureExpired(t *testing.T) {
+	secret := util.RandomString(32)
+	body := []byte(`{"id":1,"type":"account_opened"}`)
+	timestamp := time.Now().Add(-time.Hour).Unix()
+
+	signature := Sign(secret, timestamp, body)
+	err := Verify(secret, strconv.FormatInt(timestamp, 10), signature, body, time.Minute)
+	require.ErrorIs(t, err, ErrExpiredTimestamp)
+
+	// a zero tolerance accepts any timestamp
+	err = Verify(secret, strconv.FormatInt(timestamp, 10), signature, body, 0)
+	require.NoError(t, err)
+}
@@ -2,16 +2,55 @@ package worker
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
 )
 
 type TaskDistributor interface {
+	// DistributeTask enqueues a task whose payload is already encoded
+	DistributeTask(
+		ctx context.Context,
+		taskType string,
+		payload []byte,
+		opts ...asynq.Option,
+	) error
 	DistributeTaskSendVerificationEmail(
 		ctx context.Context,
 		payload *SendVerificationEmailPayload,
 		opts ...asynq.Option,
 	) error
+	DistributeTaskSendPasswordResetEmail(
+		ctx context.Context,
+		payload *SendPasswordResetEmailPayload,
+		opts ...asynq.Option,
+	) error
+	DistributeTaskSendUnlockAccountEmail(
+		ctx context.Context,
+		payload *SendUnlockAccountEmailPayload,
+		opts ...asynq.Option,
+	) error
+	DistributeTaskExecuteScheduledTransfer(
+		ctx context.Context,
+		payload *ExecuteScheduledTransferPayload,
+		opts ...asynq.Option,
+	) error
+	DistributeTaskSendAccountStatement(
+		ctx context.Context,
+		payload *SendAccountStatementPayload,
+		opts ...asynq.Option,
+	) error
+	DistributeTaskDeliverWebhook(
+		ctx context.Context,
+		payload *DeliverWebhookPayload,
+		opts ...asynq.Option,
+	) error
+	DistributeTaskSendTransferNotification(
+		ctx context.Context,
+		payload *SendTransferNotificationPayload,
+		opts ...asynq.Option,
+	) error
 }
 
 type RedisTaskDistributor struct {
@@ -23,3 +62,21 @@ func NewRedisTaskDistributor(redisOpt asynq.RedisClientOpt) TaskDistributor {
 		client: asynq.NewClient(redisOpt),
 	}
 }
+
+func (distributor *RedisTaskDistributor) DistributeTask(
+	ctx context.Context,
+	taskType string,
+	payload []byte,
+	opts ...asynq.Option,
+) error {
+	task := asynq.NewTask(taskType, payload, opts...)
+	info, err := distributor.client.EnqueueContext(ctx, task)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue task: %w", err)
+	}
+
+	log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).
+		Str("queue", info.Queue).Int("max_retry", info.MaxRetry).Msg("enqueued task")
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	db "github.com/xmeizh/simplebank/db/postgresql"
+)
+
+// OutboxTaskDistributor writes tasks to the outbox table instead of enqueuing them.
+// Given the queries of a transaction, its tasks are only published by the OutboxRelay
+// once that transaction commits.
+type OutboxTaskDistributor struct {
+	q db.Querier
+}
+
+func NewOutboxTaskDistributor(q db.Querier) TaskDistributor {
+	return &OutboxTaskDistributor{
+		q: q,
+	}
+}
+
+// DistributeTask writes the task to the outbox.
+// Only the queue, max retry and process at/in options can be stored with it.
+func (distributor *OutboxTaskDistributor) DistributeTask(
+	ctx context.Context,
+	taskType string,
+	payload []byte,
+	opts ...asynq.Option,
+) error {
+	arg := db.CreateOutboxMessageParams{
+		TaskType: taskType,
+		Payload:  payload,
+	}
+
+	for _, opt := range opts {
+		switch opt.Type() {
+		case asynq.QueueOpt:
+			arg.Queue = sql.NullString{String: opt.Value().(string), Valid: true}
+		case asynq.MaxRetryOpt:
+			arg.MaxRetry = sql.NullInt32{Int32: int32(opt.Value().(int)), Valid: true}
+		case asynq.ProcessAtOpt:
+			arg.ProcessAt = sql.NullTime{Time: opt.Value().(time.Time), Valid: true}
+		case asynq.ProcessInOpt:
+			arg.ProcessAt = sql.NullTime{Time: time.Now().Add(opt.Value().(time.Duration)), Valid: true}
+		default:
+			return fmt.Errorf("unsupported outbox task option: %s", opt)
+		}
+	}
+
+	_, err := distributor.q.CreateOutboxMessage(ctx, arg)
+	if err != nil {
+		return fmt.Errorf("failed to write task to outbox: %w", err)
+	}
+	return nil
+}
+
+func (distributor *OutboxTaskDistributor) distributeJSON(
+	ctx context.Context,
+	taskType string,
+	payload any,
+	opts ...asynq.Option,
+) error {
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task payload: %w", err)
+	}
+	return distributor.DistributeTask(ctx, taskType, jsonPayload, opts...)
+}
+
+func (distributor *OutboxTaskDistributor) DistributeTaskSendVerificationEmail(
+	ctx context.Context,
+	payload *SendVerificationEmailPayload,
+	opts ...asynq.Option,
+) error {
+	return distributor.distributeJSON(ctx, TaskSendVerificationEmail, payload, opts...)
+}
+
+func (distributor *OutboxTaskDistributor) DistributeTaskSendPasswordResetEmail(
+	ctx context.Context,
+	payload *SendPasswordResetEmailPayload,
+	opts ...asynq.Option,
+) error {
+	return distributor.distributeJSON(ctx, TaskSendPasswordResetEmail, payload, opts...)
+}
+
+func (distributor *OutboxTaskDistributor) DistributeTaskSendUnlockAccountEmail(
+	ctx context.Context,
+	payload *SendUnlockAccountEmailPayload,
+	opts ...asynq.Option,
+) error {
+	return distributor.distributeJSON(ctx, TaskSendUnlockAccountEmail, payload, opts...)
+}
+
+func (distributor *OutboxTaskDistributor) DistributeTaskExecuteScheduledTransfer(
+	ctx context.Context,
+	payload *ExecuteScheduledTransferPayload,
+	opts ...asynq.Option,
+) error {
+	return distributor.distributeJSON(ctx, TaskExecuteScheduledTransfer, payload, opts...)
+}
+
+func (distributor *OutboxTaskDistributor) DistributeTaskSendAccountStatement(
+	ctx context.Context,
+	payload *SendAccountStatementPayload,
+	opts ...asynq.Option,
+) error {
+	return distributor.distributeJSON(ctx, TaskSendAccountStatement, payload, opts...)
+}
+
+func (distributor *OutboxTaskDistributor) DistributeTaskDeliverWebhook(
+	ctx context.Context,
+	payload *DeliverWebhookPayload,
+	opts ...asynq.Option,
+) error {
+	return distributor.distributeJSON(ctx, TaskDeliverWebhook, payload, opts...)
+}
+
+func (distributor *OutboxTaskDistributor) DistributeTaskSendTransferNotification(
+	ctx context.Context,
+	payload *SendTransferNotificationPayload,
+	opts ...asynq.Option,
+) error {
+	return distributor.distributeJSON(ctx, TaskSendTransferNotification, payload, opts...)
+}
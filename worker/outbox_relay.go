@@ -0,0 +1,130 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+	db "github.com/xmeizh/simplebank/db/postgresql"
+)
+
+const (
+	// outboxBatchSize is the max number of outbox messages published per tick
+	outboxBatchSize = 100
+	// outboxRetention is how long sent outbox messages are kept before they are deleted
+	outboxRetention = 24 * time.Hour
+	// outboxMaxAttempts is how many times a message fails to publish before it's dead,
+	// which spans about a day with outboxRetryDelay
+	outboxMaxAttempts = 20
+)
+
+// OutboxRelay periodically publishes the pending outbox messages to the task distributor.
+// A message is marked sent after it's enqueued, so it's delivered at least once:
+// if marking it fails, it's published again with the same task ID, which asynq rejects
+// as long as the first task is still queued.
+type OutboxRelay struct {
+	store       db.Store
+	distributor TaskDistributor
+	interval    time.Duration
+}
+
+func NewOutboxRelay(store db.Store, distributor TaskDistributor, interval time.Duration) *OutboxRelay {
+	return &OutboxRelay{
+		store:       store,
+		distributor: distributor,
+		interval:    interval,
+	}
+}
+
+// Start runs the relay until the context is done
+func (relay *OutboxRelay) Start(ctx context.Context) error {
+	ticker := time.NewTicker(relay.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			err := relay.RelayPendingMessages(ctx)
+			if err != nil {
+				log.Error().Err(err).Msg("failed to relay outbox messages")
+			}
+
+			err = relay.DeleteSentMessages(ctx)
+			if err != nil {
+				log.Error().Err(err).Msg("failed to delete sent outbox messages")
+			}
+		}
+	}
+}
+
+// RelayPendingMessages publishes a batch of the oldest pending outbox messages
+func (relay *OutboxRelay) RelayPendingMessages(ctx context.Context) error {
+	result, err := relay.store.RelayOutboxTx(ctx, db.RelayOutboxTxParams{
+		Limit: outboxBatchSize,
+		Publish: func(message db.Outbox) error {
+			return relay.publish(ctx, message)
+		},
+		MaxAttempts: outboxMaxAttempts,
+		RetryDelay:  outboxRetryDelay,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to relay outbox messages: %w", err)
+	}
+
+	if result.Dead > 0 {
+		log.Error().Int("dead", result.Dead).Msg("some outbox messages failed too many times and won't be relayed")
+	}
+	if result.Failed > 0 {
+		log.Warn().Int("sent", result.Sent).Int("failed", result.Failed).Msg("some outbox messages failed to publish")
+	}
+	return nil
+}
+
+// outboxRetryDelay doubles the delay between the attempts to publish a message,
+// starting at 1 second and capped at 2 hours
+func outboxRetryDelay(attempts int32) time.Duration {
+	delay := time.Second
+	for i := int32(1); i < attempts && delay < 2*time.Hour; i++ {
+		delay *= 2
+	}
+	return min(delay, 2*time.Hour)
+}
+
+func (relay *OutboxRelay) publish(ctx context.Context, message db.Outbox) error {
+	opts := []asynq.Option{
+		asynq.TaskID(fmt.Sprintf("outbox:%d", message.ID)),
+	}
+	if message.Queue.Valid {
+		opts = append(opts, asynq.Queue(message.Queue.String))
+	}
+	if message.MaxRetry.Valid {
+		opts = append(opts, asynq.MaxRetry(int(message.MaxRetry.Int32)))
+	}
+	if message.ProcessAt.Valid {
+		opts = append(opts, asynq.ProcessAt(message.ProcessAt.Time))
+	}
+
+	err := relay.distributor.DistributeTask(ctx, message.TaskType, message.Payload, opts...)
+	if err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) {
+		return err
+	}
+	return nil
+}
+
+// DeleteSentMessages deletes the outbox messages sent before the retention period
+func (relay *OutboxRelay) DeleteSentMessages(ctx context.Context) error {
+	_, err := relay.store.DeleteSentOutboxMessages(ctx, sql.NullTime{
+		Time:  time.Now().Add(-outboxRetention),
+		Valid: true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete sent outbox messages: %w", err)
+	}
+	return nil
+}
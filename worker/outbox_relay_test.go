@@ -0,0 +1,16 @@
+package worker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutboxRetryDelay(t *testing.T) {
+	require.Equal(t, time.Second, outboxRetryDelay(1))
+	require.Equal(t, 2*time.Second, outboxRetryDelay(2))
+	require.Equal(t, 8*time.Second, outboxRetryDelay(4))
+	require.Equal(t, 2*time.Hour, outboxRetryDelay(outboxMaxAttempts))
+	require.Equal(t, 2*time.Hour, outboxRetryDelay(100))
+}
@@ -2,12 +2,16 @@ package worker
 
 import (
 	"context"
+	"net/http"
 
 	"github.com/hibiken/asynq"
 	"github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog/log"
 	db "github.com/xmeizh/simplebank/db/postgresql"
 	"github.com/xmeizh/simplebank/mail"
+	"github.com/xmeizh/simplebank/mail/templates"
+	"github.com/xmeizh/simplebank/util"
+	"github.com/xmeizh/simplebank/webhook"
 )
 
 const (
@@ -20,43 +24,70 @@ type TaskProcessor interface {
 	// Shutdown gracefully shuts down the task processor
 	Shutdown()
 	ProcessTaskSendVerificationEmail(ctx context.Context, task *asynq.Task) error
+	ProcessTaskSendPasswordResetEmail(ctx context.Context, task *asynq.Task) error
+	ProcessTaskSendUnlockAccountEmail(ctx context.Context, task *asynq.Task) error
+	ProcessTaskExecuteScheduledTransfer(ctx context.Context, task *asynq.Task) error
+	ProcessTaskSendAccountStatement(ctx context.Context, task *asynq.Task) error
+	ProcessTaskDeliverWebhook(ctx context.Context, task *asynq.Task) error
+	ProcessTaskSendTransferNotification(ctx context.Context, task *asynq.Task) error
 }
 
 type RedisTaskProcessor struct {
+	config     util.Config
 	server     *asynq.Server
 	store      db.Store
 	mailSender mail.EmailSender
+	templates  *templates.Renderer
+	httpClient *http.Client
 }
 
-func NewRedisTaskProcessor(redisOpt asynq.RedisClientOpt, store db.Store, mailSender mail.EmailSender) TaskProcessor {
+func NewRedisTaskProcessor(config util.Config, redisOpt asynq.RedisClientOpt, store db.Store, mailSender mail.EmailSender, renderer *templates.Renderer) TaskProcessor {
 	redis.SetLogger(NewLogger())
 
-	server := asynq.NewServer(
+	processor := &RedisTaskProcessor{
+		config:     config,
+		store:      store,
+		mailSender: mailSender,
+		templates:  renderer,
+		httpClient: webhook.NewClient(webhookRequestTimeout),
+	}
+
+	processor.server = asynq.NewServer(
 		redisOpt,
 		asynq.Config{
 			Queues: map[string]int{
 				QueueCritical: 10,
 				QueueDefault:  5,
 			},
-			ErrorHandler: asynq.ErrorHandlerFunc(func(ctx context.Context, task *asynq.Task, err error) {
-				log.Error().Err(err).Str("type", task.Type()).
-					Bytes("payload", task.Payload()).
-					Msg("process task failed")
-			}),
-			Logger: NewLogger(),
+			ErrorHandler:   asynq.ErrorHandlerFunc(processor.handleError),
+			RetryDelayFunc: retryDelay,
+			Logger:         NewLogger(),
 		},
 	)
 
-	return &RedisTaskProcessor{
-		server:     server,
-		store:      store,
-		mailSender: mailSender,
+	return processor
+}
+
+// handleError is called whenever a task fails, before it's retried or archived
+func (processor *RedisTaskProcessor) handleError(ctx context.Context, task *asynq.Task, err error) {
+	log.Error().Err(err).Str("type", task.Type()).
+		Bytes("payload", task.Payload()).
+		Msg("process task failed")
+
+	if task.Type() == TaskDeliverWebhook {
+		processor.handleDeliverWebhookError(ctx, task, err)
 	}
 }
 
 func (processor *RedisTaskProcessor) Start() error {
 	mux := asynq.NewServeMux()
 	mux.HandleFunc(TaskSendVerificationEmail, processor.ProcessTaskSendVerificationEmail)
+	mux.HandleFunc(TaskSendPasswordResetEmail, processor.ProcessTaskSendPasswordResetEmail)
+	mux.HandleFunc(TaskSendUnlockAccountEmail, processor.ProcessTaskSendUnlockAccountEmail)
+	mux.HandleFunc(TaskExecuteScheduledTransfer, processor.ProcessTaskExecuteScheduledTransfer)
+	mux.HandleFunc(TaskSendAccountStatement, processor.ProcessTaskSendAccountStatement)
+	mux.HandleFunc(TaskDeliverWebhook, processor.ProcessTaskDeliverWebhook)
+	mux.HandleFunc(TaskSendTransferNotification, processor.ProcessTaskSendTransferNotification)
 	return processor.server.Start(mux)
 }
 
@@ -0,0 +1,78 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+	db "github.com/xmeizh/simplebank/db/postgresql"
+)
+
+// dueScheduledTransfersBatchSize is the max number of scheduled transfers enqueued per tick
+const dueScheduledTransfersBatchSize = 100
+
+// ScheduledTransferScheduler periodically enqueues a task for every scheduled transfer that is due
+type ScheduledTransferScheduler struct {
+	store       db.Store
+	distributor TaskDistributor
+	interval    time.Duration
+}
+
+func NewScheduledTransferScheduler(store db.Store, distributor TaskDistributor, interval time.Duration) *ScheduledTransferScheduler {
+	return &ScheduledTransferScheduler{
+		store:       store,
+		distributor: distributor,
+		interval:    interval,
+	}
+}
+
+// Start runs the scheduler until the context is done
+func (scheduler *ScheduledTransferScheduler) Start(ctx context.Context) error {
+	ticker := time.NewTicker(scheduler.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			err := scheduler.EnqueueDueTransfers(ctx)
+			if err != nil {
+				log.Error().Err(err).Msg("failed to enqueue due scheduled transfers")
+			}
+		}
+	}
+}
+
+// EnqueueDueTransfers enqueues the scheduled transfers whose next run is due.
+// A run that is still waiting in the queue isn't enqueued again.
+func (scheduler *ScheduledTransferScheduler) EnqueueDueTransfers(ctx context.Context) error {
+	scheduledTransfers, err := scheduler.store.ListDueScheduledTransfers(ctx, db.ListDueScheduledTransfersParams{
+		NextRunAt: time.Now(),
+		Limit:     dueScheduledTransfersBatchSize,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list due scheduled transfers: %w", err)
+	}
+
+	for _, scheduledTransfer := range scheduledTransfers {
+		payload := &ExecuteScheduledTransferPayload{
+			ScheduledTransferID: scheduledTransfer.ID,
+			RunAt:               scheduledTransfer.NextRunAt,
+		}
+		opts := []asynq.Option{
+			asynq.MaxRetry(10),
+			asynq.Queue(QueueCritical),
+			asynq.Unique(time.Hour),
+		}
+
+		err = scheduler.distributor.DistributeTaskExecuteScheduledTransfer(ctx, payload, opts...)
+		if err != nil && !errors.Is(err, asynq.ErrDuplicateTask) {
+			return fmt.Errorf("failed to distribute task to execute scheduled transfer [%d]: %w", scheduledTransfer.ID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/statement"
+)
+
+// statementAccountsBatchSize is the max number of accounts loaded at once when enqueuing monthly statements
+const statementAccountsBatchSize = 100
+
+// MonthlyStatementScheduler enqueues a statement for every account of a verified user
+// each time a month ends
+type MonthlyStatementScheduler struct {
+	store       db.Store
+	distributor TaskDistributor
+}
+
+func NewMonthlyStatementScheduler(store db.Store, distributor TaskDistributor) *MonthlyStatementScheduler {
+	return &MonthlyStatementScheduler{
+		store:       store,
+		distributor: distributor,
+	}
+}
+
+// Start runs the scheduler until the context is done.
+// Statements of a month are only enqueued if the scheduler is running when that month ends.
+func (scheduler *MonthlyStatementScheduler) Start(ctx context.Context) error {
+	for {
+		month, nextMonth := statement.MonthPeriod(time.Now())
+		timer := time.NewTimer(time.Until(nextMonth))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+			err := scheduler.EnqueueMonthlyStatements(ctx, month)
+			if err != nil {
+				log.Error().Err(err).Time("month", month).Msg("failed to enqueue monthly statements")
+			}
+		}
+	}
+}
+
+// EnqueueMonthlyStatements enqueues the statements of the given month.
+// A statement that was enqueued in the last day isn't enqueued again.
+func (scheduler *MonthlyStatementScheduler) EnqueueMonthlyStatements(ctx context.Context, month time.Time) error {
+	fromTime, toTime := statement.MonthPeriod(month)
+
+	var afterID int64
+	for {
+		accounts, err := scheduler.store.ListVerifiedOwnerAccounts(ctx, db.ListVerifiedOwnerAccountsParams{
+			CreatedBefore: toTime,
+			AfterID:       afterID,
+			Limit:         statementAccountsBatchSize,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list accounts: %w", err)
+		}
+
+		for _, account := range accounts {
+			payload := &SendAccountStatementPayload{
+				AccountID: account.ID,
+				Month:     fromTime,
+			}
+			opts := []asynq.Option{
+				asynq.MaxRetry(10),
+				asynq.Queue(QueueDefault),
+				asynq.Unique(24 * time.Hour),
+			}
+
+			err = scheduler.distributor.DistributeTaskSendAccountStatement(ctx, payload, opts...)
+			if err != nil && !errors.Is(err, asynq.ErrDuplicateTask) {
+				return fmt.Errorf("failed to distribute task to send statement of account [%d]: %w", account.ID, err)
+			}
+		}
+
+		if len(accounts) < statementAccountsBatchSize {
+			return nil
+		}
+		afterID = accounts[len(accounts)-1].ID
+	}
+}
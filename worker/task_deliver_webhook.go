@@ -0,0 +1,196 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/webhook"
+)
+
+const TaskDeliverWebhook = "task:deliver_webhook"
+
+const (
+	// webhookMaxRetry is how many times a delivery is retried before it's marked failed,
+	// which spans about half a day with webhookRetryDelay
+	webhookMaxRetry = 12
+	// webhookRequestTimeout is how long an endpoint has to respond to a delivery
+	webhookRequestTimeout = 10 * time.Second
+)
+
+type DeliverWebhookPayload struct {
+	DeliveryID int64 `json:"delivery_id"`
+}
+
+func (distributor *RedisTaskDistributor) DistributeTaskDeliverWebhook(
+	ctx context.Context,
+	payload *DeliverWebhookPayload,
+	opts ...asynq.Option,
+) error {
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task payload: %w", err)
+	}
+	return distributor.DistributeTask(ctx, TaskDeliverWebhook, jsonPayload, opts...)
+}
+
+// deliverWebhookOptions enqueues a delivery at most once and retries it with webhookRetryDelay
+func deliverWebhookOptions(deliveryID int64) []asynq.Option {
+	return []asynq.Option{
+		asynq.TaskID(fmt.Sprintf("webhook_delivery:%d", deliveryID)),
+		asynq.MaxRetry(webhookMaxRetry),
+		asynq.Queue(QueueDefault),
+	}
+}
+
+// webhookRetryDelay doubles the delay between the attempts of a delivery,
+// starting at 10 seconds and capped at 6 hours
+func webhookRetryDelay(retried int) time.Duration {
+	delay := 10 * time.Second
+	for i := 0; i < retried && delay < 6*time.Hour; i++ {
+		delay *= 2
+	}
+	return min(delay, 6*time.Hour)
+}
+
+// retryDelay is the retry delay of the tasks, the webhook deliveries back off exponentially
+func retryDelay(retried int, err error, task *asynq.Task) time.Duration {
+	if task.Type() == TaskDeliverWebhook {
+		return webhookRetryDelay(retried)
+	}
+	return asynq.DefaultRetryDelayFunc(retried, err, task)
+}
+
+func (processor *RedisTaskProcessor) ProcessTaskDeliverWebhook(ctx context.Context, task *asynq.Task) error {
+	var payload DeliverWebhookPayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", asynq.SkipRetry)
+	}
+
+	retried, _ := asynq.GetRetryCount(ctx)
+	maxRetry, ok := asynq.GetMaxRetry(ctx)
+	lastAttempt := ok && retried >= maxRetry
+
+	err := processor.deliverWebhook(ctx, payload.DeliveryID, lastAttempt)
+	if err != nil {
+		return err
+	}
+
+	log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).
+		Msg("processed task")
+	return nil
+}
+
+// handleDeliverWebhookError marks the delivery failed once its task is archived,
+// in case the task gave up without recording its last attempt, e.g. because the database wasn't reachable.
+// Otherwise the delivery would stay pending, and the dispatcher can't enqueue it again while the archived task keeps its ID.
+func (processor *RedisTaskProcessor) handleDeliverWebhookError(ctx context.Context, task *asynq.Task, err error) {
+	retried, _ := asynq.GetRetryCount(ctx)
+	maxRetry, ok := asynq.GetMaxRetry(ctx)
+	lastAttempt := ok && retried >= maxRetry
+	if !lastAttempt && !errors.Is(err, asynq.SkipRetry) {
+		return
+	}
+
+	var payload DeliverWebhookPayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return
+	}
+
+	// the task context may be done already, e.g. if it timed out
+	err = processor.store.FailWebhookDelivery(context.WithoutCancel(ctx), payload.DeliveryID)
+	if err != nil {
+		log.Error().Err(err).Int64("delivery_id", payload.DeliveryID).
+			Msg("failed to mark webhook delivery failed")
+	}
+}
+
+// deliverWebhook POSTs the event of a pending delivery to its endpoint and records the attempt.
+// A failed attempt is retried unless it's the last one, which marks the delivery failed.
+func (processor *RedisTaskProcessor) deliverWebhook(ctx context.Context, deliveryID int64, lastAttempt bool) error {
+	request, err := processor.store.GetWebhookDeliveryRequest(ctx, deliveryID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("delivery doesn't exist: %w", asynq.SkipRetry)
+		}
+		return fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+
+	// the delivery may have been enqueued again by the dispatcher after it was attempted
+	if request.Status != db.WebhookDeliveryPending {
+		return nil
+	}
+
+	body, err := json.Marshal(webhook.Event{
+		ID:        request.EventID,
+		Type:      request.EventType,
+		AccountID: request.AccountID.Int64,
+		CreatedAt: request.EventCreatedAt,
+		Data:      request.Payload,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook event: %w", asynq.SkipRetry)
+	}
+
+	responseStatus, deliveryErr := processor.postWebhook(ctx, request, body)
+
+	arg := db.UpdateWebhookDeliveryAttemptParams{
+		ID:             request.ID,
+		Status:         db.WebhookDeliverySucceeded,
+		ResponseStatus: sql.NullInt32{Int32: int32(responseStatus), Valid: responseStatus != 0},
+	}
+	if deliveryErr != nil {
+		arg.Status = db.WebhookDeliveryPending
+		if lastAttempt {
+			arg.Status = db.WebhookDeliveryFailed
+		}
+		arg.LastError = deliveryErr.Error()
+	}
+
+	_, err = processor.store.UpdateWebhookDeliveryAttempt(ctx, arg)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+
+	if deliveryErr != nil {
+		return fmt.Errorf("failed to deliver webhook: %w", deliveryErr)
+	}
+	return nil
+}
+
+// postWebhook sends the signed body to the endpoint,
+// returning the response status, or 0 if the endpoint didn't respond
+func (processor *RedisTaskProcessor) postWebhook(ctx context.Context, request db.GetWebhookDeliveryRequestRow, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, request.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("invalid request: %w", err)
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhook.TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(webhook.SignatureHeader, webhook.Sign(request.Secret, timestamp, body))
+	req.Header.Set(webhook.EventHeader, request.EventType)
+	req.Header.Set(webhook.DeliveryHeader, strconv.FormatInt(request.ID, 10))
+
+	res, err := processor.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	// the response body isn't kept, since the owner of the endpoint can read the error of a delivery
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return res.StatusCode, fmt.Errorf("unexpected response status %d", res.StatusCode)
+	}
+	return res.StatusCode, nil
+}
@@ -0,0 +1,244 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hibiken/asynq"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/xmeizh/simplebank/db/mock"
+	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/util"
+	"github.com/xmeizh/simplebank/webhook"
+)
+
+func randomWebhookDeliveryRequest(url string) db.GetWebhookDeliveryRequestRow {
+	return db.GetWebhookDeliveryRequestRow{
+		ID:             util.RandomInt(1, 1000),
+		Status:         db.WebhookDeliveryPending,
+		Url:            url,
+		Secret:         util.RandomString(32),
+		EventID:        util.RandomInt(1, 1000),
+		EventType:      db.EventAccountOpened,
+		AccountID:      sql.NullInt64{Int64: util.RandomInt(1, 1000), Valid: true},
+		Payload:        json.RawMessage(`{"account":{"id":1}}`),
+		EventCreatedAt: time.Now().Truncate(time.Second).UTC(),
+	}
+}
+
+func TestDeliverWebhook(t *testing.T) {
+	testCases := []struct {
+		name          string
+		status        int
+		requestStatus string
+		lastAttempt   bool
+		buildStubs    func(store *mockdb.MockStore, request db.GetWebhookDeliveryRequestRow)
+		checkResult   func(t *testing.T, received int, err error)
+	}{
+		{
+			name:          "OK",
+			status:        http.StatusNoContent,
+			requestStatus: db.WebhookDeliveryPending,
+			buildStubs: func(store *mockdb.MockStore, request db.GetWebhookDeliveryRequestRow) {
+				store.EXPECT().
+					UpdateWebhookDeliveryAttempt(gomock.Any(), gomock.Eq(db.UpdateWebhookDeliveryAttemptParams{
+						ID:             request.ID,
+						Status:         db.WebhookDeliverySucceeded,
+						ResponseStatus: sql.NullInt32{Int32: http.StatusNoContent, Valid: true},
+					})).
+					Times(1).
+					Return(db.WebhookDelivery{}, nil)
+			},
+			checkResult: func(t *testing.T, received int, err error) {
+				require.NoError(t, err)
+				require.Equal(t, 1, received)
+			},
+		},
+		{
+			name:          "ErrorResponse",
+			status:        http.StatusInternalServerError,
+			requestStatus: db.WebhookDeliveryPending,
+			buildStubs: func(store *mockdb.MockStore, request db.GetWebhookDeliveryRequestRow) {
+				store.EXPECT().
+					UpdateWebhookDeliveryAttempt(gomock.Any(), gomock.Eq(db.UpdateWebhookDeliveryAttemptParams{
+						ID:             request.ID,
+						Status:         db.WebhookDeliveryPending,
+						ResponseStatus: sql.NullInt32{Int32: http.StatusInternalServerError, Valid: true},
+						LastError:      "unexpected response status 500",
+					})).
+					Times(1).
+					Return(db.WebhookDelivery{}, nil)
+			},
+			checkResult: func(t *testing.T, received int, err error) {
+				// the task is retried
+				require.Error(t, err)
+				require.Equal(t, 1, received)
+			},
+		},
+		{
+			name:          "LastAttempt",
+			status:        http.StatusInternalServerError,
+			requestStatus: db.WebhookDeliveryPending,
+			lastAttempt:   true,
+			buildStubs: func(store *mockdb.MockStore, request db.GetWebhookDeliveryRequestRow) {
+				store.EXPECT().
+					UpdateWebhookDeliveryAttempt(gomock.Any(), gomock.Eq(db.UpdateWebhookDeliveryAttemptParams{
+						ID:             request.ID,
+						Status:         db.WebhookDeliveryFailed,
+						ResponseStatus: sql.NullInt32{Int32: http.StatusInternalServerError, Valid: true},
+						LastError:      "unexpected response status 500",
+					})).
+					Times(1).
+					Return(db.WebhookDelivery{}, nil)
+			},
+			checkResult: func(t *testing.T, received int, err error) {
+				require.Error(t, err)
+				require.Equal(t, 1, received)
+			},
+		},
+		{
+			name:          "AlreadyDelivered",
+			status:        http.StatusOK,
+			requestStatus: db.WebhookDeliverySucceeded,
+			buildStubs: func(store *mockdb.MockStore, request db.GetWebhookDeliveryRequestRow) {
+				store.EXPECT().
+					UpdateWebhookDeliveryAttempt(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResult: func(t *testing.T, received int, err error) {
+				require.NoError(t, err)
+				require.Zero(t, received)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			var request db.GetWebhookDeliveryRequestRow
+			received := 0
+
+			receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received++
+				body, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+
+				err = webhook.Verify(request.Secret, r.Header.Get(webhook.TimestampHeader),
+					r.Header.Get(webhook.SignatureHeader), body, time.Minute)
+				require.NoError(t, err)
+				require.Equal(t, request.EventType, r.Header.Get(webhook.EventHeader))
+
+				var event webhook.Event
+				require.NoError(t, json.Unmarshal(body, &event))
+				require.Equal(t, request.EventID, event.ID)
+				require.Equal(t, request.EventType, event.Type)
+				require.Equal(t, request.AccountID.Int64, event.AccountID)
+				require.True(t, request.EventCreatedAt.Equal(event.CreatedAt))
+				require.JSONEq(t, string(request.Payload), string(event.Data))
+
+				w.WriteHeader(tc.status)
+				if tc.status >= 300 {
+					w.Write([]byte("oops"))
+				}
+			}))
+			defer receiver.Close()
+
+			request = randomWebhookDeliveryRequest(receiver.URL)
+			request.Status = tc.requestStatus
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().
+				GetWebhookDeliveryRequest(gomock.Any(), gomock.Eq(request.ID)).
+				Times(1).
+				Return(request, nil)
+			tc.buildStubs(store, request)
+
+			processor := &RedisTaskProcessor{
+				store:      store,
+				httpClient: receiver.Client(),
+			}
+			err := processor.deliverWebhook(context.Background(), request.ID, tc.lastAttempt)
+			tc.checkResult(t, received, err)
+		})
+	}
+}
+
+func TestHandleDeliverWebhookError(t *testing.T) {
+	deliveryID := util.RandomInt(1, 1000)
+	payload, err := json.Marshal(&DeliverWebhookPayload{DeliveryID: deliveryID})
+	require.NoError(t, err)
+	task := asynq.NewTask(TaskDeliverWebhook, payload)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	processor := &RedisTaskProcessor{
+		store: store,
+	}
+
+	// the task is retried, so the delivery stays pending
+	store.EXPECT().FailWebhookDelivery(gomock.Any(), gomock.Any()).Times(0)
+	processor.handleDeliverWebhookError(context.Background(), task, sql.ErrConnDone)
+
+	// the task is archived without being retried
+	store.EXPECT().FailWebhookDelivery(gomock.Any(), gomock.Eq(deliveryID)).Times(1).Return(nil)
+	processor.handleDeliverWebhookError(context.Background(), task, fmt.Errorf("failed: %w", asynq.SkipRetry))
+}
+
+func TestWebhookRetryDelay(t *testing.T) {
+	require.Equal(t, 10*time.Second, webhookRetryDelay(0))
+	require.Equal(t, 20*time.Second, webhookRetryDelay(1))
+	require.Equal(t, 80*time.Second, webhookRetryDelay(3))
+	require.Equal(t, 6*time.Hour, webhookRetryDelay(webhookMaxRetry))
+	require.Equal(t, 6*time.Hour, webhookRetryDelay(100))
+}
+
+func TestDeliverWebhookToLoopback(t *testing.T) {
+	received := 0
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received++
+	}))
+	defer receiver.Close()
+
+	request := randomWebhookDeliveryRequest(receiver.URL)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetWebhookDeliveryRequest(gomock.Any(), gomock.Eq(request.ID)).
+		Times(1).
+		Return(request, nil)
+	store.EXPECT().
+		UpdateWebhookDeliveryAttempt(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg db.UpdateWebhookDeliveryAttemptParams) (db.WebhookDelivery, error) {
+			require.Equal(t, request.ID, arg.ID)
+			require.Equal(t, db.WebhookDeliveryPending, arg.Status)
+			require.False(t, arg.ResponseStatus.Valid)
+			require.Contains(t, arg.LastError, webhook.ErrForbiddenAddress.Error())
+			return db.WebhookDelivery{}, nil
+		})
+
+	processor := &RedisTaskProcessor{
+		store:      store,
+		httpClient: webhook.NewClient(webhookRequestTimeout),
+	}
+	err := processor.deliverWebhook(context.Background(), request.ID, false)
+	require.ErrorIs(t, err, webhook.ErrForbiddenAddress)
+	require.Zero(t, received)
+}
@@ -0,0 +1,131 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+	db "github.com/xmeizh/simplebank/db/postgresql"
+)
+
+const TaskExecuteScheduledTransfer = "task:execute_scheduled_transfer"
+
+type ExecuteScheduledTransferPayload struct {
+	ScheduledTransferID int64     `json:"scheduled_transfer_id"`
+	RunAt               time.Time `json:"run_at"`
+}
+
+func (distributor *RedisTaskDistributor) DistributeTaskExecuteScheduledTransfer(
+	ctx context.Context,
+	payload *ExecuteScheduledTransferPayload,
+	opts ...asynq.Option,
+) error {
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task payload: %w", err)
+	}
+
+	task := asynq.NewTask(TaskExecuteScheduledTransfer, jsonPayload, opts...)
+	info, err := distributor.client.EnqueueContext(ctx, task)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue task: %w", err)
+	}
+
+	log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).
+		Str("queue", info.Queue).Int("max_retry", info.MaxRetry).Msg("enqueued task")
+
+	return nil
+}
+
+func (processor *RedisTaskProcessor) ProcessTaskExecuteScheduledTransfer(ctx context.Context, task *asynq.Task) error {
+	var payload ExecuteScheduledTransferPayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", asynq.SkipRetry)
+	}
+
+	scheduledTransfer, err := processor.store.GetScheduledTransfer(ctx, payload.ScheduledTransferID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("scheduled transfer doesn't exist: %w", asynq.SkipRetry)
+		}
+		return fmt.Errorf("failed to get scheduled transfer: %w", err)
+	}
+
+	if scheduledTransfer.Status != db.ScheduledTransferActive || !scheduledTransfer.NextRunAt.Equal(payload.RunAt) {
+		log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).
+			Str("status", scheduledTransfer.Status).Msg("scheduled transfer is not due, skip task")
+		return nil
+	}
+
+	arg := db.RecordScheduledTransferRunTxParams{
+		ScheduledTransferID: scheduledTransfer.ID,
+		RunAt:               payload.RunAt,
+	}
+
+	// The run is recorded in the transaction of the transfer, which is rolled back if the schedule
+	// was cancelled meanwhile. The idempotency key makes sure a retried task doesn't book the same run twice.
+	var run db.RecordScheduledTransferRunTxResult
+	_, err = processor.store.TransferTx(ctx, db.TransferTxParams{
+		FromAccountID:  scheduledTransfer.FromAccountID,
+		ToAccountID:    scheduledTransfer.ToAccountID,
+		Amount:         scheduledTransfer.Amount,
+		Username:       scheduledTransfer.Owner,
+		IdempotencyKey: fmt.Sprintf("scheduled_transfer:%d:%d", scheduledTransfer.ID, payload.RunAt.Unix()),
+		AfterTransfer: func(q db.Querier, result db.TransferTxResult) error {
+			arg.TransferID = result.Transfer.ID
+			var err error
+			run, err = db.RecordScheduledTransferRun(ctx, q, arg)
+			if err != nil {
+				return err
+			}
+			return DistributeTransferNotifications(ctx, NewOutboxTaskDistributor(q), result.Transfer)
+		},
+	})
+	if err != nil {
+		if errors.Is(err, db.ErrScheduledTransferNotDue) {
+			log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).
+				Msg("scheduled transfer is no longer due, transfer rolled back")
+			return nil
+		}
+		if !isPermanentTransferError(err) {
+			return fmt.Errorf("failed to execute scheduled transfer: %w", err)
+		}
+
+		arg.TransferID = 0
+		arg.FailureReason = err.Error()
+		run, err = processor.store.RecordScheduledTransferRunTx(ctx, arg)
+		if err != nil {
+			if errors.Is(err, db.ErrScheduledTransferNotDue) {
+				log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).
+					Msg("scheduled transfer run was already recorded")
+				return nil
+			}
+			return fmt.Errorf("failed to record scheduled transfer run: %w", err)
+		}
+	}
+
+	// a replayed transfer skips AfterTransfer, its run was recorded by the task that booked it
+	if run.Run.ID == 0 {
+		log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).
+			Msg("scheduled transfer run was already recorded")
+		return nil
+	}
+
+	log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).
+		Str("outcome", run.Run.Status).Str("failure_reason", run.Run.FailureReason).
+		Time("next_run_at", run.ScheduledTransfer.NextRunAt).Msg("processed task")
+	return nil
+}
+
+// isPermanentTransferError reports whether retrying the transfer can't succeed,
+// in which case the run is recorded as failed instead of being retried
+func isPermanentTransferError(err error) bool {
+	return errors.Is(err, db.ErrInsufficientFunds) ||
+		errors.Is(err, db.ErrIdempotencyKeyConflict) ||
+		errors.Is(err, sql.ErrNoRows)
+}
@@ -0,0 +1,171 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hibiken/asynq"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/xmeizh/simplebank/db/mock"
+	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/util"
+)
+
+func TestProcessTaskExecuteScheduledTransfer(t *testing.T) {
+	scheduledTransfer := db.ScheduledTransfer{
+		ID:              util.RandomInt(1, 1000),
+		Owner:           util.RandomOwner(),
+		FromAccountID:   util.RandomInt(1, 1000),
+		ToAccountID:     util.RandomInt(1001, 2000),
+		Amount:          util.RandomMoney(),
+		IntervalSeconds: 3600,
+		NextRunAt:       time.Now().Truncate(time.Second).UTC(),
+		Status:          db.ScheduledTransferActive,
+	}
+	cancelled := scheduledTransfer
+	cancelled.Status = db.ScheduledTransferCancelled
+
+	result := db.TransferTxResult{
+		Transfer: db.Transfer{
+			ID:            util.RandomInt(1, 1000),
+			FromAccountID: scheduledTransfer.FromAccountID,
+			ToAccountID:   scheduledTransfer.ToAccountID,
+			Amount:        scheduledTransfer.Amount,
+		},
+	}
+
+	// runAfterTransfer books the transfer like TransferTx, running its AfterTransfer with the store as the queries
+	runAfterTransfer := func(store *mockdb.MockStore) func(ctx context.Context, arg db.TransferTxParams) (db.TransferTxResult, error) {
+		return func(ctx context.Context, arg db.TransferTxParams) (db.TransferTxResult, error) {
+			err := arg.AfterTransfer(store, result)
+			return result, err
+		}
+	}
+
+	testCases := []struct {
+		name        string
+		buildStubs  func(store *mockdb.MockStore)
+		checkResult func(t *testing.T, err error)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduledTransfer.ID)).Times(1).Return(scheduledTransfer, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(runAfterTransfer(store))
+				store.EXPECT().GetScheduledTransferForUpdate(gomock.Any(), gomock.Eq(scheduledTransfer.ID)).Times(1).Return(scheduledTransfer, nil)
+				store.EXPECT().
+					CreateScheduledTransferRun(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.CreateScheduledTransferRunParams) (db.ScheduledTransferRun, error) {
+						require.Equal(t, db.ScheduledTransferRunSucceeded, arg.Status)
+						require.Equal(t, result.Transfer.ID, arg.TransferID.Int64)
+						return db.ScheduledTransferRun{ID: 1, Status: arg.Status}, nil
+					})
+				store.EXPECT().UpdateScheduledTransferNextRun(gomock.Any(), gomock.Any()).Times(1).Return(scheduledTransfer, nil)
+				store.EXPECT().CreateOutboxMessage(gomock.Any(), gomock.Any()).Times(2).Return(db.Outbox{}, nil)
+				store.EXPECT().RecordScheduledTransferRunTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResult: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "CancelledDuringTransfer",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduledTransfer.ID)).Times(1).Return(scheduledTransfer, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(runAfterTransfer(store))
+				store.EXPECT().GetScheduledTransferForUpdate(gomock.Any(), gomock.Eq(scheduledTransfer.ID)).Times(1).Return(cancelled, nil)
+				store.EXPECT().CreateScheduledTransferRun(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateOutboxMessage(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().RecordScheduledTransferRunTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResult: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "InsufficientFunds",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduledTransfer.ID)).Times(1).Return(scheduledTransfer, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{}, db.ErrInsufficientFunds)
+				store.EXPECT().
+					RecordScheduledTransferRunTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg db.RecordScheduledTransferRunTxParams) (db.RecordScheduledTransferRunTxResult, error) {
+						require.Zero(t, arg.TransferID)
+						require.Equal(t, db.ErrInsufficientFunds.Error(), arg.FailureReason)
+						return db.RecordScheduledTransferRunTxResult{
+							ScheduledTransfer: scheduledTransfer,
+							Run:               db.ScheduledTransferRun{ID: 1, Status: db.ScheduledTransferRunFailed},
+						}, nil
+					})
+			},
+			checkResult: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "Replayed",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduledTransfer.ID)).Times(1).Return(scheduledTransfer, nil)
+				// a replayed transfer doesn't run AfterTransfer
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(result, nil)
+				store.EXPECT().RecordScheduledTransferRunTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResult: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "NotDue",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduledTransfer.ID)).Times(1).Return(cancelled, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResult: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name: "TransferError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduledTransfer.ID)).Times(1).Return(scheduledTransfer, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{}, context.DeadlineExceeded)
+				store.EXPECT().RecordScheduledTransferRunTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResult: func(t *testing.T, err error) {
+				// the task is retried
+				require.Error(t, err)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			processor := &RedisTaskProcessor{
+				store: store,
+			}
+
+			payload, err := json.Marshal(&ExecuteScheduledTransferPayload{
+				ScheduledTransferID: scheduledTransfer.ID,
+				RunAt:               scheduledTransfer.NextRunAt,
+			})
+			require.NoError(t, err)
+
+			task := asynq.NewTask(TaskExecuteScheduledTransfer, payload)
+			err = processor.ProcessTaskExecuteScheduledTransfer(context.Background(), task)
+			tc.checkResult(t, err)
+		})
+	}
+}
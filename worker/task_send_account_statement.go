@@ -0,0 +1,151 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/mail/templates"
+	"github.com/xmeizh/simplebank/statement"
+)
+
+const TaskSendAccountStatement = "task:send_account_statement"
+
+type SendAccountStatementPayload struct {
+	AccountID int64 `json:"account_id"`
+	// Month is any time within the month the statement covers
+	Month time.Time `json:"month"`
+}
+
+func (distributor *RedisTaskDistributor) DistributeTaskSendAccountStatement(
+	ctx context.Context,
+	payload *SendAccountStatementPayload,
+	opts ...asynq.Option,
+) error {
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task payload: %w", err)
+	}
+
+	task := asynq.NewTask(TaskSendAccountStatement, jsonPayload, opts...)
+	info, err := distributor.client.EnqueueContext(ctx, task)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue task: %w", err)
+	}
+
+	log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).
+		Str("queue", info.Queue).Int("max_retry", info.MaxRetry).Msg("enqueued task")
+
+	return nil
+}
+
+func (processor *RedisTaskProcessor) ProcessTaskSendAccountStatement(ctx context.Context, task *asynq.Task) error {
+	var payload SendAccountStatementPayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", asynq.SkipRetry)
+	}
+
+	account, err := processor.store.GetAccount(ctx, payload.AccountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("account doesn't exist: %w", asynq.SkipRetry)
+		}
+		return fmt.Errorf("failed to get account: %w", err)
+	}
+
+	user, err := processor.store.GetUser(ctx, account.Owner)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("user doesn't exist: %w", asynq.SkipRetry)
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if !user.IsEmailVerified {
+		return fmt.Errorf("email of user %s is not verified: %w", user.Username, asynq.SkipRetry)
+	}
+
+	locale, err := processor.emailLocale(ctx, user.Username)
+	if err != nil {
+		return err
+	}
+
+	fromTime, toTime := statement.MonthPeriod(payload.Month)
+	result, err := processor.store.AccountStatementTx(ctx, db.AccountStatementTxParams{
+		AccountID: account.ID,
+		FromTime:  fromTime,
+		ToTime:    toTime,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get account statement: %w", err)
+	}
+
+	accountStatement := statement.Statement{
+		Account:        account,
+		FullName:       user.FullName,
+		FromTime:       fromTime,
+		ToTime:         toTime,
+		OpeningBalance: result.OpeningBalance,
+		ClosingBalance: result.ClosingBalance,
+		Entries:        result.Entries,
+	}
+
+	dir, err := os.MkdirTemp("", "statement")
+	if err != nil {
+		return fmt.Errorf("failed to create statement directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := fmt.Sprintf("statement_%d_%s", account.ID, fromTime.Format("2006-01"))
+	csvFile := filepath.Join(dir, name+".csv")
+	pdfFile := filepath.Join(dir, name+".pdf")
+
+	if err := writeStatementFile(csvFile, accountStatement, statement.WriteCSV); err != nil {
+		return fmt.Errorf("failed to write statement csv: %w", err)
+	}
+	if err := writeStatementFile(pdfFile, accountStatement, statement.WritePDF); err != nil {
+		return fmt.Errorf("failed to write statement pdf: %w", err)
+	}
+
+	// send email to user
+	email, err := processor.templates.Render(templates.AccountStatementEmail, locale, templates.AccountStatementEmailData{
+		FullName:  user.FullName,
+		AccountID: account.ID,
+		Currency:  account.Currency,
+		Month:     fromTime,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render account statement email: %w", err)
+	}
+
+	to := []string{user.Email}
+	err = processor.mailSender.SendEmail(email.Subject, email.Body, to, nil, nil, []string{csvFile, pdfFile})
+	if err != nil {
+		return fmt.Errorf("failed to send account statement email: %w", err)
+	}
+
+	log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).
+		Str("email", user.Email).Int("entries", len(result.Entries)).Msg("processed task")
+	return nil
+}
+
+func writeStatementFile(path string, accountStatement statement.Statement, render func(io.Writer, statement.Statement) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := render(file, accountStatement); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,177 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/mail/templates"
+	"github.com/xmeizh/simplebank/util"
+)
+
+const TaskSendTransferNotification = "task:send_transfer_notification"
+
+// Sides of a transfer a notification is sent for
+const (
+	TransferDebit  = "debit"
+	TransferCredit = "credit"
+)
+
+type SendTransferNotificationPayload struct {
+	TransferID int64 `json:"transfer_id"`
+	// TransferDebit notifies the owner of the source account, TransferCredit the owner of the destination account
+	Direction string `json:"direction"`
+}
+
+func (distributor *RedisTaskDistributor) DistributeTaskSendTransferNotification(
+	ctx context.Context,
+	payload *SendTransferNotificationPayload,
+	opts ...asynq.Option,
+) error {
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task payload: %w", err)
+	}
+	return distributor.DistributeTask(ctx, TaskSendTransferNotification, jsonPayload, opts...)
+}
+
+// DistributeTransferNotifications distributes the notifications of both sides of the transfer.
+// Each side is its own task, so a retry of one never sends the other twice.
+func DistributeTransferNotifications(ctx context.Context, distributor TaskDistributor, transfer db.Transfer) error {
+	opts := []asynq.Option{
+		asynq.MaxRetry(10),
+		asynq.Queue(QueueDefault),
+	}
+
+	for _, direction := range []string{TransferDebit, TransferCredit} {
+		payload := &SendTransferNotificationPayload{
+			TransferID: transfer.ID,
+			Direction:  direction,
+		}
+
+		err := distributor.DistributeTaskSendTransferNotification(ctx, payload, opts...)
+		if err != nil {
+			return fmt.Errorf("failed to distribute task to send %s notification of transfer [%d]: %w", direction, transfer.ID, err)
+		}
+	}
+	return nil
+}
+
+func (processor *RedisTaskProcessor) ProcessTaskSendTransferNotification(ctx context.Context, task *asynq.Task) error {
+	var payload SendTransferNotificationPayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", asynq.SkipRetry)
+	}
+
+	transfer, err := processor.store.GetTransfer(ctx, payload.TransferID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("transfer doesn't exist: %w", asynq.SkipRetry)
+		}
+		return fmt.Errorf("failed to get transfer: %w", err)
+	}
+
+	var accountID, otherAccountID, amount int64
+	switch payload.Direction {
+	case TransferDebit:
+		accountID, otherAccountID, amount = transfer.FromAccountID, transfer.ToAccountID, transfer.Amount
+	case TransferCredit:
+		accountID, otherAccountID, amount = transfer.ToAccountID, transfer.FromAccountID, transfer.ConvertedAmount
+	default:
+		return fmt.Errorf("unknown transfer direction %q: %w", payload.Direction, asynq.SkipRetry)
+	}
+
+	account, err := processor.store.GetAccount(ctx, accountID)
+	if err != nil {
+		// the account may have been closed since the transfer
+		if err == sql.ErrNoRows {
+			log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).
+				Msg("skipped task, account doesn't exist")
+			return nil
+		}
+		return fmt.Errorf("failed to get account: %w", err)
+	}
+
+	preferences, err := processor.notificationPreferences(ctx, account.Owner)
+	if err != nil {
+		return err
+	}
+
+	enabled := preferences.TransferDebitEmails
+	if payload.Direction == TransferCredit {
+		enabled = preferences.TransferCreditEmails
+	}
+	if !enabled {
+		log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).
+			Msg("skipped task, user turned off these notifications")
+		return nil
+	}
+
+	user, err := processor.store.GetUser(ctx, account.Owner)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	// unverified addresses may not belong to the user, so they aren't told about their money
+	if !user.IsEmailVerified {
+		log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).
+			Msg("skipped task, email isn't verified")
+		return nil
+	}
+
+	// send email to user
+	name := templates.TransferDebitEmail
+	if payload.Direction == TransferCredit {
+		name = templates.TransferCreditEmail
+	}
+	email, err := processor.templates.Render(name, preferences.Locale, templates.TransferEmailData{
+		FullName:       user.FullName,
+		Amount:         fmt.Sprintf("%s %s", util.FormatAmount(amount, account.Currency), account.Currency),
+		AccountID:      account.ID,
+		OtherAccountID: otherAccountID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render transfer notification email: %w", err)
+	}
+
+	to := []string{user.Email}
+	err = processor.mailSender.SendEmail(email.Subject, email.Body, to, nil, nil, nil)
+
+	if err != nil {
+		return fmt.Errorf("failed to send transfer notification email: %w", err)
+	}
+
+	log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).
+		Str("email", user.Email).Msg("processed task")
+	return nil
+}
+
+// notificationPreferences returns the preferences of the user, or the defaults if they never set any
+func (processor *RedisTaskProcessor) notificationPreferences(ctx context.Context, username string) (db.NotificationPreference, error) {
+	preferences, err := processor.store.GetNotificationPreferences(ctx, username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return db.NotificationPreference{
+				Username:             username,
+				TransferDebitEmails:  true,
+				TransferCreditEmails: true,
+				Locale:               templates.DefaultLocale,
+			}, nil
+		}
+		return db.NotificationPreference{}, fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+	return preferences, nil
+}
+
+// emailLocale returns the locale of the emails sent to the user
+func (processor *RedisTaskProcessor) emailLocale(ctx context.Context, username string) (string, error) {
+	preferences, err := processor.notificationPreferences(ctx, username)
+	if err != nil {
+		return "", err
+	}
+	return preferences.Locale, nil
+}
@@ -0,0 +1,202 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/hibiken/asynq"
+	"github.com/stretchr/testify/require"
+	mockdb "github.com/xmeizh/simplebank/db/mock"
+	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/mail"
+	"github.com/xmeizh/simplebank/mail/templates"
+	"github.com/xmeizh/simplebank/util"
+)
+
+// sentEmail is an email recorded by recordingEmailSender
+type sentEmail struct {
+	subject string
+	body    mail.Body
+	to      []string
+}
+
+// recordingEmailSender records the emails instead of sending them
+type recordingEmailSender struct {
+	sent []sentEmail
+}
+
+func (sender *recordingEmailSender) SendEmail(subject string, body mail.Body, to []string, cc []string, bcc []string, attachFiles []string) error {
+	sender.sent = append(sender.sent, sentEmail{subject: subject, body: body, to: to})
+	return nil
+}
+
+func TestProcessTaskSendTransferNotification(t *testing.T) {
+	fromUser := randomVerifiedUser()
+	toUser := randomVerifiedUser()
+	fromAccount := db.Account{ID: util.RandomInt(1, 1000), Owner: fromUser.Username, Currency: util.USD}
+	toAccount := db.Account{ID: util.RandomInt(1001, 2000), Owner: toUser.Username, Currency: util.EUR}
+	transfer := db.Transfer{
+		ID:              util.RandomInt(1, 1000),
+		FromAccountID:   fromAccount.ID,
+		ToAccountID:     toAccount.ID,
+		Amount:          1234,
+		ConvertedAmount: 1100,
+		ExchangeRate:    0.89,
+	}
+
+	testCases := []struct {
+		name        string
+		direction   string
+		buildStubs  func(store *mockdb.MockStore)
+		checkResult func(t *testing.T, sent []sentEmail, err error)
+	}{
+		{
+			name:      "Debit",
+			direction: TransferDebit,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetNotificationPreferences(gomock.Any(), gomock.Eq(fromUser.Username)).Times(1).
+					Return(db.NotificationPreference{}, sql.ErrNoRows)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(fromUser.Username)).Times(1).Return(fromUser, nil)
+			},
+			checkResult: func(t *testing.T, sent []sentEmail, err error) {
+				require.NoError(t, err)
+				require.Len(t, sent, 1)
+				require.Equal(t, []string{fromUser.Email}, sent[0].to)
+				require.Equal(t, "You sent 12.34 USD", sent[0].subject)
+				require.Contains(t, sent[0].body.Text, fromUser.FullName)
+				require.Contains(t, sent[0].body.HTML, fromUser.FullName)
+			},
+		},
+		{
+			name:      "Credit",
+			direction: TransferCredit,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				store.EXPECT().GetNotificationPreferences(gomock.Any(), gomock.Eq(toUser.Username)).Times(1).
+					Return(db.NotificationPreference{Username: toUser.Username, TransferCreditEmails: true}, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(toUser.Username)).Times(1).Return(toUser, nil)
+			},
+			checkResult: func(t *testing.T, sent []sentEmail, err error) {
+				require.NoError(t, err)
+				require.Len(t, sent, 1)
+				require.Equal(t, []string{toUser.Email}, sent[0].to)
+				require.Equal(t, "You received 11.00 EUR", sent[0].subject)
+			},
+		},
+		{
+			name:      "Locale",
+			direction: TransferCredit,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				store.EXPECT().GetNotificationPreferences(gomock.Any(), gomock.Eq(toUser.Username)).Times(1).
+					Return(db.NotificationPreference{Username: toUser.Username, TransferCreditEmails: true, Locale: "fr-CA"}, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(toUser.Username)).Times(1).Return(toUser, nil)
+			},
+			checkResult: func(t *testing.T, sent []sentEmail, err error) {
+				require.NoError(t, err)
+				require.Len(t, sent, 1)
+				require.Equal(t, "Vous avez reçu 11.00 EUR", sent[0].subject)
+			},
+		},
+		{
+			name:      "TurnedOff",
+			direction: TransferDebit,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetNotificationPreferences(gomock.Any(), gomock.Eq(fromUser.Username)).Times(1).
+					Return(db.NotificationPreference{Username: fromUser.Username, TransferCreditEmails: true}, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResult: func(t *testing.T, sent []sentEmail, err error) {
+				require.NoError(t, err)
+				require.Empty(t, sent)
+			},
+		},
+		{
+			name:      "EmailNotVerified",
+			direction: TransferCredit,
+			buildStubs: func(store *mockdb.MockStore) {
+				unverifiedUser := toUser
+				unverifiedUser.IsEmailVerified = false
+
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				store.EXPECT().GetNotificationPreferences(gomock.Any(), gomock.Eq(toUser.Username)).Times(1).
+					Return(db.NotificationPreference{}, sql.ErrNoRows)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(toUser.Username)).Times(1).Return(unverifiedUser, nil)
+			},
+			checkResult: func(t *testing.T, sent []sentEmail, err error) {
+				require.NoError(t, err)
+				require.Empty(t, sent)
+			},
+		},
+		{
+			name:      "AccountClosed",
+			direction: TransferCredit,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+				store.EXPECT().GetNotificationPreferences(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResult: func(t *testing.T, sent []sentEmail, err error) {
+				require.NoError(t, err)
+				require.Empty(t, sent)
+			},
+		},
+		{
+			name:      "UnknownDirection",
+			direction: "refund",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResult: func(t *testing.T, sent []sentEmail, err error) {
+				require.ErrorIs(t, err, asynq.SkipRetry)
+				require.Empty(t, sent)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+			tc.buildStubs(store)
+
+			renderer, err := templates.New("http://localhost:8080")
+			require.NoError(t, err)
+
+			mailSender := &recordingEmailSender{}
+			processor := &RedisTaskProcessor{
+				store:      store,
+				mailSender: mailSender,
+				templates:  renderer,
+			}
+
+			payload, err := json.Marshal(&SendTransferNotificationPayload{
+				TransferID: transfer.ID,
+				Direction:  tc.direction,
+			})
+			require.NoError(t, err)
+
+			task := asynq.NewTask(TaskSendTransferNotification, payload)
+			err = processor.ProcessTaskSendTransferNotification(context.Background(), task)
+			tc.checkResult(t, mailSender.sent, err)
+		})
+	}
+}
+
+func randomVerifiedUser() db.User {
+	return db.User{
+		Username:        util.RandomOwner(),
+		FullName:        util.RandomOwner(),
+		Email:           util.RandomEmail(),
+		IsEmailVerified: true,
+	}
+}
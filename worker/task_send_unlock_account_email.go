@@ -0,0 +1,100 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/mail/templates"
+	"github.com/xmeizh/simplebank/util"
+)
+
+const TaskSendUnlockAccountEmail = "task:send_unlock_account_email"
+
+type SendUnlockAccountEmailPayload struct {
+	Username string `json:"username"`
+}
+
+func (distributor *RedisTaskDistributor) DistributeTaskSendUnlockAccountEmail(
+	ctx context.Context,
+	payload *SendUnlockAccountEmailPayload,
+	opts ...asynq.Option,
+) error {
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task payload: %w", err)
+	}
+
+	task := asynq.NewTask(TaskSendUnlockAccountEmail, jsonPayload, opts...)
+	info, err := distributor.client.EnqueueContext(ctx, task)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue task: %w", err)
+	}
+
+	log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).
+		Str("queue", info.Queue).Int("max_retry", info.MaxRetry).Msg("enqueued task")
+
+	return nil
+}
+
+func (processor *RedisTaskProcessor) ProcessTaskSendUnlockAccountEmail(ctx context.Context, task *asynq.Task) error {
+	var payload SendUnlockAccountEmailPayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", asynq.SkipRetry)
+	}
+
+	user, err := processor.store.GetUser(ctx, payload.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("user doesn't exist: %w", asynq.SkipRetry)
+		}
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	locale, err := processor.emailLocale(ctx, user.Username)
+	if err != nil {
+		return err
+	}
+
+	// only the hash is stored, the code itself is only ever sent in the email
+	secretCode, err := util.GenerateSecretCode()
+	if err != nil {
+		return fmt.Errorf("failed to generate secret code: %w", err)
+	}
+
+	accountUnlock, err := processor.store.CreateAccountUnlock(ctx, db.CreateAccountUnlockParams{
+		Username:   user.Username,
+		HashedCode: util.HashSecretCode(secretCode),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create account unlock: %w", err)
+	}
+
+	// send email to user
+	query := url.Values{}
+	query.Set("unlock_id", fmt.Sprint(accountUnlock.ID))
+	query.Set("secret_code", secretCode)
+	email, err := processor.templates.Render(templates.UnlockAccountEmail, locale, templates.UnlockAccountEmailData{
+		FullName:  user.FullName,
+		UnlockURL: fmt.Sprintf("%s/v1/unlock_account?%s", processor.config.PublicBaseURL, query.Encode()),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render unlock account email: %w", err)
+	}
+
+	to := []string{user.Email}
+	err = processor.mailSender.SendEmail(email.Subject, email.Body, to, nil, nil, nil)
+
+	if err != nil {
+		return fmt.Errorf("failed to send unlock account email: %w", err)
+	}
+
+	log.Info().Str("type", task.Type()).Bytes("payload", task.Payload()).
+		Str("email", user.Email).Msg("processed task")
+	return nil
+}
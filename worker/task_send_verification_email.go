@@ -5,10 +5,12 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"net/url"
 
 	"github.com/hibiken/asynq"
 	"github.com/rs/zerolog/log"
 	db "github.com/xmeizh/simplebank/db/postgresql"
+	"github.com/xmeizh/simplebank/mail/templates"
 	"github.com/xmeizh/simplebank/util"
 )
 
@@ -54,6 +56,11 @@ func (processor *RedisTaskProcessor) ProcessTaskSendVerificationEmail(ctx contex
 		return fmt.Errorf("failed to get user: %w", err)
 	}
 
+	locale, err := processor.emailLocale(ctx, user.Username)
+	if err != nil {
+		return err
+	}
+
 	arg := db.CreateVerifyEmailParams{
 		Username:   user.Username,
 		Email:      user.Email,
@@ -66,14 +73,19 @@ func (processor *RedisTaskProcessor) ProcessTaskSendVerificationEmail(ctx contex
 	}
 
 	// send email to user
-	subject := "Welcome to Simple Bank"
-	verifyUrl := fmt.Sprintf("http://localhost:8080/v1/verify_email?email_id=%d&secret_code=%s", verifyEmail.ID, verifyEmail.SecretCode)
-	content := fmt.Sprintf(`Hello %s,<br/>
-	Thank you for registering with us!<br/>
-	Please <a href="%s">click here</a> to verify your email address.<br/>
-	`, user.FullName, verifyUrl)
+	query := url.Values{}
+	query.Set("email_id", fmt.Sprint(verifyEmail.ID))
+	query.Set("secret_code", verifyEmail.SecretCode)
+	email, err := processor.templates.Render(templates.VerificationEmail, locale, templates.VerificationEmailData{
+		FullName:  user.FullName,
+		VerifyURL: fmt.Sprintf("%s/v1/verify_email?%s", processor.config.PublicBaseURL, query.Encode()),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to render verification email: %w", err)
+	}
+
 	to := []string{user.Email}
-	err = processor.mailSender.SendEmail(subject, content, to, nil, nil, nil)
+	err = processor.mailSender.SendEmail(email.Subject, email.Body, to, nil, nil, nil)
 
 	if err != nil {
 		return fmt.Errorf("failed to send verification email: %w", err)
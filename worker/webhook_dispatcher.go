@@ -0,0 +1,90 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+	db "github.com/xmeizh/simplebank/db/postgresql"
+)
+
+// pendingWebhookDeliveriesBatchSize is the max number of pending deliveries loaded at once
+const pendingWebhookDeliveriesBatchSize = 100
+
+// WebhookDispatcher periodically enqueues a task for every pending webhook delivery.
+// The deliveries are created in the transactions recording their events,
+// so the dispatcher is what gets them out once those commit.
+type WebhookDispatcher struct {
+	store       db.Store
+	distributor TaskDistributor
+	interval    time.Duration
+}
+
+func NewWebhookDispatcher(store db.Store, distributor TaskDistributor, interval time.Duration) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		store:       store,
+		distributor: distributor,
+		interval:    interval,
+	}
+}
+
+// Start runs the dispatcher until the context is done
+func (dispatcher *WebhookDispatcher) Start(ctx context.Context) error {
+	ticker := time.NewTicker(dispatcher.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			err := dispatcher.EnqueuePendingDeliveries(ctx)
+			if err != nil {
+				log.Error().Err(err).Msg("failed to enqueue pending webhook deliveries")
+			}
+		}
+	}
+}
+
+// EnqueuePendingDeliveries enqueues the pending deliveries.
+// A delivery that is still queued or waiting for a retry isn't enqueued again, thanks to its task ID.
+func (dispatcher *WebhookDispatcher) EnqueuePendingDeliveries(ctx context.Context) error {
+	var afterID int64
+	for {
+		deliveries, err := dispatcher.store.ListPendingWebhookDeliveries(ctx, db.ListPendingWebhookDeliveriesParams{
+			AfterID: afterID,
+			Limit:   pendingWebhookDeliveriesBatchSize,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list pending webhook deliveries: %w", err)
+		}
+
+		for _, delivery := range deliveries {
+			err = DistributeWebhookDelivery(ctx, dispatcher.distributor, delivery.ID)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(deliveries) < pendingWebhookDeliveriesBatchSize {
+			return nil
+		}
+		afterID = deliveries[len(deliveries)-1].ID
+	}
+}
+
+// DistributeWebhookDelivery enqueues the task of a delivery unless it's already queued
+func DistributeWebhookDelivery(ctx context.Context, distributor TaskDistributor, deliveryID int64) error {
+	payload := &DeliverWebhookPayload{
+		DeliveryID: deliveryID,
+	}
+
+	err := distributor.DistributeTaskDeliverWebhook(ctx, payload, deliverWebhookOptions(deliveryID)...)
+	if err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) {
+		return fmt.Errorf("failed to distribute task to deliver webhook [%d]: %w", deliveryID, err)
+	}
+	return nil
+}